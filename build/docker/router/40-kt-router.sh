@@ -0,0 +1,4 @@
+#!/bin/sh
+# generate route from configmap managed by ktctl, and keep it updated, started before nginx by docker-entrypoint.sh
+/usr/sbin/router sync
+/usr/sbin/router watch &
@@ -0,0 +1,9 @@
+// delay request for $kt_delay milliseconds, then pass it to location $kt_next
+function delay(r) {
+    var ms = parseInt(r.variables.kt_delay) || 0;
+    setTimeout(function () {
+        r.internalRedirect(r.variables.kt_next);
+    }, ms);
+}
+
+export default {delay};
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"github.com/alibaba/kt-connect/pkg/controller"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// ArgKubeconfig application argument for kubeconfig file, use in-cluster config if absent
+	ArgKubeconfig = "--kubeconfig"
+	// ArgNamespace application argument for namespace to watch, watch all namespaces if absent
+	ArgNamespace = "--namespace"
+	// ArgResyncInterval application argument for seconds between re-checking all sessions
+	ArgResyncInterval = "--resync-interval"
+	// ArgLogLevel application argument for log level
+	ArgLogLevel = "--log-level"
+)
+
+func init() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+}
+
+func main() {
+	level, err := zerolog.ParseLevel(getParameter(ArgLogLevel, "info"))
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to parse log level")
+	}
+	zerolog.SetGlobalLevel(level)
+
+	config, err := getRestConfig(getParameter(ArgKubeconfig, ""))
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to load kubernetes config")
+	}
+	clientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to create kubernetes client")
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to create dynamic client")
+	}
+	opt.Store.Clientset = clientSet
+	opt.Store.DynamicClient = dynamicClient
+	opt.Store.RestConfig = config
+
+	resync := 30
+	if v, err2 := strconv.Atoi(getParameter(ArgResyncInterval, "30")); err2 == nil && v > 0 {
+		resync = v
+	}
+	namespace := getParameter(ArgNamespace, "")
+	log.Info().Msgf("Watching KtSessions in namespace '%s', resync every %d seconds", namespace, resync)
+
+	stop := make(chan struct{})
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ch
+		close(stop)
+	}()
+	controller.NewController(cluster.Ins(), dynamicClient, namespace, time.Duration(resync)*time.Second).Run(stop)
+}
+
+func getRestConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig == "" {
+		return rest.InClusterConfig()
+	}
+	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+}
+
+func getParameter(argVar string, defaultValue string) string {
+	for _, arg := range os.Args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) > 1 && kv[0] == argVar && kv[1] != "" {
+			return kv[1]
+		}
+	}
+	return defaultValue
+}
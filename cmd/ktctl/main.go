@@ -4,6 +4,7 @@ import (
 	"github.com/alibaba/kt-connect/pkg/kt/command"
 	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -20,7 +21,7 @@ var (
 func init() {
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, NoColor: util.IsWindows()})
-	for _, dir := range []string{util.KtKeyDir, util.KtPidDir, util.KtLockDir, util.KtProfileDir} {
+	for _, dir := range []string{util.KtKeyDir, util.KtPidDir, util.KtLockDir, util.KtProfileDir, util.KtJournalDir, util.KtMountDir} {
 		_ = util.CreateDirIfNotExist(dir)
 		_ = util.FixFileOwner(dir)
 	}
@@ -48,6 +49,8 @@ func main() {
 	rootCmd.AddCommand(command.NewMeshCommand())
 	rootCmd.AddCommand(command.NewPreviewCommand())
 	rootCmd.AddCommand(command.NewForwardCommand())
+	rootCmd.AddCommand(command.NewEnvCommand())
+	rootCmd.AddCommand(command.NewReplayCommand())
 	rootCmd.AddCommand(command.NewRecoverCommand())
 	rootCmd.AddCommand(command.NewCleanCommand())
 	rootCmd.AddCommand(command.NewConfigCommand())
@@ -62,5 +65,8 @@ func main() {
 	if err := rootCmd.Execute(); err != nil {
 		log.Error().Msgf("Exit: %s", err)
 	}
-	general.CleanupWorkspace()
+	// nothing was created in dry run mode, hence nothing to tear down
+	if !cluster.IsDryRun() {
+		general.CleanupWorkspace()
+	}
 }
@@ -1,13 +1,12 @@
 package main
 
 import (
-	"fmt"
 	"github.com/alibaba/kt-connect/pkg/router"
 	"github.com/gofrs/flock"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"os"
-	"strings"
+	"time"
 )
 
 func init() {
@@ -16,127 +15,103 @@ func init() {
 }
 
 const pathKtLock = "/var/kt.lock"
-const actionSetup = "setup"
-const actionAdd = "add"
-const actionRemove = "remove"
+const actionSync = "sync"
+const actionWatch = "watch"
+const syncInterval = 2 * time.Second
+const watchInterval = 30 * time.Second
 
 func main() {
-	fileLock := flock.New(pathKtLock)
-	if err := fileLock.Lock(); err != nil {
-		log.Error().Err(err).Msgf("Unable to fetch route lock")
+	if len(os.Args) != 2 {
+		usage()
 		return
 	}
-	defer fileLock.Unlock()
-	if len(os.Args) < 3 {
+	switch os.Args[1] {
+	case actionSync:
+		sync()
+	case actionWatch:
+		watch()
+	default:
+		log.Error().Msgf("Invalid action '%s'", os.Args[1])
 		usage()
-	} else {
-		switch os.Args[1] {
-		case actionSetup:
-			setup(os.Args[2:])
-		case actionAdd:
-			add(os.Args[2:])
-		case actionRemove:
-			remove(os.Args[2:])
-		default:
-			log.Error().Msgf("Invalid action '%s'", os.Args[1])
-			usage()
-		}
 	}
 }
 
 func usage() {
 	log.Info().Msgf(`Usage: 
-router %s <service-name> <service-port> <custom-version>
-router %s <custom-version>
-router %s <custom-version>
-`, actionSetup, actionAdd, actionRemove)
+router %s
+router %s
+`, actionSync, actionWatch)
 }
 
-func setup(args []string) {
-	if len(args) < 3 {
-		usage()
-		return
-	}
-	header, version := splitVersionMark(args[2])
-	ktConf := router.KtConf{
-		Service:  args[0],
-		Ports:    getPorts(args[1]),
-		Header:   header,
-		Versions: []string{version},
-	}
-	err := router.WriteKtConf(&ktConf)
+// sync generate route configuration from configmap before nginx started
+func sync() {
+	ktConf, _, err := router.NewSyncer().Load()
 	if err != nil {
-		log.Error().Err(err).Msgf("Write kt config failed")
+		log.Error().Err(err).Msgf("Load route config failed")
 		return
 	}
-	err = router.WriteAndReloadRouteConf(&ktConf)
-	if err != nil {
-		log.Error().Err(err).Msgf("Write and load route config failed")
+	fileLock := flock.New(pathKtLock)
+	if err = fileLock.Lock(); err != nil {
+		log.Error().Err(err).Msgf("Unable to fetch route lock")
 		return
 	}
-	log.Info().Msgf("Route setup completed.")
-}
-
-func add(args []string) {
-	header, version := splitVersionMark(args[0])
-	err := updateRoute(header, version, actionAdd)
-	if err != nil {
-		log.Error().Err(err).Msgf("Update route with add failed")
+	defer fileLock.Unlock()
+	if err = router.WriteKtConf(ktConf); err != nil {
+		log.Error().Err(err).Msgf("Write kt config failed")
 		return
 	}
-	log.Info().Msgf("Route updated.")
-}
-
-func remove(args []string) {
-	header, version := splitVersionMark(args[0])
-	err := updateRoute(header, version, actionRemove)
-	if err != nil {
-		log.Error().Err(err).Msgf("Update route with remove failed" )
+	if err = router.WriteRouteConf(ktConf); err != nil {
+		log.Error().Err(err).Msgf("Write route config failed")
 		return
 	}
-	log.Info().Msgf("Route updated.")
-}
-
-func splitVersionMark(mark string) (string, string) {
-	splits := strings.Split(mark, ":")
-	return strings.ReplaceAll(splits[0], "-", "_"), splits[1]
-}
-
-func getPorts(portsParameter string) [][]string {
-	ports := make([][]string, 0)
-	for _, pp := range strings.Split(portsParameter, ",") {
-		ports = append(ports, strings.Split(pp, ":"))
-	}
-	return ports
+	log.Info().Msgf("Route setup completed.")
 }
 
-func updateRoute(header, version, action string) error {
-	ktConf, err := router.ReadKtConf()
-	if err != nil {
-		return err
-	}
-	if ktConf.Header != header {
-		return fmt.Errorf("specified header '%s' no match mesh pod header '%s'", header, ktConf.Header)
+// watch reload route whenever configmap updated by ktctl, and remove routes of versions
+// whose mesh service keeps unreachable, run in background of router pod
+func watch() {
+	syncer := router.NewSyncer()
+	watchdog := router.NewWatchdog()
+	fileLock := flock.New(pathKtLock)
+	// configuration at startup is already applied by sync action
+	if _, _, err := syncer.Load(); err == nil {
+		syncer.Commit()
 	}
-	switch action {
-	case actionAdd:
-		ktConf.Versions = append(ktConf.Versions, version)
-	case actionRemove:
-		versions := ktConf.Versions
-		for i, v := range versions {
-			if v == version {
-				ktConf.Versions = append(versions[:i], versions[i+1:]...)
-				break
+	lastCheck := time.Now()
+	for range time.Tick(syncInterval) {
+		ktConf, changed, err := syncer.Load()
+		if err != nil {
+			log.Warn().Err(err).Msgf("Load route config failed")
+			continue
+		}
+		if time.Since(lastCheck) >= watchInterval {
+			lastCheck = time.Now()
+			for _, version := range watchdog.Check(ktConf, lastCheck) {
+				syncer.Expire(version)
+				ktConf.Remove(version)
+				changed = true
+				log.Info().Msgf("Mesh service of version '%s' unreachable for too long, route removed", version)
 			}
 		}
+		if !changed {
+			continue
+		}
+		if err = applyRoute(fileLock, ktConf); err != nil {
+			log.Error().Err(err).Msgf("Write and load route config failed")
+			continue
+		}
+		syncer.Commit()
+		log.Info().Msgf("Route updated.")
 	}
-	err = router.WriteKtConf(ktConf)
-	if err != nil {
+}
+
+func applyRoute(fileLock *flock.Flock, ktConf *router.KtConf) error {
+	if err := fileLock.Lock(); err != nil {
 		return err
 	}
-	err = router.WriteAndReloadRouteConf(ktConf)
-	if err != nil {
+	defer fileLock.Unlock()
+	if err := router.WriteKtConf(ktConf); err != nil {
 		return err
 	}
-	return nil
+	return router.WriteAndReloadRouteConf(ktConf)
 }
@@ -3,9 +3,14 @@ package main
 import (
 	"github.com/alibaba/kt-connect/pkg/common"
 	"github.com/alibaba/kt-connect/pkg/shadow/dnsserver"
+	"github.com/alibaba/kt-connect/pkg/shadow/muxserver"
+	"github.com/alibaba/kt-connect/pkg/shadow/readiness"
+	"github.com/alibaba/kt-connect/pkg/shadow/sshserver"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -16,6 +21,14 @@ const (
 	ArgDnsProtocol = "--protocol"
 	// ArgLogLevel application argument for shadow pod log level
 	ArgLogLevel = "--log-level"
+	// ArgDnsPort application argument for shadow pod dns port
+	ArgDnsPort = "--dns-port"
+	// ArgSshPort application argument for shadow pod ssh port
+	ArgSshPort = "--ssh-port"
+	// ArgMuxPort application argument for shadow pod mux tunnel port
+	ArgMuxPort = "--mux-port"
+	// ArgAuthorizedKeys application argument for path of authorized keys file
+	ArgAuthorizedKeys = "--authorized-keys"
 )
 
 func init() {
@@ -29,18 +42,75 @@ func main() {
 		log.Error().Err(err).Msgf("Failed to parse log level")
 	}
 	zerolog.SetGlobalLevel(level)
-	dnsPort := common.StandardDnsPort
-	dnsProtocol := getParameter(common.EnvVarDnsProtocol, ArgDnsProtocol, "udp")
-	localDomain := getParameter(common.EnvVarLocalDomains, ArgLocalDomains, "")
-	log.Info().Msgf("Shadow DNS on %s port %d, log level %s", dnsProtocol, dnsPort, logLevel)
-	if localDomain != "" {
-		log.Info().Msgf("Using local domain %s", localDomain)
-	}
-	dnsserver.Start(dnsPort, dnsProtocol, localDomain)
+
+	authorizedKeys := getParameter("", ArgAuthorizedKeys, defaultAuthorizedKeysFile())
+	dnsProtocol := getParameter(common.EnvVarDnsProtocol, ArgDnsProtocol, "")
+	if dnsProtocol == "" {
+		log.Info().Msgf("Skip shadow DNS")
+	} else {
+		dnsPort := getIntParameter(common.EnvVarDnsPort, ArgDnsPort, common.StandardDnsPort)
+		localDomain := getParameter(common.EnvVarLocalDomains, ArgLocalDomains, "")
+		log.Info().Msgf("Shadow DNS on %s port %d, log level %s", dnsProtocol, dnsPort, logLevel)
+		if localDomain != "" {
+			log.Info().Msgf("Using local domain %s", localDomain)
+		}
+		go dnsserver.Start(dnsPort, dnsProtocol, localDomain)
+	}
+
+	sshServer, err := sshserver.NewSshServer(authorizedKeys)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to create ssh server")
+		os.Exit(1)
+	}
+	muxServer := muxserver.NewMuxServer(authorizedKeys)
+
+	muxPort := getIntParameter("", ArgMuxPort, common.StandardMuxPort)
+	log.Info().Msgf("Shadow mux tunnel on port %d", muxPort)
+	go muxserver.Start(muxPort, muxServer)
+
+	exposePorts := getPortsParameter(common.EnvVarExposePorts)
+	log.Info().Msgf("Shadow readiness probe on port %d, checking ports %v", common.ReadinessPort, exposePorts)
+	go readiness.Start(common.ReadinessPort, common.ReadinessPath, exposePorts, sshServer, muxServer)
+
+	sshPort := getIntParameter(common.EnvVarSshPort, ArgSshPort, common.StandardSshPort)
+	log.Info().Msgf("Shadow SSH on port %d, using authorized keys %s", sshPort, authorizedKeys)
+	sshserver.Start(sshPort, sshServer)
+	os.Exit(1)
+}
+
+// defaultAuthorizedKeysFile authorized keys are mounted to home directory via config map
+func defaultAuthorizedKeysFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "/" {
+		home = "/root"
+	}
+	return filepath.Join(home, "authorized", "authorized_keys")
+}
+
+func getPortsParameter(envVar string) []int {
+	var ports []int
+	for _, p := range strings.Split(os.Getenv(envVar), ",") {
+		if port, err := strconv.Atoi(strings.TrimSpace(p)); err == nil && port > 0 {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+func getIntParameter(envVar string, argVar string, defaultValue int) int {
+	value := getParameter(envVar, argVar, "")
+	if value == "" {
+		return defaultValue
+	}
+	if v, err := strconv.Atoi(value); err == nil && v > 0 {
+		return v
+	}
+	log.Warn().Msgf("Invalid value of %s: %s", argVar, value)
+	return defaultValue
 }
 
 func getParameter(envVar string, argVar string, defaultValue string) string {
-	if os.Getenv(envVar) != "" {
+	if envVar != "" && os.Getenv(envVar) != "" {
 		return os.Getenv(envVar)
 	}
 	for _, arg := range os.Args {
@@ -5,6 +5,7 @@ go 1.18
 require (
 	github.com/fsnotify/fsnotify v1.5.1
 	github.com/gofrs/flock v0.8.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/miekg/dns v1.1.45
 	github.com/mitchellh/go-ps v1.0.0
 	github.com/rs/zerolog v1.26.1
@@ -42,7 +43,6 @@ require (
 	github.com/google/gofuzz v1.1.0 // indirect
 	github.com/google/uuid v1.3.0 // indirect
 	github.com/googleapis/gnostic v0.5.5 // indirect
-	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/imdario/mergo v0.3.5 // indirect
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
@@ -10,6 +10,20 @@ const (
 	StandardSshPort = 22
 	// StandardDnsPort standard dns port
 	StandardDnsPort = 53
+	// StandardMuxPort mux tunnel port of shadow pod
+	StandardMuxPort = 2200
+	// ReadinessPort port of shadow pod serving readiness probe
+	ReadinessPort = 2201
+	// ReadinessPath http path of shadow pod readiness probe
+	ReadinessPath = "/ready"
+	// NonRootSshPort ssh port of shadow pod running as non-root user
+	NonRootSshPort = 2222
+	// NonRootDnsPort dns port of shadow pod running as non-root user
+	NonRootDnsPort = 5353
+	// NonRootUser user of shadow pod running as non-root
+	NonRootUser = "kt"
+	// NonRootUid uid of shadow pod running as non-root
+	NonRootUid = 1000
 
 	// EnvVarLocalDomains environment variable for local domain config
 	EnvVarLocalDomains = "KT_LOCAL_DOMAIN"
@@ -17,4 +31,10 @@ const (
 	EnvVarDnsProtocol = "KT_DNS_PROTOCOL"
 	// EnvVarLogLevel environment variable for shadow pod log level
 	EnvVarLogLevel = "KT_LOG_LEVEL"
+	// EnvVarDnsPort environment variable for shadow pod dns port
+	EnvVarDnsPort = "KT_DNS_PORT"
+	// EnvVarSshPort environment variable for shadow pod ssh port
+	EnvVarSshPort = "KT_SSH_PORT"
+	// EnvVarExposePorts environment variable for ports exposed to local, shadow pod is ready only when all of them reachable
+	EnvVarExposePorts = "KT_EXPOSE_PORTS"
 )
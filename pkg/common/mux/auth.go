@@ -0,0 +1,107 @@
+package mux
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	handshakeMagic   = "KTMUX\x01"
+	nonceSize        = 32
+	handshakeTimeout = 10 * time.Second
+	maxAuthSize      = 8 * 1024
+)
+
+// ErrUnauthorized client key is not authorized
+var ErrUnauthorized = errors.New("mux authentication failed")
+
+type authPayload struct {
+	PublicKey []byte
+	Format    string
+	Signature []byte
+}
+
+// ServerHandshake verify the client holds an authorized private key, by asking it to sign a random nonce
+func ServerHandshake(conn net.Conn, isAuthorized func(ssh.PublicKey) bool) error {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	if _, err := conn.Write(append([]byte(handshakeMagic), nonce...)); err != nil {
+		return err
+	}
+	lengthBuf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, lengthBuf); err != nil {
+		return err
+	}
+	length := binary.BigEndian.Uint32(lengthBuf)
+	if length > maxAuthSize {
+		return fmt.Errorf("authentication payload too large (%d bytes)", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return err
+	}
+	var payload authPayload
+	if err := ssh.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	publicKey, err := ssh.ParsePublicKey(payload.PublicKey)
+	if err == nil && isAuthorized(publicKey) {
+		err = publicKey.Verify(nonce, &ssh.Signature{Format: payload.Format, Blob: payload.Signature})
+	} else if err == nil {
+		err = ErrUnauthorized
+	}
+	if err != nil {
+		_, _ = conn.Write([]byte{1})
+		return ErrUnauthorized
+	}
+	_, err = conn.Write([]byte{0})
+	return err
+}
+
+// ClientHandshake prove to server that client holds the private key
+func ClientHandshake(conn net.Conn, signer ssh.Signer) error {
+	_ = conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	hello := make([]byte, len(handshakeMagic)+nonceSize)
+	if _, err := io.ReadFull(conn, hello); err != nil {
+		return err
+	}
+	if string(hello[:len(handshakeMagic)]) != handshakeMagic {
+		return fmt.Errorf("peer is not a mux tunnel server")
+	}
+	signature, err := signer.Sign(rand.Reader, hello[len(handshakeMagic):])
+	if err != nil {
+		return err
+	}
+	data := ssh.Marshal(&authPayload{
+		PublicKey: signer.PublicKey().Marshal(),
+		Format:    signature.Format,
+		Signature: signature.Blob,
+	})
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+	if _, err = conn.Write(buf); err != nil {
+		return err
+	}
+	result := make([]byte, 1)
+	if _, err = io.ReadFull(conn, result); err != nil {
+		return err
+	}
+	if result[0] != 0 {
+		return ErrUnauthorized
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package mux
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Frame layout: version(1) type(1) stream id(4) length(4) payload(length)
+const (
+	protocolVersion = 1
+	headerSize      = 10
+	// maxPayloadSize max payload length of a single frame
+	maxPayloadSize = 32 * 1024
+)
+
+const (
+	// typeOpen open a stream, payload is the open request
+	typeOpen byte = iota + 1
+	// typeAck stream accepted by peer
+	typeAck
+	// typeReject stream refused by peer, payload is the reason
+	typeReject
+	// typeData stream data
+	typeData
+	// typeWindow receive window update, payload is 4 bytes of increased credit
+	typeWindow
+	// typeClose sender will neither read nor write the stream any more
+	typeClose
+	// typePing keepalive request, stream id is always 0
+	typePing
+	// typePong keepalive response, stream id is always 0
+	typePong
+)
+
+type header [headerSize]byte
+
+func (h *header) encode(frameType byte, streamId uint32, length int) {
+	h[0] = protocolVersion
+	h[1] = frameType
+	binary.BigEndian.PutUint32(h[2:6], streamId)
+	binary.BigEndian.PutUint32(h[6:10], uint32(length))
+}
+
+func (h *header) frameType() byte {
+	return h[1]
+}
+
+func (h *header) streamId() uint32 {
+	return binary.BigEndian.Uint32(h[2:6])
+}
+
+func (h *header) length() uint32 {
+	return binary.BigEndian.Uint32(h[6:10])
+}
+
+func readFrame(r io.Reader, h *header, buf []byte) ([]byte, error) {
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return nil, err
+	}
+	if h[0] != protocolVersion {
+		return nil, fmt.Errorf("unsupported protocol version %d", h[0])
+	}
+	length := h.length()
+	if length > maxPayloadSize {
+		return nil, fmt.Errorf("frame payload too large (%d bytes)", length)
+	}
+	payload := buf[:length]
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,134 @@
+package mux
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func newSessionPair(t *testing.T, config *Config) (*Session, *Session) {
+	c1, c2 := net.Pipe()
+	client := Client(c1, config)
+	server := Server(c2, config)
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	return client, server
+}
+
+func serveEcho(server *Session) {
+	for {
+		st, err := server.Accept()
+		if err != nil {
+			return
+		}
+		req, err := ParseRequest(st.Request())
+		if err != nil || req.Kind != RequestDial {
+			_ = st.Refuse("unexpected request")
+			continue
+		}
+		_ = st.Confirm()
+		go func() {
+			_, _ = io.Copy(st, st)
+			_ = st.Close()
+		}()
+	}
+}
+
+func TestOpenAndEcho(t *testing.T) {
+	client, server := newSessionPair(t, nil)
+	go serveEcho(server)
+
+	st, err := client.Open((&Request{Kind: RequestDial, Address: "127.0.0.1:80"}).Encode())
+	require.Nil(t, err)
+	_, err = st.Write([]byte("hello"))
+	require.Nil(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(st, buf)
+	require.Nil(t, err)
+	require.Equal(t, "hello", string(buf))
+	require.Nil(t, st.Close())
+}
+
+func TestOpenRefused(t *testing.T) {
+	client, server := newSessionPair(t, nil)
+	go serveEcho(server)
+
+	_, err := client.Open([]byte("listen 0.0.0.0:80"))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unexpected request")
+	require.Equal(t, 0, client.NumStreams())
+}
+
+func TestFlowControl(t *testing.T) {
+	config := DefaultConfig()
+	config.WindowSize = 4 * 1024
+	client, server := newSessionPair(t, config)
+	go serveEcho(server)
+
+	st, err := client.Open((&Request{Kind: RequestDial, Address: "127.0.0.1:80"}).Encode())
+	require.Nil(t, err)
+	data := make([]byte, 1024*1024)
+	_, _ = rand.Read(data)
+	go func() {
+		_, _ = st.Write(data)
+	}()
+	received := make([]byte, len(data))
+	_, err = io.ReadFull(st, received)
+	require.Nil(t, err)
+	require.True(t, bytes.Equal(data, received))
+}
+
+func TestKeepAliveTimeout(t *testing.T) {
+	config := DefaultConfig()
+	config.KeepAliveInterval = 20 * time.Millisecond
+	config.KeepAliveTimeout = 50 * time.Millisecond
+	c1, c2 := net.Pipe()
+	client := Client(c1, config)
+	// peer never responds
+	go func() {
+		_, _ = io.Copy(io.Discard, c2)
+	}()
+	select {
+	case <-client.CloseChan():
+		require.Equal(t, ErrKeepAliveTimeout, client.err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("session should be closed by keepalive timeout")
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer, _ := ssh.NewSignerFromKey(key)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherSigner, _ := ssh.NewSignerFromKey(otherKey)
+	isAuthorized := func(k ssh.PublicKey) bool {
+		return bytes.Equal(k.Marshal(), signer.PublicKey().Marshal())
+	}
+
+	for _, s := range []ssh.Signer{signer, otherSigner} {
+		c1, c2 := net.Pipe()
+		res := make(chan error)
+		go func() {
+			res <- ServerHandshake(c2, isAuthorized)
+		}()
+		clientErr := ClientHandshake(c1, s)
+		serverErr := <-res
+		if s == signer {
+			require.Nil(t, clientErr)
+			require.Nil(t, serverErr)
+		} else {
+			require.Equal(t, ErrUnauthorized, clientErr)
+			require.Equal(t, ErrUnauthorized, serverErr)
+		}
+		_ = c1.Close()
+		_ = c2.Close()
+	}
+}
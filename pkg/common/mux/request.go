@@ -0,0 +1,52 @@
+package mux
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	// RequestDial ask peer to dial the address
+	RequestDial = "dial"
+	// RequestListen ask peer to listen the address, until the stream closed
+	RequestListen = "listen"
+	// RequestForwarded connection accepted on the listened address
+	RequestForwarded = "forwarded"
+)
+
+// Request open request of kt tunnel stream
+type Request struct {
+	Kind    string
+	Address string
+}
+
+// Encode convert request to bytes
+func (r *Request) Encode() []byte {
+	return []byte(fmt.Sprintf("%s %s", r.Kind, r.Address))
+}
+
+// ParseRequest parse request from bytes
+func ParseRequest(data []byte) (*Request, error) {
+	parts := strings.SplitN(string(data), " ", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid request '%s'", string(data))
+	}
+	return &Request{Kind: parts[0], Address: parts[1]}, nil
+}
+
+// Pipe copy data in both direction, and close both side when either side finished
+func Pipe(a, b io.ReadWriteCloser) {
+	var once sync.Once
+	closeBoth := func() {
+		_ = a.Close()
+		_ = b.Close()
+	}
+	go func() {
+		_, _ = io.Copy(a, b)
+		once.Do(closeBoth)
+	}()
+	_, _ = io.Copy(b, a)
+	once.Do(closeBoth)
+}
@@ -0,0 +1,304 @@
+package mux
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrSessionClosed session is already closed
+	ErrSessionClosed = errors.New("mux session closed")
+	// ErrKeepAliveTimeout peer not response in time
+	ErrKeepAliveTimeout = errors.New("mux keepalive timeout")
+	// ErrOpenTimeout peer not accept or refuse the stream in time
+	ErrOpenTimeout = errors.New("mux stream open timeout")
+)
+
+// Config session config
+type Config struct {
+	// KeepAliveInterval interval of sending ping
+	KeepAliveInterval time.Duration
+	// KeepAliveTimeout session is closed if nothing received from peer in this duration
+	KeepAliveTimeout time.Duration
+	// OpenTimeout duration to wait for stream accepted by peer
+	OpenTimeout time.Duration
+	// WindowSize receive window size of each stream
+	WindowSize uint32
+	// AcceptBacklog max count of streams waiting to be accepted
+	AcceptBacklog int
+}
+
+// DefaultConfig default session config
+func DefaultConfig() *Config {
+	return &Config{
+		KeepAliveInterval: 30 * time.Second,
+		KeepAliveTimeout:  90 * time.Second,
+		OpenTimeout:       30 * time.Second,
+		WindowSize:        256 * 1024,
+		AcceptBacklog:     256,
+	}
+}
+
+// Session multiplex streams over a single connection
+type Session struct {
+	conn     net.Conn
+	config   *Config
+	nextId   uint32
+	streams  map[uint32]*Stream
+	lock     sync.Mutex
+	sendLock sync.Mutex
+	incoming chan *Stream
+	closed   chan struct{}
+	once     sync.Once
+	err      error
+	// unix nano of last frame received
+	lastRecv int64
+}
+
+// Client create session at client side
+func Client(conn net.Conn, config *Config) *Session {
+	return newSession(conn, config, 1)
+}
+
+// Server create session at server side
+func Server(conn net.Conn, config *Config) *Session {
+	return newSession(conn, config, 2)
+}
+
+func newSession(conn net.Conn, config *Config, firstId uint32) *Session {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	s := &Session{
+		conn:     conn,
+		config:   config,
+		nextId:   firstId,
+		streams:  map[uint32]*Stream{},
+		incoming: make(chan *Stream, config.AcceptBacklog),
+		closed:   make(chan struct{}),
+		lastRecv: time.Now().UnixNano(),
+	}
+	go s.recvLoop()
+	go s.keepAlive()
+	return s
+}
+
+// Open open a new stream with request, and wait for peer to accept it
+func (s *Session) Open(request []byte) (*Stream, error) {
+	if len(request) > maxPayloadSize {
+		return nil, fmt.Errorf("request too large (%d bytes)", len(request))
+	}
+	s.lock.Lock()
+	if s.IsClosed() {
+		s.lock.Unlock()
+		return nil, ErrSessionClosed
+	}
+	// client use odd id, server use even id
+	id := s.nextId
+	s.nextId += 2
+	st := newStream(s, id, request)
+	s.streams[id] = st
+	s.lock.Unlock()
+
+	if err := s.writeFrame(typeOpen, id, request); err != nil {
+		s.removeStream(id)
+		return nil, err
+	}
+	select {
+	case err := <-st.ack:
+		if err != nil {
+			s.removeStream(id)
+			return nil, err
+		}
+		return st, nil
+	case <-s.closed:
+		return nil, s.err
+	case <-time.After(s.config.OpenTimeout):
+		_ = st.Close()
+		return nil, ErrOpenTimeout
+	}
+}
+
+// Accept wait for stream opened by peer, the stream must be either confirmed or refused
+func (s *Session) Accept() (*Stream, error) {
+	select {
+	case st := <-s.incoming:
+		return st, nil
+	case <-s.closed:
+		return nil, s.err
+	}
+}
+
+// Close close the session and all its streams
+func (s *Session) Close() error {
+	s.closeWithError(ErrSessionClosed)
+	return nil
+}
+
+// IsClosed whether the session is closed
+func (s *Session) IsClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// CloseChan channel closed when session closed
+func (s *Session) CloseChan() <-chan struct{} {
+	return s.closed
+}
+
+// RemoteAddr address of peer
+func (s *Session) RemoteAddr() net.Addr {
+	return s.conn.RemoteAddr()
+}
+
+// NumStreams count of active streams
+func (s *Session) NumStreams() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.streams)
+}
+
+func (s *Session) closeWithError(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.closed)
+		_ = s.conn.Close()
+		s.lock.Lock()
+		streams := s.streams
+		s.streams = map[uint32]*Stream{}
+		s.lock.Unlock()
+		for _, st := range streams {
+			st.abort(err)
+		}
+	})
+}
+
+func (s *Session) writeFrame(frameType byte, streamId uint32, payload []byte) error {
+	buf := make([]byte, headerSize+len(payload))
+	(*header)(buf[:headerSize]).encode(frameType, streamId, len(payload))
+	copy(buf[headerSize:], payload)
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+	if s.IsClosed() {
+		return s.err
+	}
+	if _, err := s.conn.Write(buf); err != nil {
+		s.closeWithError(err)
+		return err
+	}
+	return nil
+}
+
+// writeFrameAsync used by receiving loop, which should never be blocked by writing
+func (s *Session) writeFrameAsync(frameType byte, streamId uint32, payload []byte) {
+	go func() {
+		_ = s.writeFrame(frameType, streamId, payload)
+	}()
+}
+
+func (s *Session) getStream(id uint32) *Stream {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.streams[id]
+}
+
+func (s *Session) removeStream(id uint32) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.streams, id)
+}
+
+func (s *Session) recvLoop() {
+	var h header
+	buf := make([]byte, maxPayloadSize)
+	for {
+		payload, err := readFrame(s.conn, &h, buf)
+		if err != nil {
+			s.closeWithError(err)
+			return
+		}
+		atomic.StoreInt64(&s.lastRecv, time.Now().UnixNano())
+		if err = s.handleFrame(&h, payload); err != nil {
+			s.closeWithError(err)
+			return
+		}
+	}
+}
+
+func (s *Session) handleFrame(h *header, payload []byte) error {
+	id := h.streamId()
+	switch h.frameType() {
+	case typePing:
+		s.writeFrameAsync(typePong, 0, append([]byte{}, payload...))
+	case typePong:
+		// last receive time already updated
+	case typeOpen:
+		st := newStream(s, id, append([]byte{}, payload...))
+		s.lock.Lock()
+		if _, exists := s.streams[id]; exists {
+			s.lock.Unlock()
+			return fmt.Errorf("duplicated stream id %d", id)
+		}
+		s.streams[id] = st
+		s.lock.Unlock()
+		select {
+		case s.incoming <- st:
+		default:
+			s.removeStream(id)
+			s.writeFrameAsync(typeReject, id, []byte("accept backlog full"))
+		}
+	case typeAck:
+		if st := s.getStream(id); st != nil {
+			st.acknowledge(nil)
+		}
+	case typeReject:
+		if st := s.getStream(id); st != nil {
+			st.acknowledge(fmt.Errorf("stream refused: %s", string(payload)))
+		}
+	case typeData:
+		if st := s.getStream(id); st != nil {
+			return st.pushData(payload)
+		}
+	case typeWindow:
+		if len(payload) != 4 {
+			return fmt.Errorf("invalid window update of stream %d", id)
+		}
+		if st := s.getStream(id); st != nil {
+			st.addSendWindow(binary.BigEndian.Uint32(payload))
+		}
+	case typeClose:
+		if st := s.getStream(id); st != nil {
+			st.remoteClose()
+		}
+	default:
+		return fmt.Errorf("unknown frame type %d", h.frameType())
+	}
+	return nil
+}
+
+func (s *Session) keepAlive() {
+	ticker := time.NewTicker(s.config.KeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			lastRecv := time.Unix(0, atomic.LoadInt64(&s.lastRecv))
+			if time.Since(lastRecv) > s.config.KeepAliveTimeout {
+				s.closeWithError(ErrKeepAliveTimeout)
+				return
+			}
+			s.writeFrameAsync(typePing, 0, nil)
+		case <-s.closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package mux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Stream a bidirectional byte stream inside session, implements net.Conn
+type Stream struct {
+	id      uint32
+	session *Session
+	request []byte
+	ack     chan error
+
+	lock sync.Mutex
+	cond *sync.Cond
+	buf  bytes.Buffer
+	// bytes read by application but not yet returned to peer as window credit
+	consumed   uint32
+	sendWindow uint32
+	// peer closed the stream
+	remoteClosed bool
+	// local closed the stream
+	localClosed bool
+	// session broken
+	err           error
+	readDeadline  time.Time
+	writeDeadline time.Time
+	closeOnce     sync.Once
+}
+
+func newStream(s *Session, id uint32, request []byte) *Stream {
+	st := &Stream{
+		id:         id,
+		session:    s,
+		request:    request,
+		ack:        make(chan error, 1),
+		sendWindow: s.config.WindowSize,
+	}
+	st.cond = sync.NewCond(&st.lock)
+	return st
+}
+
+// Request the request attached when stream opened
+func (st *Stream) Request() []byte {
+	return st.request
+}
+
+// Confirm accept the stream opened by peer
+func (st *Stream) Confirm() error {
+	return st.session.writeFrame(typeAck, st.id, nil)
+}
+
+// Refuse refuse the stream opened by peer
+func (st *Stream) Refuse(reason string) error {
+	st.session.removeStream(st.id)
+	return st.session.writeFrame(typeReject, st.id, []byte(reason))
+}
+
+// Read implements net.Conn
+func (st *Stream) Read(p []byte) (int, error) {
+	st.lock.Lock()
+	for st.buf.Len() == 0 {
+		if st.localClosed {
+			st.lock.Unlock()
+			return 0, io.ErrClosedPipe
+		}
+		if st.remoteClosed {
+			st.lock.Unlock()
+			return 0, io.EOF
+		}
+		if st.err != nil {
+			st.lock.Unlock()
+			return 0, st.err
+		}
+		if !st.readDeadline.IsZero() && !time.Now().Before(st.readDeadline) {
+			st.lock.Unlock()
+			return 0, os.ErrDeadlineExceeded
+		}
+		st.cond.Wait()
+	}
+	n, _ := st.buf.Read(p)
+	st.consumed += uint32(n)
+	var credit uint32
+	if st.consumed >= st.session.config.WindowSize/2 {
+		credit = st.consumed
+		st.consumed = 0
+	}
+	st.lock.Unlock()
+	if credit > 0 {
+		st.sendWindowUpdate(credit)
+	}
+	return n, nil
+}
+
+// Write implements net.Conn
+func (st *Stream) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		st.lock.Lock()
+		for st.sendWindow == 0 {
+			if err := st.writeError(); err != nil {
+				st.lock.Unlock()
+				return written, err
+			}
+			st.cond.Wait()
+		}
+		if err := st.writeError(); err != nil {
+			st.lock.Unlock()
+			return written, err
+		}
+		n := len(p)
+		if n > int(st.sendWindow) {
+			n = int(st.sendWindow)
+		}
+		if n > maxPayloadSize {
+			n = maxPayloadSize
+		}
+		st.sendWindow -= uint32(n)
+		st.lock.Unlock()
+
+		if err := st.session.writeFrame(typeData, st.id, p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// writeError must be called with lock held
+func (st *Stream) writeError() error {
+	if st.localClosed || st.remoteClosed {
+		return io.ErrClosedPipe
+	}
+	if st.err != nil {
+		return st.err
+	}
+	if !st.writeDeadline.IsZero() && !time.Now().Before(st.writeDeadline) {
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
+
+// Close implements net.Conn
+func (st *Stream) Close() error {
+	var err error
+	st.closeOnce.Do(func() {
+		st.lock.Lock()
+		st.localClosed = true
+		remoteClosed := st.remoteClosed
+		st.buf.Reset()
+		st.cond.Broadcast()
+		st.lock.Unlock()
+		if remoteClosed {
+			st.session.removeStream(st.id)
+		}
+		err = st.session.writeFrame(typeClose, st.id, nil)
+	})
+	return err
+}
+
+// LocalAddr implements net.Conn
+func (st *Stream) LocalAddr() net.Addr {
+	return st.session.conn.LocalAddr()
+}
+
+// RemoteAddr implements net.Conn
+func (st *Stream) RemoteAddr() net.Addr {
+	return st.session.conn.RemoteAddr()
+}
+
+// SetDeadline implements net.Conn
+func (st *Stream) SetDeadline(t time.Time) error {
+	st.setDeadline(&st.readDeadline, t)
+	st.setDeadline(&st.writeDeadline, t)
+	return nil
+}
+
+// SetReadDeadline implements net.Conn
+func (st *Stream) SetReadDeadline(t time.Time) error {
+	st.setDeadline(&st.readDeadline, t)
+	return nil
+}
+
+// SetWriteDeadline implements net.Conn
+func (st *Stream) SetWriteDeadline(t time.Time) error {
+	st.setDeadline(&st.writeDeadline, t)
+	return nil
+}
+
+func (st *Stream) setDeadline(deadline *time.Time, t time.Time) {
+	st.lock.Lock()
+	*deadline = t
+	st.cond.Broadcast()
+	st.lock.Unlock()
+	if !t.IsZero() {
+		// wake up the waiting reader or writer when deadline reached
+		time.AfterFunc(time.Until(t), func() {
+			st.lock.Lock()
+			st.cond.Broadcast()
+			st.lock.Unlock()
+		})
+	}
+}
+
+func (st *Stream) acknowledge(err error) {
+	select {
+	case st.ack <- err:
+	default:
+	}
+}
+
+func (st *Stream) pushData(data []byte) error {
+	st.lock.Lock()
+	if st.localClosed {
+		// nobody will read it, give the credit back at once
+		st.lock.Unlock()
+		st.sendWindowUpdate(uint32(len(data)))
+		return nil
+	}
+	if uint32(st.buf.Len()+len(data)) > st.session.config.WindowSize {
+		st.lock.Unlock()
+		return fmt.Errorf("stream %d receive window exceeded", st.id)
+	}
+	st.buf.Write(data)
+	st.cond.Broadcast()
+	st.lock.Unlock()
+	return nil
+}
+
+func (st *Stream) addSendWindow(credit uint32) {
+	st.lock.Lock()
+	st.sendWindow += credit
+	st.cond.Broadcast()
+	st.lock.Unlock()
+}
+
+func (st *Stream) sendWindowUpdate(credit uint32) {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, credit)
+	st.session.writeFrameAsync(typeWindow, st.id, payload)
+}
+
+func (st *Stream) remoteClose() {
+	st.lock.Lock()
+	st.remoteClosed = true
+	localClosed := st.localClosed
+	st.cond.Broadcast()
+	st.lock.Unlock()
+	if localClosed {
+		st.session.removeStream(st.id)
+	}
+}
+
+func (st *Stream) abort(err error) {
+	st.lock.Lock()
+	st.err = err
+	st.cond.Broadcast()
+	st.lock.Unlock()
+	st.acknowledge(err)
+}
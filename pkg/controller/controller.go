@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"sync"
+	"time"
+)
+
+// Controller reconcile cluster to KtSession resources
+type Controller struct {
+	k        cluster.KubernetesInterface
+	informer cache.SharedIndexInformer
+	lock     sync.Mutex
+}
+
+// NewController create controller watching KtSessions in namespace, empty namespace for all namespaces
+// every session is re-checked at resync interval, so that expired session would be recovered in time
+func NewController(k cluster.KubernetesInterface, client dynamic.Interface, namespace string, resync time.Duration) *Controller {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, resync, namespace, nil)
+	c := &Controller{
+		k:        k,
+		informer: factory.ForResource(cluster.KtSessionGVR).Informer(),
+	}
+	c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { c.handle(obj) },
+		UpdateFunc: func(oldObj, newObj any) { c.handle(newObj) },
+	})
+	return c
+}
+
+// Run start watching until stop channel closed
+func (c *Controller) Run(stop <-chan struct{}) {
+	log.Info().Msgf("KtSession controller started")
+	c.informer.Run(stop)
+	log.Info().Msgf("KtSession controller stopped")
+}
+
+func (c *Controller) handle(obj any) {
+	session, ok := toSession(obj)
+	if !ok {
+		return
+	}
+	// sessions may share the same service, reconcile them one by one
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := Reconcile(c.k, session, c.listSessions(session.Namespace)); err != nil {
+		log.Warn().Err(err).Msgf("Failed to reconcile KtSession %s/%s", session.Namespace, session.Name)
+	}
+}
+
+func (c *Controller) listSessions(namespace string) []cluster.KtSession {
+	var sessions []cluster.KtSession
+	for _, obj := range c.informer.GetStore().List() {
+		if s, ok := toSession(obj); ok && s.Namespace == namespace {
+			sessions = append(sessions, *s)
+		}
+	}
+	return sessions
+}
+
+func toSession(obj any) (*cluster.KtSession, bool) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, false
+	}
+	session := &cluster.KtSession{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, session); err != nil {
+		log.Warn().Err(err).Msgf("Invalid KtSession %s/%s", u.GetNamespace(), u.GetName())
+		return nil, false
+	}
+	return session, true
+}
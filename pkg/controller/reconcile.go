@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"time"
+)
+
+// Reconcile make cluster consistent with the KtSession
+// sessions: all KtSessions in the same namespace, used for checking whether a service is shared
+func Reconcile(k cluster.KubernetesInterface, session *cluster.KtSession, sessions []cluster.KtSession) error {
+	if session.DeletionTimestamp != nil {
+		if !hasFinalizer(session) {
+			return nil
+		}
+		if err := recoverSession(k, session, sessions); err != nil {
+			return err
+		}
+		session.Finalizers = removeFinalizer(session.Finalizers)
+		if _, err := k.UpdateKtSession(session); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+		log.Info().Msgf("KtSession %s/%s recovered", session.Namespace, session.Name)
+		return nil
+	}
+
+	if isSessionExpired(k, session) {
+		log.Info().Msgf("Lease of KtSession %s/%s expired, removing it", session.Namespace, session.Name)
+		return k.RemoveKtSession(session.Name, session.Namespace)
+	}
+
+	if !hasFinalizer(session) {
+		session.Finalizers = append(session.Finalizers, util.KtSessionFinalizer)
+		updated, err := k.UpdateKtSession(session)
+		if err != nil {
+			return err
+		}
+		session = updated
+	}
+
+	phase, message := util.SessionPhaseApplied, ""
+	if err := applySession(k, session); err != nil {
+		phase, message = util.SessionPhaseFailed, err.Error()
+	}
+	if session.Status.Phase != phase || session.Status.Message != message ||
+		session.Status.ObservedGeneration != session.Generation {
+		session.Status = cluster.KtSessionStatus{
+			Phase:              phase,
+			Message:            message,
+			ObservedGeneration: session.Generation,
+		}
+		if _, err := k.UpdateKtSessionStatus(session); err != nil {
+			return err
+		}
+		log.Info().Msgf("KtSession %s/%s %s %s", session.Namespace, session.Name, phase, message)
+	}
+	return nil
+}
+
+// applySession change services and deployments as the session desired
+func applySession(k cluster.KubernetesInterface, session *cluster.KtSession) error {
+	for _, change := range session.Spec.Services {
+		svc, err := k.GetService(change.Name, session.Namespace)
+		if err != nil {
+			return fmt.Errorf("failed to get service %s: %s", change.Name, err)
+		}
+		rawSelector, err := json.Marshal(change.OriginalSelector)
+		if err != nil {
+			return err
+		}
+		// keep kt-selector annotation, so that recover and clean command still work
+		if util.MapEquals(svc.Spec.Selector, change.Selector) && svc.Annotations != nil &&
+			svc.Annotations[util.KtSelector] == string(rawSelector) {
+			continue
+		}
+		svc.Spec.Selector = change.Selector
+		svc.Annotations = util.MapPut(svc.Annotations, util.KtSelector, string(rawSelector))
+		if _, err = k.UpdateService(svc); err != nil {
+			return fmt.Errorf("failed to update service %s: %s", change.Name, err)
+		}
+		log.Info().Msgf("Service %s/%s now selecting %v", session.Namespace, change.Name, change.Selector)
+	}
+	for _, change := range session.Spec.Deployments {
+		if err := scaleIfNeeded(k, change.Name, session.Namespace, change.Replicas); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoverSession undo changes of the session, service still used by other sessions are left unchanged
+func recoverSession(k cluster.KubernetesInterface, session *cluster.KtSession, sessions []cluster.KtSession) error {
+	for _, change := range session.Spec.Services {
+		if user := sharedBy(session, change.Name, sessions); user != "" {
+			log.Info().Msgf("Service %s/%s still used by KtSession %s, skip recovering", session.Namespace, change.Name, user)
+			continue
+		}
+		svc, err := k.GetService(change.Name, session.Namespace)
+		if k8sErrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		svc.Spec.Selector = change.OriginalSelector
+		delete(svc.Annotations, util.KtSelector)
+		if _, err = k.UpdateService(svc); err != nil {
+			return err
+		}
+		log.Info().Msgf("Service %s/%s recovered", session.Namespace, change.Name)
+	}
+	for _, change := range session.Spec.Deployments {
+		if err := scaleIfNeeded(k, change.Name, session.Namespace, change.OriginalReplicas); err != nil &&
+			!k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func scaleIfNeeded(k cluster.KubernetesInterface, name, namespace string, replicas int32) error {
+	app, err := k.GetDeployment(name, namespace)
+	if err != nil {
+		return err
+	}
+	if app.Spec.Replicas != nil && *app.Spec.Replicas == replicas {
+		return nil
+	}
+	return k.ScaleTo(name, namespace, &replicas)
+}
+
+// isSessionExpired session expires when its lease expired or missing
+func isSessionExpired(k cluster.KubernetesInterface, session *cluster.KtSession) bool {
+	lease, err := k.GetLease(session.Spec.Lease, session.Namespace)
+	if err == nil {
+		return cluster.IsLeaseExpired(lease)
+	} else if !k8sErrors.IsNotFound(err) {
+		log.Warn().Err(err).Msgf("Failed to check lease of KtSession %s/%s", session.Namespace, session.Name)
+		return false
+	}
+	// lease of newly created session may not be visible yet
+	return time.Since(session.CreationTimestamp.Time) > util.SessionLeaseDurationSec*time.Second
+}
+
+// sharedBy return name of another active session which also changes the service
+func sharedBy(session *cluster.KtSession, svcName string, sessions []cluster.KtSession) string {
+	for _, s := range sessions {
+		if s.Name == session.Name || s.Namespace != session.Namespace || s.DeletionTimestamp != nil {
+			continue
+		}
+		for _, change := range s.Spec.Services {
+			if change.Name == svcName {
+				return s.Name
+			}
+		}
+	}
+	return ""
+}
+
+func hasFinalizer(session *cluster.KtSession) bool {
+	for _, f := range session.Finalizers {
+		if f == util.KtSessionFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(finalizers []string) []string {
+	var remains []string
+	for _, f := range finalizers {
+		if f != util.KtSessionFinalizer {
+			remains = append(remains, f)
+		}
+	}
+	return remains
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	appV1 "k8s.io/api/apps/v1"
+	coordV1 "k8s.io/api/coordination/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func newFakeCluster() *cluster.Kubernetes {
+	replicas := int32(2)
+	durationSec := int32(60)
+	renewTime := metav1.NewMicroTime(time.Now())
+	objects := []runtime.Object{
+		&coreV1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "tomcat", Namespace: "default"},
+			Spec:       coreV1.ServiceSpec{Selector: map[string]string{"app": "tomcat"}},
+		},
+		&appV1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "tomcat", Namespace: "default"},
+			Spec:       appV1.DeploymentSpec{Replicas: &replicas},
+		},
+		&coordV1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: "kt-session-alive", Namespace: "default"},
+			Spec:       coordV1.LeaseSpec{LeaseDurationSeconds: &durationSec, RenewTime: &renewTime},
+		},
+	}
+	scheme := runtime.NewScheme()
+	return &cluster.Kubernetes{
+		Clientset: testclient.NewSimpleClientset(objects...),
+		DynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme,
+			map[schema.GroupVersionResource]string{cluster.KtSessionGVR: "KtSessionList"}),
+	}
+}
+
+func newSession(t *testing.T, k *cluster.Kubernetes, name, lease string) *cluster.KtSession {
+	session, err := k.CreateKtSession(&cluster.KtSession{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.Now()},
+		Spec: cluster.KtSessionSpec{
+			Component: util.ComponentExchange,
+			Lease:     lease,
+			Services: []cluster.ServiceChange{{
+				Name:             "tomcat",
+				Selector:         map[string]string{util.KtRole: util.RoleExchangeShadow},
+				OriginalSelector: map[string]string{"app": "tomcat"},
+			}},
+			Deployments: []cluster.DeploymentChange{{Name: "tomcat", Replicas: 0, OriginalReplicas: 2}},
+		},
+	})
+	require.Nil(t, err)
+	return session
+}
+
+func TestReconcile_applyAndRecover(t *testing.T) {
+	k := newFakeCluster()
+	session := newSession(t, k, "kt-session-alive", "kt-session-alive")
+
+	require.Nil(t, Reconcile(k, session, nil))
+	session, err := k.GetKtSession("kt-session-alive", "default")
+	require.Nil(t, err)
+	require.True(t, session.IsApplied())
+	require.Equal(t, []string{util.KtSessionFinalizer}, session.Finalizers)
+	svc, _ := k.GetService("tomcat", "default")
+	require.Equal(t, map[string]string{util.KtRole: util.RoleExchangeShadow}, svc.Spec.Selector)
+	require.Equal(t, `{"app":"tomcat"}`, svc.Annotations[util.KtSelector])
+	app, _ := k.GetDeployment("tomcat", "default")
+	require.Equal(t, int32(0), *app.Spec.Replicas)
+
+	now := metav1.Now()
+	session.DeletionTimestamp = &now
+	require.Nil(t, Reconcile(k, session, nil))
+	svc, _ = k.GetService("tomcat", "default")
+	require.Equal(t, map[string]string{"app": "tomcat"}, svc.Spec.Selector)
+	require.NotContains(t, svc.Annotations, util.KtSelector)
+	app, _ = k.GetDeployment("tomcat", "default")
+	require.Equal(t, int32(2), *app.Spec.Replicas)
+	session, _ = k.GetKtSession("kt-session-alive", "default")
+	require.Empty(t, session.Finalizers)
+}
+
+func TestReconcile_sharedService(t *testing.T) {
+	k := newFakeCluster()
+	session := newSession(t, k, "kt-session-alive", "kt-session-alive")
+	other := newSession(t, k, "kt-session-other", "kt-session-alive")
+	require.Nil(t, Reconcile(k, session, nil))
+
+	now := metav1.Now()
+	session.DeletionTimestamp = &now
+	require.Nil(t, Reconcile(k, session, []cluster.KtSession{*session, *other}))
+	svc, _ := k.GetService("tomcat", "default")
+	require.Equal(t, map[string]string{util.KtRole: util.RoleExchangeShadow}, svc.Spec.Selector)
+}
+
+func TestReconcile_expiredSession(t *testing.T) {
+	k := newFakeCluster()
+	session := newSession(t, k, "kt-session-gone", "kt-session-gone")
+	session.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	require.Nil(t, Reconcile(k, session, nil))
+	_, err := k.GetKtSession("kt-session-gone", "default")
+	require.NotNil(t, err)
+
+	session = newSession(t, k, "kt-session-new", "kt-session-new")
+	require.Nil(t, Reconcile(k, session, nil))
+	session, err = k.GetKtSession("kt-session-new", "default")
+	require.Nil(t, err)
+	require.True(t, session.IsApplied())
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/alibaba/kt-connect/pkg/kt/command/birdseye"
 	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -76,8 +77,13 @@ func showConnectors() error {
 		return err
 	}
 
+	leases, err := cluster.GetKtLeases("")
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to fetch kt leases")
+	}
+
 	unknownUserCount := 0
-	users := birdseye.GetConnectors(pods, apps)
+	users := birdseye.GetConnectors(pods, apps, leases)
 	log.Info().Msgf("---- User connecting to cluster ----")
 	for _, user := range users {
 		if user == birdseye.UnknownUser {
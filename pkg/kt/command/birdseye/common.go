@@ -6,6 +6,7 @@ import (
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	appV1 "k8s.io/api/apps/v1"
+	coordV1 "k8s.io/api/coordination/v1"
 	coreV1 "k8s.io/api/core/v1"
 	"strings"
 )
@@ -46,15 +47,15 @@ func GetKtPodsAndDeployments() ([]coreV1.Pod, []appV1.Deployment, error) {
 	return pods.Items, apps.Items, nil
 }
 
-func GetConnectors(pods []coreV1.Pod, apps []appV1.Deployment) []string {
+func GetConnectors(pods []coreV1.Pod, apps []appV1.Deployment, leases map[string]coordV1.Lease) []string {
 	users:= make([]string, 0)
 	for _, pod := range pods {
-		if user := checkConnector(pod.Annotations); user != "" {
+		if user := checkConnector(pod.Annotations, leases); user != "" {
 			users = append(users, user)
 		}
 	}
 	for _, app := range apps {
-		if user := checkConnector(app.Annotations); user != "" {
+		if user := checkConnector(app.Annotations, leases); user != "" {
 			users = append(users, user)
 		}
 	}
@@ -125,9 +126,13 @@ func getMeshedUserNames(svcs []coreV1.Service, pods []coreV1.Pod, namePrefix str
 	return "[" + strings.Join(users, "], [") + "]"
 }
 
-func checkConnector(annotations map[string]string) string {
+func checkConnector(annotations map[string]string, leases map[string]coordV1.Lease) string {
 	if user, exists := annotations[util.KtUser]; exists {
-		lastHeartBeat := util.ParseTimestamp(annotations[util.KtLastHeartBeat])
+		lastHeartBeat := cluster.LastRenewTime(annotations, leases)
+		if lastHeartBeat < 0 {
+			// resource created by earlier version
+			lastHeartBeat = util.ParseTimestamp(annotations[util.KtLastHeartBeat])
+		}
 		if lastHeartBeat > 0 {
 			lastActiveInMin := (util.GetTime() - lastHeartBeat) / 60
 			return fmt.Sprintf("%s (last active %d min ago)", user, lastActiveInMin)
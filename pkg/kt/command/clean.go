@@ -5,9 +5,11 @@ import (
 	"github.com/alibaba/kt-connect/pkg/kt/command/clean"
 	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"strings"
+	"time"
 )
 
 // NewCleanCommand return new connect command
@@ -34,16 +36,11 @@ func NewCleanCommand() *cobra.Command {
 
 // Clean delete unavailing shadow pods
 func Clean() error {
+	if opt.Get().Clean.Watch {
+		return watchAndClean()
+	}
 	if !opt.Get().Clean.LocalOnly {
-		if resourceToClean, err := clean.CheckClusterResources(); err != nil {
-			log.Warn().Err(err).Msgf("Failed to clean up cluster resources")
-		} else if isEmpty(resourceToClean) {
-			log.Info().Msg("No unavailing kt resource found (^.^)YYa!!")
-		} else if opt.Get().Clean.DryRun {
-			clean.PrintClusterResourcesToClean(resourceToClean)
-		} else {
-			clean.TidyClusterResources(resourceToClean)
-		}
+		cleanNamespace(opt.Get().Global.Namespace)
 	}
 	if !opt.Get().Clean.DryRun {
 		clean.TidyLocalResources()
@@ -51,12 +48,49 @@ func Clean() error {
 	return nil
 }
 
-func isEmpty(r *clean.ResourceToClean) bool {
-	return len(r.PodsToDelete) == 0 &&
-		len(r.ConfigMapsToDelete) == 0 &&
-		len(r.DeploymentsToDelete) == 0 &&
-		len(r.DeploymentsToScale) == 0 &&
-		len(r.ServicesToDelete) == 0 &&
-		len(r.ServicesToUnlock) == 0 &&
-		len(r.ServicesToRecover) == 0
+// watchAndClean periodically clean unavailing resources in all namespaces
+func watchAndClean() error {
+	if opt.Get().Clean.WatchInterval <= 0 {
+		return fmt.Errorf("watch interval must be greater than 0")
+	}
+	log.Info().Msgf("Checking unavailing kt resources every %d seconds", opt.Get().Clean.WatchInterval)
+	for {
+		for _, namespace := range getNamespacesToWatch() {
+			cleanNamespace(namespace)
+		}
+		time.Sleep(time.Duration(opt.Get().Clean.WatchInterval) * time.Second)
+	}
+}
+
+func getNamespacesToWatch() []string {
+	namespaces, err := cluster.Ins().GetAllNamespaces()
+	if err != nil {
+		log.Debug().Err(err).Msgf("Unable to list namespaces, only watch namespace %s", opt.Get().Global.Namespace)
+		return []string{opt.Get().Global.Namespace}
+	}
+	names := make([]string, 0)
+	for _, ns := range namespaces.Items {
+		names = append(names, ns.Name)
+	}
+	return names
+}
+
+func cleanNamespace(namespace string) {
+	if resourceToClean, err := clean.CheckClusterResources(namespace); err != nil {
+		log.Warn().Err(err).Msgf("Failed to clean up cluster resources in namespace %s", namespace)
+	} else if resourceToClean.IsEmpty() {
+		if !opt.Get().Clean.Watch {
+			log.Info().Msg("No unavailing kt resource found (^.^)YYa!!")
+		}
+	} else if opt.Get().Clean.DryRun {
+		if opt.Get().Clean.Watch {
+			log.Info().Msgf("---- Namespace %s ----", namespace)
+		}
+		clean.PrintClusterResourcesToClean(resourceToClean)
+	} else {
+		if opt.Get().Clean.Watch {
+			log.Info().Msgf("Cleaning namespace %s", namespace)
+		}
+		clean.TidyClusterResources(resourceToClean, namespace)
+	}
 }
@@ -11,7 +11,9 @@ import (
 	"github.com/rs/zerolog/log"
 	"io/ioutil"
 	appV1 "k8s.io/api/apps/v1"
+	coordV1 "k8s.io/api/coordination/v1"
 	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
 	"os"
 	"strconv"
 	"strings"
@@ -25,15 +27,18 @@ type ResourceToClean struct {
 	DeploymentsToScale  map[string]int32
 	ServicesToRecover   []string
 	ServicesToUnlock   []string
+	EndpointsToRecover  []string
+	LeasesToDelete      []string
 }
 
 
-func CheckClusterResources() (*ResourceToClean, error) {
-	pods, cfs, apps, svcs, err := cluster.Ins().GetKtResources(opt.Get().Global.Namespace)
+// CheckClusterResources find unavailing kt resources in specified namespace
+func CheckClusterResources(namespace string) (*ResourceToClean, error) {
+	pods, cfs, apps, svcs, err := cluster.Ins().GetKtResources(namespace)
 	if err != nil {
 		return nil, err
 	}
-	log.Debug().Msgf("Find %d kt pods", len(pods))
+	log.Debug().Msgf("Find %d kt pods in namespace %s", len(pods), namespace)
 	resourceToClean := ResourceToClean{
 		PodsToDelete:        make([]string, 0),
 		ServicesToDelete:    make([]string, 0),
@@ -42,90 +47,160 @@ func CheckClusterResources() (*ResourceToClean, error) {
 		DeploymentsToScale:  make(map[string]int32),
 		ServicesToRecover:   make([]string, 0),
 		ServicesToUnlock:    make([]string, 0),
+		EndpointsToRecover:  make([]string, 0),
+		LeasesToDelete:      make([]string, 0),
+	}
+	leases, err := cluster.GetKtLeases(namespace)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to fetch kt leases")
+		leases = map[string]coordV1.Lease{}
 	}
 	for _, pod := range pods {
-		analysisExpiredPods(pod, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
+		analysisExpiredPods(pod, leases, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
 	}
 	for _, cf := range cfs {
-		analysisExpiredConfigmaps(cf, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
+		analysisExpiredConfigmaps(cf, leases, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
 	}
 	for _, app := range apps {
-		analysisExpiredDeployments(app, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
+		analysisExpiredDeployments(app, leases, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
 	}
 	for _, svc := range svcs {
-		analysisExpiredServices(svc, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
+		analysisExpiredServices(svc, leases, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
+	}
+	analysisExpiredLeases(leases, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
+	svcList, err := cluster.Ins().GetAllServiceInNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+	analysisLockAndOrphanServices(svcList.Items, leases, &resourceToClean)
+	slices, err := cluster.Ins().GetAllEndpointSlicesInNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := cluster.Ins().GetAllEndpointsInNamespace(namespace)
+	if err != nil {
+		return nil, err
 	}
-	svcList, err := cluster.Ins().GetAllServiceInNamespace(opt.Get().Global.Namespace)
-	analysisLockAndOrphanServices(svcList.Items, &resourceToClean)
+	analysisTakenOverEndpoints(slices, endpoints, leases, opt.Get().Clean.ThresholdInMinus, &resourceToClean)
+	resourceToClean.ServicesToRecover = distinct(resourceToClean.ServicesToRecover)
 	return &resourceToClean, nil
 }
 
-func TidyClusterResources(r *ResourceToClean) {
+// TidyClusterResources delete or recover resources found by CheckClusterResources
+func TidyClusterResources(r *ResourceToClean, namespace string) {
 	log.Info().Msgf("Deleting %d unavailing kt pods", len(r.PodsToDelete))
 	for _, name := range r.PodsToDelete {
-		err := cluster.Ins().RemovePod(name, opt.Get().Global.Namespace)
+		err := cluster.Ins().RemovePod(name, namespace)
 		if err != nil {
 			log.Warn().Err(err).Msgf("Failed to delete pods %s", name)
 		} else {
-			log.Info().Msgf(" * %s", name)
+			reportAction("Pod", name, namespace, "KtPodDeleted", "Deleted unavailing kt pod")
 		}
 	}
 	log.Info().Msgf("Deleting %d unavailing config maps", len(r.ConfigMapsToDelete))
 	for _, name := range r.ConfigMapsToDelete {
-		err := cluster.Ins().RemoveConfigMap(name, opt.Get().Global.Namespace)
+		err := cluster.Ins().RemoveConfigMap(name, namespace)
 		if err != nil {
 			log.Warn().Err(err).Msgf("Failed to delete config map %s", name)
 		} else {
-			log.Info().Msgf(" * %s", name)
+			reportAction("ConfigMap", name, namespace, "KtConfigMapDeleted", "Deleted unavailing kt config map")
 		}
 	}
 	log.Info().Msgf("Deleting %d unavailing deployments", len(r.DeploymentsToDelete))
 	for _, name := range r.DeploymentsToDelete {
-		err := cluster.Ins().RemoveDeployment(name, opt.Get().Global.Namespace)
+		err := cluster.Ins().RemoveDeployment(name, namespace)
 		if err != nil {
 			log.Warn().Err(err).Msgf("Failed to delete deployment %s", name)
 		} else {
-			log.Info().Msgf(" * %s", name)
+			reportAction("Deployment", name, namespace, "KtDeploymentDeleted", "Deleted unavailing kt deployment")
 		}
 	}
 	log.Info().Msgf("Recovering %d scaled deployments", len(r.DeploymentsToScale))
 	for name, replica := range r.DeploymentsToScale {
-		err := cluster.Ins().ScaleTo(name, opt.Get().Global.Namespace, &replica)
+		err := cluster.Ins().ScaleTo(name, namespace, &replica)
 		if err != nil {
 			log.Warn().Err(err).Msgf("Failed to scale deployment %s to %d", name, replica)
 		} else {
-			log.Info().Msgf(" * %s", name)
+			reportAction("Deployment", name, namespace, "KtDeploymentRecovered",
+				fmt.Sprintf("Scaled exchanged deployment back to %d replicas", replica))
 		}
 	}
 	log.Info().Msgf("Deleting %d unavailing services", len(r.ServicesToDelete))
 	for _, name := range r.ServicesToDelete {
-		err := cluster.Ins().RemoveService(name, opt.Get().Global.Namespace)
+		err := cluster.Ins().RemoveService(name, namespace)
 		if err != nil {
 			log.Warn().Err(err).Msgf("Failed to delete service %s", name)
 		} else {
-			log.Info().Msgf(" * %s", name)
+			reportAction("Service", name, namespace, "KtServiceDeleted", "Deleted unavailing kt service")
 		}
 	}
 	log.Info().Msgf("Recovering %d meshed services", len(r.ServicesToRecover))
 	for _, name := range r.ServicesToRecover {
-		general.RecoverOriginalService(name, opt.Get().Global.Namespace)
-		log.Info().Msgf(" * %s", name)
+		general.RecoverOriginalService(name, namespace)
+		reportAction("Service", name, namespace, "KtServiceRecovered", "Recovered original selector of service")
+	}
+	log.Info().Msgf("Recovering %d taken over endpoints", len(r.EndpointsToRecover))
+	for _, name := range r.EndpointsToRecover {
+		general.RecoverServiceEndpoints(name, namespace)
+		reportAction("Service", name, namespace, "KtEndpointsRecovered", "Recovered endpoints of service")
+	}
+	log.Info().Msgf("Deleting %d expired leases", len(r.LeasesToDelete))
+	for _, name := range r.LeasesToDelete {
+		err := cluster.Ins().RemoveLease(name, namespace)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to delete lease %s", name)
+		} else {
+			reportAction("Lease", name, namespace, "KtLeaseDeleted", "Deleted expired kt lease")
+		}
 	}
 	log.Info().Msgf("Recovering %d locked services", len(r.ServicesToUnlock))
 	for _, name := range r.ServicesToUnlock {
-		if app, err := cluster.Ins().GetService(name, opt.Get().Global.Namespace); err == nil {
+		if app, err := cluster.Ins().GetService(name, namespace); err == nil {
 			delete(app.Annotations, util.KtLock)
 			_, err = cluster.Ins().UpdateService(app)
 			if err != nil {
 				log.Warn().Err(err).Msgf("Failed to lock service %s", name)
 			} else {
-				log.Info().Msgf(" * %s", name)
+				reportAction("Service", name, namespace, "KtServiceUnlocked", "Removed expired kt lock of service")
 			}
 		}
 	}
 	log.Info().Msg("Done")
 }
 
+// IsEmpty check whether there is nothing to clean
+func (r *ResourceToClean) IsEmpty() bool {
+	return len(r.PodsToDelete) == 0 &&
+		len(r.ConfigMapsToDelete) == 0 &&
+		len(r.DeploymentsToDelete) == 0 &&
+		len(r.DeploymentsToScale) == 0 &&
+		len(r.ServicesToDelete) == 0 &&
+		len(r.ServicesToUnlock) == 0 &&
+		len(r.ServicesToRecover) == 0 &&
+		len(r.EndpointsToRecover) == 0 &&
+		len(r.LeasesToDelete) == 0
+}
+
+// reportAction print the action, and record it as kubernetes event in watch mode
+func reportAction(kind, name, namespace, reason, message string) {
+	log.Info().Msgf(" * %s", name)
+	if opt.Get().Clean.Watch {
+		if err := cluster.Ins().CreateEvent(kind, name, namespace, reason, message); err != nil {
+			log.Debug().Err(err).Msgf("Failed to record event of %s %s", kind, name)
+		}
+	}
+}
+
+func distinct(items []string) []string {
+	result := make([]string, 0)
+	for _, item := range items {
+		if !util.Contains(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func PrintClusterResourcesToClean(r *ResourceToClean) {
 	log.Info().Msgf("Find %d unavailing pods to delete:", len(r.PodsToDelete))
 	for _, name := range r.PodsToDelete {
@@ -151,6 +226,14 @@ func PrintClusterResourcesToClean(r *ResourceToClean) {
 	for _, name := range r.ServicesToRecover {
 		log.Info().Msgf(" * %s", name)
 	}
+	log.Info().Msgf("Find %d taken over endpoints to recover:", len(r.EndpointsToRecover))
+	for _, name := range r.EndpointsToRecover {
+		log.Info().Msgf(" * %s", name)
+	}
+	log.Info().Msgf("Find %d expired leases to delete:", len(r.LeasesToDelete))
+	for _, name := range r.LeasesToDelete {
+		log.Info().Msgf(" * %s", name)
+	}
 	log.Info().Msgf("Find %d locked services to recover:", len(r.ServicesToUnlock))
 	for _, name := range r.ServicesToUnlock {
 		log.Info().Msgf(" * %s", name)
@@ -211,12 +294,11 @@ func parseComponentAndPid(pidFileName string) (string, int) {
 	return "", -1
 }
 
-func analysisExpiredPods(pod coreV1.Pod, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
-	lastHeartBeat := util.ParseTimestamp(pod.Annotations[util.KtLastHeartBeat])
-	if lastHeartBeat < 0 {
-		log.Debug().Msgf("Pod %s does no have heart beat annotation", pod.Name)
-	} else if isExpired(lastHeartBeat, cleanThresholdInMinus) {
-		log.Debug().Msgf(" * pod %s expired, lastHeartBeat: %d ", pod.Name, lastHeartBeat)
+func analysisExpiredPods(pod coreV1.Pod, leases map[string]coordV1.Lease, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
+	if tracked, expired := isResourceExpired(pod.Annotations, leases, cleanThresholdInMinus); !tracked {
+		log.Debug().Msgf("Pod %s does no have lease or heart beat annotation", pod.Name)
+	} else if expired {
+		log.Debug().Msgf(" * pod %s expired, leases: %s", pod.Name, pod.Annotations[util.KtLease])
 		if pod.DeletionTimestamp == nil {
 			resourceToClean.PodsToDelete = append(resourceToClean.PodsToDelete, pod.Name)
 		}
@@ -224,51 +306,74 @@ func analysisExpiredPods(pod coreV1.Pod, cleanThresholdInMinus int64, resourceTo
 	}
 }
 
-func analysisExpiredConfigmaps(cf coreV1.ConfigMap, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
-	lastHeartBeat := util.ParseTimestamp(cf.Annotations[util.KtLastHeartBeat])
-	if lastHeartBeat < 0 {
-		log.Debug().Msgf("Configmap %s does no have heart beat annotation", cf.Name)
-	} else if isExpired(lastHeartBeat, cleanThresholdInMinus) {
+func analysisExpiredConfigmaps(cf coreV1.ConfigMap, leases map[string]coordV1.Lease, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
+	if tracked, expired := isResourceExpired(cf.Annotations, leases, cleanThresholdInMinus); !tracked {
+		log.Debug().Msgf("Configmap %s does no have lease or heart beat annotation", cf.Name)
+	} else if expired {
 		resourceToClean.ConfigMapsToDelete = append(resourceToClean.ConfigMapsToDelete, cf.Name)
 	}
 }
 
-func analysisExpiredDeployments(app appV1.Deployment, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
-	lastHeartBeat := util.ParseTimestamp(app.Annotations[util.KtLastHeartBeat])
-	if lastHeartBeat < 0 {
-		log.Debug().Msgf("Deployment %s does no have heart beat annotation", app.Name)
-	} else if isExpired(lastHeartBeat, cleanThresholdInMinus) {
+func analysisExpiredDeployments(app appV1.Deployment, leases map[string]coordV1.Lease, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
+	if tracked, expired := isResourceExpired(app.Annotations, leases, cleanThresholdInMinus); !tracked {
+		log.Debug().Msgf("Deployment %s does no have lease or heart beat annotation", app.Name)
+	} else if expired {
 		resourceToClean.DeploymentsToDelete = append(resourceToClean.DeploymentsToDelete, app.Name)
 		analysisConfigAnnotation(app.Labels[util.KtRole], util.String2Map(app.Annotations[util.KtConfig]), resourceToClean)
 	}
 }
 
-func analysisExpiredServices(svc coreV1.Service, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
-	lastHeartBeat := util.ParseTimestamp(svc.Annotations[util.KtLastHeartBeat])
-	if lastHeartBeat < 0 {
-		log.Debug().Msgf("Service %s does no have heart beat annotation", svc.Name)
-	} else if isExpired(lastHeartBeat, cleanThresholdInMinus) {
+func analysisExpiredServices(svc coreV1.Service, leases map[string]coordV1.Lease, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
+	if tracked, expired := isResourceExpired(svc.Annotations, leases, cleanThresholdInMinus); !tracked {
+		log.Debug().Msgf("Service %s does no have lease or heart beat annotation", svc.Name)
+	} else if expired {
 		resourceToClean.ServicesToDelete = append(resourceToClean.ServicesToDelete, svc.Name)
 	}
 }
 
-func analysisLockAndOrphanServices(svcs []coreV1.Service, resourceToClean *ResourceToClean) {
+func analysisExpiredLeases(leases map[string]coordV1.Lease, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
+	for name, lease := range leases {
+		if cluster.IsLeaseExpired(&lease) && (lease.Spec.RenewTime == nil ||
+			isExpired(lease.Spec.RenewTime.Unix(), cleanThresholdInMinus)) {
+			resourceToClean.LeasesToDelete = append(resourceToClean.LeasesToDelete, name)
+		}
+	}
+}
+
+// isResourceExpired check session leases and heart beat annotation of resource, resource is expired only after
+// neither of them has been updated within the clean threshold, or none of its leases exists anymore
+func isResourceExpired(annotations map[string]string, leases map[string]coordV1.Lease, cleanThresholdInMinus int64) (bool, bool) {
+	leaseTracked, alive := cluster.CheckSessionLeases(annotations, leases)
+	if alive {
+		return true, false
+	}
+	lastActive := cluster.LastRenewTime(annotations, leases)
+	if lastHeartBeat := util.ParseTimestamp(annotations[util.KtLastHeartBeat]); lastHeartBeat > lastActive {
+		lastActive = lastHeartBeat
+	}
+	if lastActive < 0 {
+		return leaseTracked, leaseTracked
+	}
+	return true, isExpired(lastActive, cleanThresholdInMinus)
+}
+
+func analysisLockAndOrphanServices(svcs []coreV1.Service, leases map[string]coordV1.Lease, resourceToClean *ResourceToClean) {
 	for _, svc := range svcs {
 		if svc.Annotations == nil {
 			continue
 		}
-		if lock, exists := svc.Annotations[util.KtLock]; exists && util.GetTime() - util.ParseTimestamp(lock) > general.LockTimeout {
+		if lock, exists := svc.Annotations[util.KtLock]; exists && util.GetTime() - util.ParseTimestamp(lock) > util.LockLeaseDurationSec {
 			resourceToClean.ServicesToUnlock = append(resourceToClean.ServicesToUnlock, svc.Name)
 		}
 		if svc.Annotations[util.KtSelector] != "" {
 			if svc.Spec.Selector[util.KtRole] == util.RoleRouter {
-				// it's a meshed service, but router pod already gone
-				if !isRouterPodExist(svc.Name, svc.Namespace) {
+				// it's a meshed service, but router pod already gone or expired
+				if !isRouterPodAlive(svc.Name, svc.Namespace, leases) {
 					resourceToClean.ServicesToRecover = append(resourceToClean.ServicesToRecover, svc.Name)
 				}
 			} else {
-				// it's an exchanged service, but shadow pod already gone
-				if !isShadowPodExist(svc.Spec.Selector, svc.Name, svc.Namespace, util.KtExchangeContainer) {
+				// it's an exchanged service, but shadow pod already gone or expired
+				if !isShadowPodAlive(svc.Spec.Selector, svc.Name, svc.Namespace, util.KtExchangeContainer, leases) {
 					resourceToClean.ServicesToRecover = append(resourceToClean.ServicesToRecover, svc.Name)
 				}
 			}
@@ -276,6 +381,40 @@ func analysisLockAndOrphanServices(svcs []coreV1.Service, resourceToClean *Resou
 	}
 }
 
+// analysisTakenOverEndpoints find services whose endpoints are emptied by kt or whose kt endpoint slices are expired,
+// while none of kt endpoint slices of the service is still alive
+func analysisTakenOverEndpoints(slices []discoveryV1.EndpointSlice, endpoints []coreV1.Endpoints,
+	leases map[string]coordV1.Lease, cleanThresholdInMinus int64, resourceToClean *ResourceToClean) {
+	aliveServices := map[string]bool{}
+	var candidates []string
+	for _, s := range slices {
+		svcName := s.Labels[discoveryV1.LabelServiceName]
+		if svcName == "" {
+			continue
+		}
+		if s.Labels[discoveryV1.LabelManagedBy] == util.KubernetesToolkit {
+			if _, expired := isResourceExpired(s.Annotations, leases, cleanThresholdInMinus); expired {
+				log.Debug().Msgf(" * endpoint slice %s expired, leases: %s", s.Name, s.Annotations[util.KtLease])
+				candidates = append(candidates, svcName)
+			} else {
+				aliveServices[svcName] = true
+			}
+		} else if _, exists := s.Annotations[util.KtEndpoints]; exists {
+			candidates = append(candidates, svcName)
+		}
+	}
+	for _, ep := range endpoints {
+		if _, exists := ep.Annotations[util.KtEndpoints]; exists {
+			candidates = append(candidates, ep.Name)
+		}
+	}
+	for _, svcName := range distinct(candidates) {
+		if !aliveServices[svcName] {
+			resourceToClean.EndpointsToRecover = append(resourceToClean.EndpointsToRecover, svcName)
+		}
+	}
+}
+
 func analysisConfigAnnotation(role string, config map[string]string, resourceToClean *ResourceToClean) {
 	log.Debug().Msgf("   role %s, config: %v", role, config)
 	// scale exchange
@@ -294,23 +433,28 @@ func analysisConfigAnnotation(role string, config map[string]string, resourceToC
 	}
 }
 
-func isShadowPodExist(selector map[string]string, svcName, namespace, suffix string) bool {
+func isShadowPodAlive(selector map[string]string, svcName, namespace, suffix string, leases map[string]coordV1.Lease) bool {
 	pods, err := cluster.Ins().GetPodsByLabel(selector, namespace)
 	if err != nil {
 		return false
 	}
 	for _, pod := range pods.Items {
-		if strings.HasPrefix(pod.Name, fmt.Sprintf("%s-%s-", svcName, suffix)) {
+		if strings.HasPrefix(pod.Name, fmt.Sprintf("%s-%s-", svcName, suffix)) && !isPodExpired(pod, leases) {
 			return true
 		}
 	}
 	return false
 }
 
-func isRouterPodExist(svcName, namespace string) bool {
+func isRouterPodAlive(svcName, namespace string, leases map[string]coordV1.Lease) bool {
 	routerPodName := svcName + util.RouterPodSuffix
-	_, err := cluster.Ins().GetPod(routerPodName, namespace)
-	return err == nil
+	pod, err := cluster.Ins().GetPod(routerPodName, namespace)
+	return err == nil && !isPodExpired(*pod, leases)
+}
+
+func isPodExpired(pod coreV1.Pod, leases map[string]coordV1.Lease) bool {
+	_, expired := isResourceExpired(pod.Annotations, leases, opt.Get().Clean.ThresholdInMinus)
+	return expired
 }
 
 func isExpired(lastHeartBeat, cleanThresholdInMinus int64) bool {
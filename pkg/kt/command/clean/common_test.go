@@ -1,7 +1,15 @@
 package clean
 
 import (
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	coordV1 "k8s.io/api/coordination/v1"
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func Test_toPid(t *testing.T) {
@@ -18,3 +26,95 @@ func Test_toPid(t *testing.T) {
 		t.Errorf("unmatch %d", pid)
 	}
 }
+
+func Test_isResourceExpired(t *testing.T) {
+	renewTime := metav1.NewMicroTime(time.Now())
+	recentRenewTime := metav1.NewMicroTime(time.Now().Add(-2 * time.Minute))
+	oldRenewTime := metav1.NewMicroTime(time.Now().Add(-10 * time.Minute))
+	duration := int32(60)
+	leases := map[string]coordV1.Lease{
+		"alive":  {Spec: coordV1.LeaseSpec{RenewTime: &renewTime, LeaseDurationSeconds: &duration}},
+		"recent": {Spec: coordV1.LeaseSpec{RenewTime: &recentRenewTime, LeaseDurationSeconds: &duration}},
+		"old":    {Spec: coordV1.LeaseSpec{RenewTime: &oldRenewTime, LeaseDurationSeconds: &duration}},
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantTracked bool
+		wantExpired bool
+	}{
+		{name: "untracked", annotations: map[string]string{}, wantTracked: false, wantExpired: false},
+		{name: "aliveLease", annotations: map[string]string{util.KtLease: "alive"}, wantTracked: true, wantExpired: false},
+		{name: "missingLease", annotations: map[string]string{util.KtLease: "gone"}, wantTracked: true, wantExpired: true},
+		{name: "leaseExpiredWithinThreshold", annotations: map[string]string{util.KtLease: "recent"},
+			wantTracked: true, wantExpired: false},
+		{name: "leaseExpiredOverThreshold", annotations: map[string]string{util.KtLease: "old,gone"},
+			wantTracked: true, wantExpired: true},
+		{name: "leaseExpiredButRecentHeartBeat", annotations: map[string]string{util.KtLease: "old",
+			util.KtLastHeartBeat: util.GetTimestamp()}, wantTracked: true, wantExpired: false},
+		{name: "recentHeartBeat", annotations: map[string]string{util.KtLastHeartBeat: util.GetTimestamp()},
+			wantTracked: true, wantExpired: false},
+		{name: "oldHeartBeat", annotations: map[string]string{util.KtLastHeartBeat: strconv.FormatInt(util.GetTime()-600, 10)},
+			wantTracked: true, wantExpired: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracked, expired := isResourceExpired(tt.annotations, leases, 5)
+			require.Equal(t, tt.wantTracked, tracked)
+			require.Equal(t, tt.wantExpired, expired)
+		})
+	}
+}
+
+func Test_analysisExpiredLeases(t *testing.T) {
+	recentRenewTime := metav1.NewMicroTime(time.Now().Add(-2 * time.Minute))
+	oldRenewTime := metav1.NewMicroTime(time.Now().Add(-10 * time.Minute))
+	duration := int32(60)
+	leases := map[string]coordV1.Lease{
+		"recent": {Spec: coordV1.LeaseSpec{RenewTime: &recentRenewTime, LeaseDurationSeconds: &duration}},
+		"old":    {Spec: coordV1.LeaseSpec{RenewTime: &oldRenewTime, LeaseDurationSeconds: &duration}},
+	}
+	r := &ResourceToClean{}
+	analysisExpiredLeases(leases, 5, r)
+	require.Equal(t, []string{"old"}, r.LeasesToDelete)
+}
+
+func Test_analysisTakenOverEndpoints(t *testing.T) {
+	renewTime := metav1.NewMicroTime(time.Now())
+	duration := int32(60)
+	leases := map[string]coordV1.Lease{
+		"alive": {Spec: coordV1.LeaseSpec{RenewTime: &renewTime, LeaseDurationSeconds: &duration}},
+	}
+	slice := func(name, svcName string, labels, annotations map[string]string) discoveryV1.EndpointSlice {
+		return discoveryV1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{Name: name,
+			Labels: util.MergeMap(labels, map[string]string{discoveryV1.LabelServiceName: svcName}), Annotations: annotations}}
+	}
+	ktManaged := map[string]string{discoveryV1.LabelManagedBy: util.KubernetesToolkit}
+	slices := []discoveryV1.EndpointSlice{
+		// exchanged service with alive shadow slice
+		slice("live-origin", "live", nil, map[string]string{util.KtEndpoints: "[]"}),
+		slice("live-kt", "live", ktManaged, map[string]string{util.KtLease: "alive"}),
+		// exchanged service whose session is gone
+		slice("dead-origin", "dead", nil, map[string]string{util.KtEndpoints: "[]"}),
+		slice("dead-kt", "dead", ktManaged, map[string]string{util.KtLease: "gone"}),
+		// orphaned kt slice only
+		slice("orphan-kt", "orphan", ktManaged, map[string]string{util.KtLastHeartBeat: "1"}),
+		// untouched service
+		slice("normal", "normal", nil, nil),
+	}
+	endpoints := []coreV1.Endpoints{
+		{ObjectMeta: metav1.ObjectMeta{Name: "emptied", Annotations: map[string]string{util.KtEndpoints: "[]"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "normal"}},
+	}
+	r := &ResourceToClean{}
+	analysisTakenOverEndpoints(slices, endpoints, leases, 5, r)
+	require.Equal(t, []string{"dead", "orphan", "emptied"}, r.EndpointsToRecover)
+}
+
+func TestResourceToClean_IsEmpty(t *testing.T) {
+	r := &ResourceToClean{}
+	require.True(t, r.IsEmpty())
+	r.LeasesToDelete = []string{"kt-session-abc"}
+	require.False(t, r.IsEmpty())
+	require.Equal(t, []string{"a", "b"}, distinct([]string{"a", "b", "a"}))
+}
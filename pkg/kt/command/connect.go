@@ -82,7 +82,7 @@ func preCheck() error {
 }
 
 func silenceCleanup() {
-	if r, err := clean.CheckClusterResources(); err == nil {
+	if r, err := clean.CheckClusterResources(opt.Get().Global.Namespace); err == nil {
 		for _, name := range r.PodsToDelete {
 			_ = cluster.Ins().RemovePod(name, opt.Get().Global.Namespace)
 		}
@@ -108,5 +108,8 @@ func checkPermissionAndOptions() error {
 	if opt.Get().Connect.Mode == util.ConnectModeTun2Socks && opt.Get().Connect.DnsMode == util.DnsModePodDns {
 		return fmt.Errorf("dns mode '%s' is not available for connect mode '%s'", util.DnsModePodDns, util.ConnectModeTun2Socks)
 	}
+	if opt.Get().Global.RestrictedShadow && opt.Get().Connect.DnsMode == util.DnsModePodDns {
+		return fmt.Errorf("dns mode '%s' is not available for restricted shadow pod", util.DnsModePodDns)
+	}
 	return nil
 }
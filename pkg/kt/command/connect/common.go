@@ -3,9 +3,11 @@ package connect
 import (
 	"fmt"
 	"github.com/alibaba/kt-connect/pkg/common"
+	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/service/dns"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
 	"github.com/alibaba/kt-connect/pkg/kt/transmission"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
@@ -27,19 +29,23 @@ func setupDns(shadowPodName, shadowPodIp string) error {
 		}
 	} else if opt.Get().Connect.DnsMode == util.DnsModePodDns {
 		log.Info().Msgf("Setting up dns in pod mode")
+		journal.Record(journal.Entry{Action: journal.ActionNameServer})
 		return dns.SetNameServer(shadowPodIp)
 	} else if strings.HasPrefix(opt.Get().Connect.DnsMode, util.DnsModeLocalDns) {
 		log.Info().Msgf("Setting up dns in local mode")
 		svcToIp, headlessPods := getServiceHosts(opt.Get().Global.Namespace, true)
+		journal.Record(journal.Entry{Action: journal.ActionHosts})
 		if err := dns.DumpHosts(svcToIp, ""); err != nil {
 			return err
 		}
 		watchServicesAndPods(opt.Get().Global.Namespace, svcToIp, headlessPods, true)
 
 		forwardedPodPort := util.GetRandomTcpPort()
-		if _, err := transmission.SetupPortForwardToLocal(shadowPodName, common.StandardDnsPort, forwardedPodPort); err != nil {
+		gone, err := transmission.SetupPortForwardToLocal(shadowPodName, cluster.ShadowDnsPort(), forwardedPodPort)
+		if err != nil {
 			return err
 		}
+		general.ExitOnPortForwardGone(gone)
 
 		dnsPort := util.AlternativeDnsPort
 		if util.IsWindows() {
@@ -53,6 +59,7 @@ func setupDns(shadowPodName, shadowPodIp string) error {
 			log.Error().Err(err).Msgf("Failed to setup local dns server")
 			return err
 		}
+		journal.Record(journal.Entry{Action: journal.ActionNameServer})
 		return dns.SetNameServer(fmt.Sprintf("%s:%d", common.Localhost, dnsPort))
 	} else {
 		return fmt.Errorf("invalid dns mode: '%s', supportted mode are %s, %s, %s", opt.Get().Connect.DnsMode,
@@ -100,6 +107,7 @@ func dumpToHost(targetNamespaces string) error {
 			namespacesToDump = append(namespacesToDump, ns)
 		}
 	}
+	journal.Record(journal.Entry{Action: journal.ActionHosts})
 	hosts := map[string]string{}
 	for _, namespace := range namespacesToDump {
 		log.Debug().Msgf("Search service in %s namespace ...", namespace)
@@ -1,7 +1,7 @@
 package connect
 
 import (
-	"github.com/alibaba/kt-connect/pkg/common"
+	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/service/sshuttle"
@@ -22,9 +22,11 @@ func BySshuttle() error {
 	cidr, excludeCidr := cluster.Ins().ClusterCidr(opt.Get().Global.Namespace)
 
 	localSshPort := util.GetRandomTcpPort()
-	if _, err = transmission.SetupPortForwardToLocal(podName, common.StandardSshPort, localSshPort); err != nil {
+	gone, err := transmission.SetupPortForwardToLocal(podName, cluster.ShadowSshPort(), localSshPort)
+	if err != nil {
 		return err
 	}
+	general.ExitOnPortForwardGone(gone)
 
 	req := &sshuttle.SSHVPNRequest{
 		LocalSshPort:           localSshPort,
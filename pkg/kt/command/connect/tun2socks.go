@@ -3,8 +3,10 @@ package connect
 import (
 	"fmt"
 	"github.com/alibaba/kt-connect/pkg/common"
+	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
 	"github.com/alibaba/kt-connect/pkg/kt/service/sshchannel"
 	"github.com/alibaba/kt-connect/pkg/kt/service/tun"
 	"github.com/alibaba/kt-connect/pkg/kt/transmission"
@@ -26,9 +28,11 @@ func ByTun2Socks() error {
 
 	localSshPort := util.GetRandomTcpPort()
 	socksAddr := fmt.Sprintf("socks5://%s:%d", opt.Get().Connect.ProxyAddr, opt.Get().Connect.ProxyPort)
-	if _, err = transmission.SetupPortForwardToLocal(podName, common.StandardSshPort, localSshPort); err != nil {
+	gone, err := transmission.SetupPortForwardToLocal(podName, cluster.ShadowTunnelPort(), localSshPort)
+	if err != nil {
 		return err
 	}
+	general.ExitOnPortForwardGone(gone)
 	if err = startSocks5Connection(podIP, privateKeyPath, localSshPort, true); err != nil {
 		return err
 	}
@@ -61,6 +65,7 @@ func ByTun2Socks() error {
 func setupTunRoute() error {
 	cidr, excludeCidr := cluster.Ins().ClusterCidr(opt.Get().Global.Namespace)
 
+	journal.Record(journal.Entry{Action: journal.ActionRoute})
 	err := tun.Ins().SetRoute(cidr, excludeCidr)
 	if err != nil {
 		if tun.IsAllRouteFailError(err) {
@@ -128,7 +133,7 @@ func setupSocks5HeartBeat(podIP, socks5Address string) *time.Ticker {
 		for {
 			select {
 			case <-ticker.C:
-				if c, err2 := dialer.Dial("tcp", fmt.Sprintf("[%s]:%d", podIP, common.StandardSshPort)); err2 != nil {
+				if c, err2 := dialer.Dial("tcp", fmt.Sprintf("[%s]:%d", podIP, cluster.ShadowSshPort())); err2 != nil {
 					log.Debug().Err(err2).Msgf("Socks proxy heartbeat interrupted")
 				} else {
 					_ = c.Close()
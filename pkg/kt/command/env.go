@@ -0,0 +1,79 @@
+package command
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/command/general"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// NewEnvCommand return new env command
+func NewEnvCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Fetch environment variables and mounted files of specified workload, and run local command with them",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("name of workload to fetch environment is required")
+			} else if len(args) > 1 {
+				return fmt.Errorf("too many workload names are spcified (%s), should be one", strings.Join(args, ","))
+			}
+			opt.Get().Global.UseLocalTime = true
+			return general.Prepare()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return Env(args[0])
+		},
+		Example: "ktctl env <workload-name> [command options]",
+	}
+
+	cmd.SetUsageTemplate(general.UsageTemplate(true))
+	opt.SetOptions(cmd, cmd.Flags(), opt.Get().Env, opt.EnvFlags())
+	return cmd
+}
+
+// Env print environment of workload, or run command with it
+func Env(resourceName string) error {
+	mountRoot := opt.Get().Env.MountRoot
+	if mountRoot == "" && opt.Get().Env.Run == "" {
+		// files should be kept for later use
+		_, name, err := general.ParseResourceName(resourceName)
+		if err != nil {
+			return err
+		}
+		mountRoot = filepath.Join(util.KtMountDir, name)
+	}
+	envs, err := general.ResolveLocalEnv(resourceName, opt.Get().Env.Container, mountRoot)
+	if err != nil {
+		return err
+	}
+	if opt.Get().Env.Run == "" {
+		for _, e := range envs {
+			kv := strings.SplitN(e, "=", 2)
+			fmt.Printf("%s=%s\n", kv[0], quoteIfNeeded(kv[1]))
+		}
+		return nil
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGQUIT)
+	done, err := general.RunLocalProcess(opt.Get().Env.Run, envs)
+	if err != nil {
+		return err
+	}
+	return general.WaitLocalProcess(ch, done)
+}
+
+func quoteIfNeeded(value string) string {
+	if strings.ContainsAny(value, " \t\r\n\"'\\$#") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/alibaba/kt-connect/pkg/kt/command/exchange"
 	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/inspector"
+	"github.com/alibaba/kt-connect/pkg/kt/service/recorder"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -37,29 +39,43 @@ func NewExchangeCommand() *cobra.Command {
 
 //Exchange exchange kubernetes workload
 func Exchange(resourceName string) error {
+	if opt.Get().Exchange.DryRun {
+		return general.DryRun(util.ComponentExchange, func() error {
+			return exchangeResource(resourceName)
+		})
+	}
 	ch, err := general.SetupProcess(util.ComponentExchange)
 	if err != nil {
 		return err
 	}
 
-	if opt.Get().Exchange.SkipPortChecking {
+	// local application is not started yet when using --run
+	if opt.Get().Exchange.SkipPortChecking && opt.Get().Exchange.Run == "" {
 		if port := util.FindBrokenLocalPort(opt.Get().Exchange.Expose); port != "" {
 			return fmt.Errorf("no application is running on port %s", port)
 		}
 	}
 
-	log.Info().Msgf("Using %s mode", opt.Get().Exchange.Mode)
-	if opt.Get().Exchange.Mode == util.ExchangeModeScale {
-		err = exchange.ByScale(resourceName)
-	} else if opt.Get().Exchange.Mode == util.ExchangeModeEphemeral {
-		err = exchange.ByEphemeralContainer(resourceName)
-	} else if opt.Get().Exchange.Mode == util.ExchangeModeSelector {
-		err = exchange.BySelector(resourceName)
-	} else {
-		err = fmt.Errorf("invalid exchange method '%s', supportted are %s, %s, %s", opt.Get().Exchange.Mode,
-			util.ExchangeModeSelector, util.ExchangeModeScale, util.ExchangeModeEphemeral)
+	if opt.Get().Exchange.Record != "" {
+		if err = recorder.Start(opt.Get().Exchange.Record); err != nil {
+			return err
+		}
 	}
-	if err != nil {
+	if opt.Get().Exchange.Inspect != "" {
+		if err = inspector.Start(opt.Get().Exchange.Inspect); err != nil {
+			return err
+		}
+	}
+
+	// environment must be fetched before target pods are replaced
+	var envs []string
+	if opt.Get().Exchange.Run != "" {
+		if envs, err = general.ResolveLocalEnv(resourceName, "", ""); err != nil {
+			return err
+		}
+	}
+
+	if err = exchangeResource(resourceName); err != nil {
 		return err
 	}
 	resourceType, realName := toTypeAndName(resourceName)
@@ -67,12 +83,35 @@ func Exchange(resourceName string) error {
 	log.Info().Msgf(" Now all request to %s '%s' will be redirected to local", resourceType, realName)
 	log.Info().Msg("---------------------------------------------------------------")
 
+	if opt.Get().Exchange.Run != "" {
+		done, err2 := general.RunLocalProcess(opt.Get().Exchange.Run, envs)
+		if err2 != nil {
+			return err2
+		}
+		return general.WaitLocalProcess(ch, done)
+	}
+
 	// watch background process, clean the workspace and exit if background process occur exception
 	s := <-ch
 	log.Info().Msgf("Terminal Signal is %s", s)
 	return nil
 }
 
+func exchangeResource(resourceName string) error {
+	log.Info().Msgf("Using %s mode", opt.Get().Exchange.Mode)
+	if opt.Get().Exchange.Mode == util.ExchangeModeScale {
+		return exchange.ByScale(resourceName)
+	} else if opt.Get().Exchange.Mode == util.ExchangeModeEphemeral {
+		return exchange.ByEphemeralContainer(resourceName)
+	} else if opt.Get().Exchange.Mode == util.ExchangeModeSelector {
+		return exchange.BySelector(resourceName)
+	} else if opt.Get().Exchange.Mode == util.ExchangeModeEndpoints {
+		return exchange.ByEndpoints(resourceName)
+	}
+	return fmt.Errorf("invalid exchange method '%s', supportted are %s, %s, %s, %s", opt.Get().Exchange.Mode,
+		util.ExchangeModeSelector, util.ExchangeModeScale, util.ExchangeModeEndpoints, util.ExchangeModeEphemeral)
+}
+
 func toTypeAndName(name string) (string, string) {
 	parts := strings.Split(name, "/")
 	if len(parts) > 1 {
@@ -0,0 +1,134 @@
+package exchange
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/command/general"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/transmission"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"net"
+	"strings"
+)
+
+func ByEndpoints(resourceName string) error {
+	if err := general.CheckWorkloadMode(resourceName, opt.Get().Global.Namespace, util.ExchangeModeEndpoints); err != nil {
+		return err
+	}
+	svc, err := general.GetServiceByResourceName(resourceName, opt.Get().Global.Namespace)
+	if err != nil {
+		return err
+	}
+	if err = checkEndpointsMode(svc); err != nil {
+		return err
+	}
+	if port := util.FindInvalidRemotePort(opt.Get().Exchange.Expose, general.GetTargetPorts(svc)); port != "" {
+		return fmt.Errorf("target port %s not exists in service %s", port, svc.Name)
+	}
+
+	// Lock service to avoid conflict, must be first step
+	svc, err = general.LockService(svc.Name, opt.Get().Global.Namespace)
+	if err != nil {
+		return err
+	}
+	defer general.UnlockService(svc.Name, opt.Get().Global.Namespace)
+
+	slices, err := cluster.Ins().GetEndpointSlicesOfService(svc.Name, opt.Get().Global.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, s := range slices {
+		if s.Labels[discoveryV1.LabelManagedBy] == util.KubernetesToolkit {
+			return fmt.Errorf("service '%s' is already exchanging by another user, cannot apply exchange", svc.Name)
+		}
+	}
+
+	// Create shadow pod
+	shadowName := svc.Name + util.ExchangePodInfix + strings.ToLower(util.RandomString(5))
+	shadowLabels := map[string]string{
+		util.KtRole:   util.RoleExchangeShadow,
+		util.KtTarget: util.RandomString(20),
+	}
+	annotation := map[string]string{
+		util.KtConfig: fmt.Sprintf("service=%s", svc.Name),
+	}
+	_, podName, privateKeyPath, err := cluster.Ins().GetOrCreateShadow(shadowName, shadowLabels, annotation,
+		map[string]string{}, opt.Get().Exchange.Expose, map[int]string{})
+	if err != nil {
+		return err
+	}
+	if !cluster.IsDryRun() {
+		_, gone, err2 := transmission.ForwardPodToLocal(opt.Get().Exchange.Expose, podName, privateKeyPath)
+		if err2 != nil {
+			return err2
+		}
+		general.ExitOnPortForwardGone(gone)
+	}
+	pod, err := cluster.Ins().GetPod(podName, opt.Get().Global.Namespace)
+	if err != nil {
+		return err
+	}
+
+	// Let traffic of target service go to shadow pod
+	opt.Store.Origin = svc.Name
+	if err = general.TakeOverServiceEndpoints(svc.Name, opt.Get().Global.Namespace); err != nil {
+		return err
+	}
+	if _, err = cluster.Ins().CreateEndpointSlice(toShadowEndpointSlice(svc, pod)); err != nil {
+		return err
+	}
+	log.Info().Msgf("Endpoints of service %s taken over by shadow pod %s", svc.Name, pod.Name)
+	return nil
+}
+
+// checkEndpointsMode endpoints mode only applies to service without selector, slices of service with selector are
+// owned by endpointslice-controller, which rebuilds them as soon as they are modified, and kube-proxy balances
+// traffic across all slices of a service, so original pods could never be excluded without touching the selector
+func checkEndpointsMode(svc *coreV1.Service) error {
+	if len(svc.Spec.Selector) > 0 {
+		return fmt.Errorf("exchange mode '%s' is not supported for service '%s' which has selector, "+
+			"please use '%s' mode to keep the service untouched, or '%s' or '%s' mode instead",
+			util.ExchangeModeEndpoints, svc.Name, util.ExchangeModeEphemeral, util.ExchangeModeSelector, util.ExchangeModeScale)
+	}
+	return nil
+}
+
+func toShadowEndpointSlice(svc *coreV1.Service, pod *coreV1.Pod) *discoveryV1.EndpointSlice {
+	addressType := discoveryV1.AddressTypeIPv4
+	if ip := net.ParseIP(pod.Status.PodIP); ip != nil && ip.To4() == nil {
+		addressType = discoveryV1.AddressTypeIPv6
+	}
+	var ports []discoveryV1.EndpointPort
+	for i := range svc.Spec.Ports {
+		p := svc.Spec.Ports[i]
+		port := p.Port
+		if p.TargetPort.Type == intstr.Int && p.TargetPort.IntVal > 0 {
+			port = p.TargetPort.IntVal
+		}
+		ports = append(ports, discoveryV1.EndpointPort{Name: &p.Name, Protocol: &p.Protocol, Port: &port})
+	}
+	ready := true
+	return &discoveryV1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: svc.Namespace,
+			Labels: map[string]string{
+				discoveryV1.LabelServiceName: svc.Name,
+				util.KtRole:                  util.RoleExchangeShadow,
+			},
+			Annotations: map[string]string{util.KtConfig: fmt.Sprintf("service=%s", svc.Name)},
+		},
+		AddressType: addressType,
+		Endpoints: []discoveryV1.Endpoint{{
+			Addresses:  []string{pod.Status.PodIP},
+			Conditions: discoveryV1.EndpointConditions{Ready: &ready},
+			TargetRef:  &coreV1.ObjectReference{Kind: "Pod", Name: pod.Name, Namespace: pod.Namespace, UID: pod.UID},
+		}},
+		Ports: ports,
+	}
+}
@@ -0,0 +1,37 @@
+package exchange
+
+import (
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"testing"
+)
+
+func Test_checkEndpointsMode(t *testing.T) {
+	svc := &coreV1.Service{ObjectMeta: metav1.ObjectMeta{Name: "tomcat"}}
+	require.Nil(t, checkEndpointsMode(svc))
+	svc.Spec.Selector = map[string]string{"app": "tomcat"}
+	require.NotNil(t, checkEndpointsMode(svc))
+}
+
+func Test_toShadowEndpointSlice(t *testing.T) {
+	svc := &coreV1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "tomcat", Namespace: "default"},
+		Spec: coreV1.ServiceSpec{Ports: []coreV1.ServicePort{
+			{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080), Protocol: coreV1.ProtocolTCP},
+		}},
+	}
+	pod := &coreV1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "tomcat-kt-abcde", Namespace: "default"},
+		Status:     coreV1.PodStatus{PodIP: "fd00::1"},
+	}
+	slice := toShadowEndpointSlice(svc, pod)
+	require.Equal(t, "tomcat", slice.Labels[discoveryV1.LabelServiceName])
+	require.Equal(t, util.RoleExchangeShadow, slice.Labels[util.KtRole])
+	require.Equal(t, discoveryV1.AddressTypeIPv6, slice.AddressType)
+	require.Equal(t, []string{"fd00::1"}, slice.Endpoints[0].Addresses)
+	require.Equal(t, int32(8080), *slice.Ports[0].Port)
+}
@@ -17,8 +17,18 @@ import (
 
 func ByEphemeralContainer(resourceName string) error {
 	log.Warn().Msgf("Experimental feature. It just works on kubernetes above v1.23, and it can NOT work with istio.")
+	if opt.Get().Global.RestrictedShadow {
+		return fmt.Errorf("exchange mode '%s' is not available for restricted shadow pod", util.ExchangeModeEphemeral)
+	}
 
 	pods, err := getPodsOfResource(resourceName, opt.Get().Global.Namespace)
+	if err != nil {
+		return err
+	}
+	// Keep controller of custom workload from replacing exchanged pods
+	if err = general.SuspendWorkload(resourceName, opt.Get().Global.Namespace, util.ExchangeModeEphemeral); err != nil {
+		return err
+	}
 
 	for _, pod := range pods {
 		if pod.Status.Phase != coreV1.PodRunning {
@@ -32,11 +42,15 @@ func ByEphemeralContainer(resourceName string) error {
 
 		// record data
 		opt.Store.Shadow = util.Append(opt.Store.Shadow, pod.Name)
+		if cluster.IsDryRun() {
+			continue
+		}
 
-		localSSHPort, err2 := transmission.ForwardPodToLocal(opt.Get().Exchange.Expose, pod.Name, privateKey)
+		localSSHPort, gone, err2 := transmission.ForwardPodToLocal(opt.Get().Exchange.Expose, pod.Name, privateKey)
 		if err2 != nil {
 			return err2
 		}
+		general.ExitOnPortForwardGone(gone)
 		err = exchangeWithEphemeralContainer(opt.Get().Exchange.Expose, localSSHPort, privateKey)
 		if err != nil {
 			return err
@@ -65,6 +79,20 @@ func getPodsOfResource(resourceName, namespace string) ([]coreV1.Pod, error) {
 	case "service":
 		return getPodsOfService(name, namespace)
 	}
+	if obj, plugin, isWorkload, err2 := general.GetWorkload(resourceName, namespace); isWorkload {
+		if err2 != nil {
+			return nil, err2
+		}
+		labels, err2 := plugin.GetPodLabels(obj)
+		if err2 != nil {
+			return nil, err2
+		}
+		pods, err2 := cluster.Ins().GetPodsByLabel(labels, namespace)
+		if err2 != nil {
+			return nil, err2
+		}
+		return pods.Items, nil
+	}
 	return nil, fmt.Errorf("invalid resource type: %s", resourceType)
 }
 
@@ -87,6 +115,8 @@ func createEphemeralContainer(containerName, podName string) (string, error) {
 	privateKey, err := cluster.Ins().AddEphemeralContainer(containerName, podName, envs)
 	if err != nil {
 		return "", err
+	} else if cluster.IsDryRun() {
+		return privateKey, nil
 	}
 
 	for i := 0; i < 10; i++ {
@@ -5,13 +5,23 @@ import (
 	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
+	"github.com/alibaba/kt-connect/pkg/kt/service/workload"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
 	appV1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"strings"
 )
 
 func ByScale(resourceName string) error {
+	if obj, plugin, isWorkload, err := general.GetWorkload(resourceName, opt.Get().Global.Namespace); isWorkload {
+		if err != nil {
+			return err
+		}
+		return scaleWorkload(resourceName, obj, plugin)
+	}
+
 	app, err := general.GetDeploymentByResourceName(resourceName, opt.Get().Global.Namespace)
 	if err != nil {
 		return err
@@ -30,6 +40,11 @@ func ByScale(resourceName string) error {
 	}
 
 	down := int32(0)
+	if opt.Get().Global.UseSessionController {
+		return general.RecordReplicasChange(app.Name, opt.Get().Global.Namespace, down, opt.Store.Replicas)
+	}
+	journal.Record(journal.Entry{Action: journal.ActionScale, Kind: "deployment", Name: app.Name,
+		Namespace: opt.Get().Global.Namespace, Replicas: opt.Store.Replicas})
 	if err = cluster.Ins().ScaleTo(app.Name, opt.Get().Global.Namespace, &down); err != nil {
 		return err
 	}
@@ -37,6 +52,28 @@ func ByScale(resourceName string) error {
 	return nil
 }
 
+// scaleWorkload let controller of custom workload scale it down, instead of scaling deployment directly
+func scaleWorkload(resourceName string, obj *unstructured.Unstructured, plugin workload.Plugin) error {
+	labels, err := plugin.GetPodLabels(obj)
+	if err != nil {
+		return err
+	}
+	// check whether workload supports scale mode before creating anything
+	if _, _, err = plugin.Suspend(obj, util.ExchangeModeScale); err != nil {
+		return err
+	}
+	labels[util.KtRole] = util.RoleExchangeShadow
+
+	shadowPodName := obj.GetName() + util.ExchangePodInfix + strings.ToLower(util.RandomString(5))
+	log.Info().Msgf("Creating exchange shadow %s in namespace %s", shadowPodName, opt.Get().Global.Namespace)
+	annotations := map[string]string{util.KtConfig: fmt.Sprintf("workload=%s", resourceName)}
+	if err = general.CreateShadowAndInbound(shadowPodName, opt.Get().Exchange.Expose,
+		labels, annotations, map[int]string{}); err != nil {
+		return err
+	}
+	return general.SuspendWorkload(resourceName, opt.Get().Global.Namespace, util.ExchangeModeScale)
+}
+
 func getExchangeAnnotation() map[string]string {
 	return map[string]string{
 		util.KtConfig: fmt.Sprintf("app=%s,replicas=%d",
@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
 	"strings"
 )
 
 func BySelector(resourceName string) error {
+	// check whether workload supports selector mode before creating anything
+	if err := general.CheckWorkloadMode(resourceName, opt.Get().Global.Namespace, util.ExchangeModeSelector); err != nil {
+		return err
+	}
 	// Get service to exchange
 	svc, err := general.GetServiceByResourceName(resourceName, opt.Get().Global.Namespace)
 	if err != nil {
@@ -20,7 +25,7 @@ func BySelector(resourceName string) error {
 	}
 
 	// Lock service to avoid conflict, must be first step
-	svc, err = general.LockService(svc.Name, opt.Get().Global.Namespace, 0);
+	svc, err = general.LockService(svc.Name, opt.Get().Global.Namespace);
 	if err != nil {
 		return err
 	}
@@ -52,11 +57,21 @@ func BySelector(resourceName string) error {
 		return err
 	}
 
+	// Keep controller of custom workload from reverting service selector
+	if err = general.SuspendWorkload(resourceName, opt.Get().Global.Namespace, util.ExchangeModeSelector); err != nil {
+		return err
+	}
+
 	// Let target service select shadow pod
 	opt.Store.Origin = svc.Name
+	originSelector := svc.Spec.Selector
 	if err = general.UpdateServiceSelector(svc.Name, opt.Get().Global.Namespace, shadowLabels); err != nil {
 		return err
 	}
 
+	if opt.Get().Exchange.Fallback && !cluster.IsDryRun() {
+		general.StartShadowFallback(svc, originSelector, shadowLabels)
+	}
+
 	return nil
 }
@@ -2,6 +2,7 @@ package forward
 
 import (
 	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/transmission"
@@ -19,10 +20,11 @@ func RedirectService(serviceName string, localPort, remotePort int) (int, error)
 		localPort = svcPort
 	}
 	gone, err := transmission.SetupPortForwardToLocal(podName, podPort, localPort)
-	go func() {
-		<-gone
-	}()
-	return localPort, err
+	if err != nil {
+		return localPort, err
+	}
+	general.ExitOnPortForwardGone(gone)
+	return localPort, nil
 }
 
 func RedirectAddress(remoteAddress string, localPort, remotePort int) error {
@@ -0,0 +1,32 @@
+package general
+
+import (
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/rs/zerolog/log"
+	"strings"
+)
+
+// DryRun walk through the changes of component without applying them, and print the plan
+func DryRun(componentName string, f func() error) error {
+	opt.Store.Component = componentName
+	k := cluster.EnableDryRun()
+	log.Info().Msgf("Dry run mode, no change will be applied to cluster")
+	if err := f(); err != nil {
+		return err
+	}
+	plan := k.Plan()
+	log.Info().Msg("---------------------------------------------------------------")
+	if len(plan) == 0 {
+		log.Info().Msgf(" Nothing to change")
+	} else {
+		log.Info().Msgf(" Following %d changes would be applied:", len(plan))
+		for _, item := range plan {
+			for _, line := range strings.Split(item.String(), "\n") {
+				log.Info().Msgf(" %s", line)
+			}
+		}
+	}
+	log.Info().Msg("---------------------------------------------------------------")
+	return nil
+}
@@ -0,0 +1,188 @@
+package general
+
+import (
+	"encoding/json"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"sync"
+)
+
+// endpointSliceMirroringController slices managed by it are kept in sync with the Endpoints object
+const endpointSliceMirroringController = "endpointslicemirroring-controller.k8s.io"
+
+// originEndpoints local copy of taken over endpoints, which is preferred over the annotation,
+// in case the annotation is lost or modified by others (e.g. gitops tools)
+var originEndpoints = map[string]string{}
+var originEndpointsLock sync.Mutex
+
+// TakeOverServiceEndpoints empty existing endpoints of service, original ones are kept in annotation and journal,
+// changes already made are rolled back if any step failed
+func TakeOverServiceEndpoints(svcName, namespace string) error {
+	journal.Record(journal.Entry{Action: journal.ActionEndpoints, Kind: "service", Name: svcName, Namespace: namespace})
+	if err := takeOverServiceEndpoints(svcName, namespace); err != nil {
+		log.Warn().Msgf("Failed to take over endpoints of service %s, rolling back", svcName)
+		RecoverServiceEndpoints(svcName, namespace)
+		return err
+	}
+	return nil
+}
+
+func takeOverServiceEndpoints(svcName, namespace string) error {
+	if endpoints, err := cluster.Ins().GetEndpoints(svcName, namespace); err == nil {
+		if _, exists := endpoints.Annotations[util.KtEndpoints]; !exists && len(endpoints.Subsets) > 0 {
+			marshaled, err2 := json.Marshal(endpoints.Subsets)
+			if err2 != nil {
+				return err2
+			}
+			keepOriginEndpoints("endpoints", endpoints.Name, namespace, string(marshaled))
+			endpoints.Annotations = util.MapPut(endpoints.Annotations, util.KtEndpoints, string(marshaled))
+			endpoints.Subsets = nil
+			if _, err = cluster.Ins().UpdateEndpoints(endpoints); err != nil {
+				return err
+			}
+		}
+	} else if !k8sErrors.IsNotFound(err) {
+		return err
+	}
+
+	slices, err := cluster.Ins().GetEndpointSlicesOfService(svcName, namespace)
+	if err != nil {
+		return err
+	}
+	for i := range slices {
+		s := &slices[i]
+		if s.Labels[discoveryV1.LabelManagedBy] == util.KubernetesToolkit ||
+			s.Labels[discoveryV1.LabelManagedBy] == endpointSliceMirroringController {
+			// mirrored slices follow the Endpoints object
+			continue
+		}
+		if _, exists := s.Annotations[util.KtEndpoints]; exists || len(s.Endpoints) == 0 {
+			continue
+		}
+		marshaled, err2 := json.Marshal(s.Endpoints)
+		if err2 != nil {
+			return err2
+		}
+		keepOriginEndpoints("endpointslice", s.Name, namespace, string(marshaled))
+		s.Annotations = util.MapPut(s.Annotations, util.KtEndpoints, string(marshaled))
+		s.Endpoints = []discoveryV1.Endpoint{}
+		if _, err = cluster.Ins().UpdateEndpointSlice(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepOriginEndpoints save original endpoints to journal and local copy before they are emptied
+func keepOriginEndpoints(kind, name, namespace, marshaled string) {
+	journal.Record(journal.Entry{Action: journal.ActionEndpoints, Kind: kind, Name: name, Namespace: namespace,
+		Origin: marshaled})
+	rememberOriginEndpoints(kind, name, namespace, marshaled)
+}
+
+func rememberOriginEndpoints(kind, name, namespace, marshaled string) {
+	originEndpointsLock.Lock()
+	defer originEndpointsLock.Unlock()
+	originEndpoints[kind+"/"+namespace+"/"+name] = marshaled
+}
+
+func forgetOriginEndpoints() {
+	originEndpointsLock.Lock()
+	defer originEndpointsLock.Unlock()
+	originEndpoints = map[string]string{}
+}
+
+// originEndpointsOf original endpoints to restore, local copy is used if the object is still taken over
+// (annotated or emptied), otherwise the annotation, return false if nothing to restore
+func originEndpointsOf(kind, name, namespace string, annotations map[string]string, emptied bool) (string, bool) {
+	originEndpointsLock.Lock()
+	defer originEndpointsLock.Unlock()
+	key := kind + "/" + namespace + "/" + name
+	local, kept := originEndpoints[key]
+	delete(originEndpoints, key)
+	annotated, exists := annotations[util.KtEndpoints]
+	if kept && (exists || emptied) {
+		if exists && annotated != local {
+			log.Warn().Msgf("Annotation %s of %s %s was modified, restoring from local copy", util.KtEndpoints, kind, name)
+		}
+		return local, true
+	}
+	return annotated, exists
+}
+
+// RecoverServiceEndpoints remove endpoint slices created by kt and restore taken over endpoints of service
+func RecoverServiceEndpoints(svcName, namespace string) {
+	slices, err := cluster.Ins().GetEndpointSlicesOfService(svcName, namespace)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to fetch endpoint slices of service %s", svcName)
+		return
+	}
+	for i := range slices {
+		s := &slices[i]
+		if s.Labels[discoveryV1.LabelManagedBy] == util.KubernetesToolkit {
+			if err = cluster.Ins().RemoveEndpointSlice(s.Name, namespace); err != nil {
+				log.Error().Err(err).Msgf("Failed to remove endpoint slice %s", s.Name)
+			}
+			continue
+		}
+		origin, exists := originEndpointsOf("endpointslice", s.Name, namespace, s.Annotations, len(s.Endpoints) == 0)
+		if !exists {
+			continue
+		}
+		var endpoints []discoveryV1.Endpoint
+		if err = json.Unmarshal([]byte(origin), &endpoints); err != nil {
+			log.Error().Err(err).Msgf("Failed to unmarshal original endpoints of endpoint slice %s", s.Name)
+			continue
+		}
+		s.Endpoints = endpoints
+		delete(s.Annotations, util.KtEndpoints)
+		if _, err = cluster.Ins().UpdateEndpointSlice(s); err != nil {
+			log.Error().Err(err).Msgf("Failed to recover endpoint slice %s", s.Name)
+		}
+	}
+
+	endpoints, err := cluster.Ins().GetEndpoints(svcName, namespace)
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			log.Error().Err(err).Msgf("Failed to fetch endpoints of service %s", svcName)
+		}
+		return
+	}
+	if originSubsets, exists := originEndpointsOf("endpoints", svcName, namespace, endpoints.Annotations,
+		len(endpoints.Subsets) == 0); exists {
+		var subsets []coreV1.EndpointSubset
+		if err = json.Unmarshal([]byte(originSubsets), &subsets); err != nil {
+			log.Error().Err(err).Msgf("Failed to unmarshal original endpoints of service %s", svcName)
+			return
+		}
+		endpoints.Subsets = subsets
+		delete(endpoints.Annotations, util.KtEndpoints)
+		if _, err = cluster.Ins().UpdateEndpoints(endpoints); err != nil {
+			log.Error().Err(err).Msgf("Failed to recover endpoints of service %s", svcName)
+		}
+	}
+}
+
+// IsEndpointsTakenOver check whether endpoints of service is taken over by kt
+func IsEndpointsTakenOver(svcName, namespace string) bool {
+	slices, err := cluster.Ins().GetEndpointSlicesOfService(svcName, namespace)
+	if err != nil {
+		return false
+	}
+	for _, s := range slices {
+		if _, exists := s.Annotations[util.KtEndpoints]; exists ||
+			s.Labels[discoveryV1.LabelManagedBy] == util.KubernetesToolkit {
+			return true
+		}
+	}
+	if endpoints, err2 := cluster.Ins().GetEndpoints(svcName, namespace); err2 == nil {
+		_, exists := endpoints.Annotations[util.KtEndpoints]
+		return exists
+	}
+	return false
+}
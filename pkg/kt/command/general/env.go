@@ -0,0 +1,154 @@
+package general
+
+import (
+	"fmt"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/service/env"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ResolveLocalEnv fetch environment variables of target workload, and mirror its mounted files to local directory
+// a temporary directory is used if mountRoot not specified, which would be removed when session ends
+func ResolveLocalEnv(resourceName, containerName, mountRoot string) ([]string, error) {
+	meta, spec, err := getPodTemplate(resourceName, opt.Get().Global.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	environment, err := env.Resolve(meta, spec, containerName)
+	if err != nil {
+		return nil, err
+	}
+	if mountRoot == "" {
+		_, name, _ := ParseResourceName(resourceName)
+		mountRoot = filepath.Join(util.KtMountDir, fmt.Sprintf("%s-%d", name, os.Getpid()))
+		opt.Store.MountRoot = mountRoot
+	}
+	if err = environment.Mirror(mountRoot); err != nil {
+		return nil, fmt.Errorf("failed to mirror mounted files: %s", err)
+	}
+	log.Info().Msgf("Resolved %d environment variables and %d mounted files of %s, files are put in %s",
+		len(environment.Envs), len(environment.Files), resourceName, mountRoot)
+	return append(environment.Export(), fmt.Sprintf("%s=%s", util.EnvMountRoot, mountRoot)), nil
+}
+
+// RunLocalProcess start command with specified environment variables, the process is stopped when session ends
+func RunLocalProcess(cmdline string, envs []string) (chan error, error) {
+	cmd := util.ShellCommand(cmdline)
+	cmd.Env = append(os.Environ(), envs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start '%s': %s", cmdline, err)
+	}
+	log.Info().Msgf("Local process %d started", cmd.Process.Pid)
+	opt.Store.LocalProcess = cmd.Process
+	done := make(chan error)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	return done, nil
+}
+
+// WaitLocalProcess block until local process exited or terminal signal received
+func WaitLocalProcess(ch chan os.Signal, done chan error) error {
+	select {
+	case s := <-ch:
+		log.Info().Msgf("Terminal Signal is %s", s)
+		return nil
+	case err := <-done:
+		opt.Store.LocalProcess = nil
+		if err != nil {
+			return fmt.Errorf("local process exited, %s", err)
+		}
+		log.Info().Msgf("Local process exited")
+		return nil
+	}
+}
+
+// stopLocalProcess terminate local process and remove temporary mirrored files
+func stopLocalProcess() {
+	if opt.Store.LocalProcess != nil {
+		log.Info().Msgf("Stopping local process %d", opt.Store.LocalProcess.Pid)
+		if err := opt.Store.LocalProcess.Signal(os.Interrupt); err != nil {
+			_ = opt.Store.LocalProcess.Kill()
+		} else {
+			time.Sleep(1 * time.Second)
+			if util.IsProcessExist(opt.Store.LocalProcess.Pid) {
+				_ = opt.Store.LocalProcess.Kill()
+			}
+		}
+	}
+	if opt.Store.MountRoot != "" {
+		if err := os.RemoveAll(opt.Store.MountRoot); err != nil {
+			log.Debug().Err(err).Msgf("Failed to remove %s", opt.Store.MountRoot)
+		}
+	}
+}
+
+// getPodTemplate get metadata and spec of pod running the target resource
+func getPodTemplate(resourceName, namespace string) (*metav1.ObjectMeta, *coreV1.PodSpec, error) {
+	resourceType, name, err := ParseResourceName(resourceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch resourceType {
+	case "pod":
+		pod, err2 := cluster.Ins().GetPod(name, namespace)
+		if err2 != nil {
+			return nil, nil, err2
+		}
+		return &pod.ObjectMeta, &pod.Spec, nil
+	case "deploy":
+		fallthrough
+	case "deployment":
+		app, err2 := GetDeploymentByResourceName(resourceName, namespace)
+		if err2 != nil {
+			return nil, nil, err2
+		}
+		meta := app.Spec.Template.ObjectMeta.DeepCopy()
+		meta.Namespace = namespace
+		return meta, &app.Spec.Template.Spec, nil
+	default:
+		labels, err2 := getPodLabelsOfResource(resourceName, namespace)
+		if err2 != nil {
+			return nil, nil, err2
+		}
+		if len(labels) == 0 {
+			return nil, nil, fmt.Errorf("%s does not select any pod", resourceName)
+		}
+		pods, err2 := cluster.Ins().GetPodsByLabel(labels, namespace)
+		if err2 != nil {
+			return nil, nil, err2
+		}
+		for _, pod := range pods.Items {
+			if pod.Labels[util.KtRole] == "" && pod.DeletionTimestamp == nil {
+				return &pod.ObjectMeta, &pod.Spec, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("no running pod of %s found", resourceName)
+	}
+}
+
+// getPodLabelsOfResource labels selecting pods of custom workload or service
+func getPodLabelsOfResource(resourceName, namespace string) (map[string]string, error) {
+	obj, plugin, isWorkload, err := GetWorkload(resourceName, namespace)
+	if isWorkload {
+		if err != nil {
+			return nil, err
+		}
+		return plugin.GetPodLabels(obj)
+	}
+	svc, err := GetServiceByResourceName(resourceName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return svc.Spec.Selector, nil
+}
@@ -0,0 +1,48 @@
+package general
+
+import (
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/workload"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func Test_getPodTemplate(t *testing.T) {
+	podOf := func(name string, labels map[string]string) *coreV1.Pod {
+		return &coreV1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Spec:       coreV1.PodSpec{ServiceAccountName: name},
+		}
+	}
+	opt.Store.Clientset = testclient.NewSimpleClientset(
+		podOf("tomcat-kt-abcde", map[string]string{"app": "tomcat", workload.RolloutPodTemplateHash: "6f8b9c7d5",
+			util.KtRole: util.RoleExchangeShadow}),
+		podOf("tomcat-canary", map[string]string{"app": "tomcat", workload.RolloutPodTemplateHash: "57d6f7b8c"}),
+		podOf("tomcat-stable", map[string]string{"app": "tomcat", workload.RolloutPodTemplateHash: "6f8b9c7d5"}),
+	)
+	opt.Store.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Rollout",
+			"metadata":   map[string]any{"name": "tomcat", "namespace": "default"},
+			"spec": map[string]any{
+				"selector": map[string]any{"matchLabels": map[string]any{"app": "tomcat"}},
+			},
+			"status": map[string]any{"stableRS": "6f8b9c7d5"},
+		},
+	})
+
+	meta, spec, err := getPodTemplate("rollout/tomcat", "default")
+	require.Nil(t, err)
+	require.Equal(t, "tomcat-stable", meta.Name)
+	require.Equal(t, "tomcat-stable", spec.ServiceAccountName)
+	_, _, err = getPodTemplate("rollout/none", "default")
+	require.NotNil(t, err)
+}
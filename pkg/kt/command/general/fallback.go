@@ -0,0 +1,213 @@
+package general
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fallbackCheckInterval = 3 * time.Second
+
+// fallbackWatcher running fallback watcher, the fallback endpoint slice is only touched by its goroutine
+type fallbackWatcher struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+var fallback *fallbackWatcher
+var fallbackLock sync.Mutex
+
+// StartShadowFallback keep adding original pods back to service as endpoints whenever shadow pods are not ready,
+// and removing them after shadow pods become ready again, until RemoveFallbackEndpoints is called
+func StartShadowFallback(svc *coreV1.Service, originSelector, shadowLabels map[string]string) {
+	fallbackLock.Lock()
+	defer fallbackLock.Unlock()
+	if fallback != nil {
+		return
+	}
+	fallback = &fallbackWatcher{stop: make(chan struct{}), done: make(chan struct{})}
+	go func(w *fallbackWatcher) {
+		defer close(w.done)
+		watchShadowFallback(svc, originSelector, shadowLabels, w.stop)
+	}(fallback)
+}
+
+// RemoveFallbackEndpoints stop fallback watcher, and wait for it removing the fallback endpoint slice
+func RemoveFallbackEndpoints() {
+	fallbackLock.Lock()
+	w := fallback
+	fallback = nil
+	fallbackLock.Unlock()
+	if w == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+}
+
+func watchShadowFallback(svc *coreV1.Service, originSelector, shadowLabels map[string]string, stop chan struct{}) {
+	sliceName := svc.Name + util.FallbackSliceSuffix
+	var current *discoveryV1.EndpointSlice
+	ticker := time.NewTicker(fallbackCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			if current != nil {
+				removeFallbackSlice(sliceName, svc.Namespace)
+			}
+			return
+		case <-ticker.C:
+		}
+		if isShadowReady(shadowLabels, svc.Namespace) {
+			if current != nil {
+				if err := cluster.Ins().RemoveEndpointSlice(sliceName, svc.Namespace); err != nil && !k8sErrors.IsNotFound(err) {
+					log.Warn().Err(err).Msgf("Failed to remove fallback endpoints of service %s", svc.Name)
+					continue
+				}
+				current = nil
+				log.Info().Msgf("Local endpoint reachable again, traffic of service %s goes to shadow pod", svc.Name)
+			}
+			continue
+		}
+		pods, err := cluster.Ins().GetPodsByLabel(originSelector, svc.Namespace)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Failed to fetch original pods of service %s", svc.Name)
+			continue
+		}
+		slice := toFallbackEndpointSlice(svc, sliceName, pods.Items)
+		if current == nil {
+			if current, err = cluster.Ins().CreateEndpointSlice(slice); err != nil {
+				log.Warn().Err(err).Msgf("Failed to add fallback endpoints to service %s", svc.Name)
+				current = nil
+				continue
+			}
+			log.Warn().Msgf("Local endpoint unreachable, traffic of service %s falls back to %d original pods",
+				svc.Name, len(slice.Endpoints))
+		} else if !isSameAddresses(current.Endpoints, slice.Endpoints) {
+			current.Endpoints = slice.Endpoints
+			current.Ports = slice.Ports
+			if updated, err2 := cluster.Ins().UpdateEndpointSlice(current); err2 != nil {
+				log.Debug().Err(err2).Msgf("Failed to update fallback endpoints of service %s", svc.Name)
+			} else {
+				current = updated
+			}
+		}
+	}
+}
+
+func removeFallbackSlice(name, namespace string) {
+	if err := cluster.Ins().RemoveEndpointSlice(name, namespace); err != nil && !k8sErrors.IsNotFound(err) {
+		log.Error().Err(err).Msgf("Failed to remove fallback endpoint slice %s", name)
+		return
+	}
+	log.Info().Msgf("Fallback endpoint slice %s removed", name)
+}
+
+func isShadowReady(shadowLabels map[string]string, namespace string) bool {
+	pods, err := cluster.Ins().GetPodsByLabel(shadowLabels, namespace)
+	if err != nil {
+		// treat as ready to avoid flapping on api server error
+		log.Debug().Err(err).Msgf("Failed to fetch shadow pod")
+		return true
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil && isPodReady(&pod) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPodReady(pod *coreV1.Pod) bool {
+	if pod.Status.Phase != coreV1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == coreV1.PodReady {
+			return c.Status == coreV1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func toFallbackEndpointSlice(svc *coreV1.Service, name string, pods []coreV1.Pod) *discoveryV1.EndpointSlice {
+	addressType := discoveryV1.AddressTypeIPv4
+	var endpoints []discoveryV1.Endpoint
+	var samplePod *coreV1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Labels[util.KtRole] != "" || pod.DeletionTimestamp != nil || !isPodReady(pod) || pod.Status.PodIP == "" {
+			continue
+		}
+		if ip := net.ParseIP(pod.Status.PodIP); ip != nil && ip.To4() == nil {
+			addressType = discoveryV1.AddressTypeIPv6
+		}
+		ready := true
+		endpoints = append(endpoints, discoveryV1.Endpoint{
+			Addresses:  []string{pod.Status.PodIP},
+			Conditions: discoveryV1.EndpointConditions{Ready: &ready},
+			TargetRef:  &coreV1.ObjectReference{Kind: "Pod", Name: pod.Name, Namespace: pod.Namespace, UID: pod.UID},
+		})
+		samplePod = pod
+	}
+	var ports []discoveryV1.EndpointPort
+	for i := range svc.Spec.Ports {
+		p := svc.Spec.Ports[i]
+		port := p.Port
+		if p.TargetPort.Type == intstr.Int && p.TargetPort.IntVal > 0 {
+			port = p.TargetPort.IntVal
+		} else if p.TargetPort.Type == intstr.String && samplePod != nil {
+			port = findContainerPort(samplePod, p.TargetPort.StrVal, port)
+		}
+		ports = append(ports, discoveryV1.EndpointPort{Name: &p.Name, Protocol: &p.Protocol, Port: &port})
+	}
+	return &discoveryV1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: svc.Namespace,
+			Labels: map[string]string{
+				discoveryV1.LabelServiceName: svc.Name,
+			},
+			Annotations: map[string]string{util.KtConfig: fmt.Sprintf("service=%s", svc.Name)},
+		},
+		AddressType: addressType,
+		Endpoints:   endpoints,
+		Ports:       ports,
+	}
+}
+
+func findContainerPort(pod *coreV1.Pod, portName string, defaultPort int32) int32 {
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			if cp.Name == portName {
+				return cp.ContainerPort
+			}
+		}
+	}
+	return defaultPort
+}
+
+func isSameAddresses(a, b []discoveryV1.Endpoint) bool {
+	return joinAddresses(a) == joinAddresses(b)
+}
+
+func joinAddresses(endpoints []discoveryV1.Endpoint) string {
+	var addresses []string
+	for _, e := range endpoints {
+		addresses = append(addresses, e.Addresses...)
+	}
+	sort.Strings(addresses)
+	return strings.Join(addresses, ",")
+}
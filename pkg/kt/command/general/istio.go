@@ -0,0 +1,138 @@
+package general
+
+import (
+	"fmt"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	"strings"
+)
+
+const (
+	// KindVirtualService kind name of istio virtual service
+	KindVirtualService = "virtualservice"
+	// KindDestinationRule kind name of istio destination rule
+	KindDestinationRule = "destinationrule"
+	// IstioDefaultRoute name of fallback http route in virtual service created by kt
+	IstioDefaultRoute = "kt-default"
+)
+
+// VirtualServiceGVR group version resource of istio virtual service
+var VirtualServiceGVR = schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "virtualservices"}
+
+// DestinationRuleGVR group version resource of istio destination rule
+var DestinationRuleGVR = schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "destinationrules"}
+
+// IstioRuleName name of http route and subset added for the mesh version
+func IstioRuleName(meshVersion string) string {
+	return "kt-" + meshVersion
+}
+
+// FindIstioRule find virtual service or destination rule of service, return nil if not exist
+func FindIstioRule(kind, svcName, namespace string) (*unstructured.Unstructured, error) {
+	items, err := cluster.Ins().ListCustomResources(istioGVR(kind), namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		var hosts []string
+		if kind == KindVirtualService {
+			hosts, _, _ = unstructured.NestedStringSlice(items[i].Object, "spec", "hosts")
+		} else {
+			host, _, _ := unstructured.NestedString(items[i].Object, "spec", "host")
+			hosts = []string{host}
+		}
+		for _, host := range hosts {
+			if isHostOfService(host, svcName, namespace) {
+				return &items[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// RecordIstioChange record virtual service or destination rule change in journal and runtime store
+func RecordIstioChange(kind, name, namespace string) {
+	journal.Record(journal.Entry{Action: journal.ActionIstio, Kind: kind, Name: name, Namespace: namespace,
+		Patch: IstioRuleName(strings.Split(opt.Store.Mesh, ":")[1])})
+	opt.Store.IstioRules = util.Append(opt.Store.IstioRules, kind+"/"+name)
+}
+
+// RemoveIstioRule remove http route or subset added by kt, and the whole resource if it's created by kt
+// and no other kt session is using it anymore
+func RemoveIstioRule(kind, name, namespace, ruleName string) error {
+	gvr := istioGVR(kind)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := cluster.Ins().GetCustomResource(gvr, name, namespace)
+		if err != nil {
+			return err
+		}
+		field := "http"
+		if kind == KindDestinationRule {
+			field = "subsets"
+		}
+		items, _, _ := unstructured.NestedSlice(obj.Object, "spec", field)
+		var remaining []any
+		inUse := false
+		for _, item := range items {
+			m, ok := item.(map[string]any)
+			if ok && m["name"] == ruleName {
+				continue
+			}
+			if ok && isRuleOfOtherSession(m["name"]) {
+				inUse = true
+			}
+			remaining = append(remaining, item)
+		}
+		if obj.GetLabels()[util.ControlBy] == util.KubernetesToolkit && !inUse {
+			log.Info().Msgf("Removing %s %s", kind, name)
+			return cluster.Ins().RemoveCustomResource(gvr, name, namespace)
+		}
+		if len(remaining) == len(items) {
+			log.Debug().Msgf("No %s named %s found in %s %s", field, ruleName, kind, name)
+			return nil
+		}
+		if err = unstructured.SetNestedSlice(obj.Object, remaining, "spec", field); err != nil {
+			return err
+		}
+		log.Info().Msgf("Removing %s %s from %s %s", field, ruleName, kind, name)
+		return cluster.Ins().UpdateCustomResource(gvr, obj)
+	})
+}
+
+// recoverIstioRules remove istio rule changes made by current process
+func recoverIstioRules() {
+	if opt.Store.IstioRules == "" {
+		return
+	}
+	ruleName := IstioRuleName(strings.Split(opt.Store.Mesh, ":")[1])
+	for _, rule := range strings.Split(opt.Store.IstioRules, ",") {
+		parts := strings.Split(rule, "/")
+		if err := RemoveIstioRule(parts[0], parts[1], opt.Get().Global.Namespace, ruleName); err != nil {
+			log.Error().Err(err).Msgf("Failed to recover %s %s", parts[0], parts[1])
+		}
+	}
+}
+
+// isRuleOfOtherSession check whether the http route or subset is added by kt for a mesh version
+func isRuleOfOtherSession(name any) bool {
+	ruleName, ok := name.(string)
+	return ok && strings.HasPrefix(ruleName, IstioRuleName("")) && ruleName != IstioDefaultRoute
+}
+
+func istioGVR(kind string) schema.GroupVersionResource {
+	if kind == KindVirtualService {
+		return VirtualServiceGVR
+	}
+	return DestinationRuleGVR
+}
+
+func isHostOfService(host, svcName, namespace string) bool {
+	return host == svcName || host == fmt.Sprintf("%s.%s", svcName, namespace) ||
+		strings.HasPrefix(host, fmt.Sprintf("%s.%s.svc", svcName, namespace))
+}
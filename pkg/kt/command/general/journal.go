@@ -0,0 +1,294 @@
+package general
+
+import (
+	"encoding/json"
+	"fmt"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/service/dns"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
+	"github.com/alibaba/kt-connect/pkg/kt/service/tun"
+	"github.com/alibaba/kt-connect/pkg/kt/service/workload"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"path/filepath"
+	"strconv"
+)
+
+// OpenJournal start recording mutating steps of current process
+func OpenJournal(component string) {
+	if err := journal.Open(component, journal.Entry{
+		Cluster:   opt.Store.RestConfig.Host,
+		Holder:    cluster.LeaseHolder(),
+		Lease:     cluster.SessionLeaseName(),
+		Namespace: opt.Get().Global.Namespace,
+	}); err != nil {
+		log.Warn().Err(err).Msgf("Failed to create operation journal, changes could not be rolled back if ktctl crashed")
+	}
+}
+
+// recordSelectorChange record original selector of service in journal
+func recordSelectorChange(svcName, namespace, marshaledSelector string) {
+	var selector map[string]string
+	_ = json.Unmarshal([]byte(marshaledSelector), &selector)
+	journal.Record(journal.Entry{Action: journal.ActionSelector, Kind: "service", Name: svcName,
+		Namespace: namespace, Selector: selector})
+}
+
+// ReplayJournals undo incomplete sessions left by ktctl processes which exited without cleanup
+func ReplayJournals() {
+	for _, path := range journal.ListIncomplete() {
+		claimed, entries, err := journal.Claim(path)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Journal %s is being replayed by another process", path)
+			continue
+		}
+		if len(entries) == 0 || entries[0].Action != journal.ActionBegin {
+			log.Warn().Msgf("Journal %s is broken, discarding", path)
+			journal.Finish(claimed)
+			continue
+		}
+		if entries[0].Cluster != opt.Store.RestConfig.Host {
+			log.Info().Msgf("Journal %s belongs to cluster %s, skipped", filepath.Base(path), entries[0].Cluster)
+			journal.Release(claimed)
+			continue
+		}
+		if needAdmin(entries) && !util.IsRunAsAdmin() {
+			log.Warn().Msgf("Journal %s contains local dns or route changes, please run 'ktctl recover --local' as %s user",
+				filepath.Base(path), util.GetAdminUserName())
+			journal.Release(claimed)
+			continue
+		}
+		log.Info().Msgf("Rolling back incomplete session recorded in %s", filepath.Base(path))
+		replay(entries)
+		journal.Finish(claimed)
+	}
+}
+
+// replay undo entries in reverse order
+func replay(entries []journal.Entry) {
+	begin := entries[0]
+	undoneLocal := map[string]bool{}
+	for i := len(entries) - 1; i > 0; i-- {
+		e := entries[i]
+		var err error
+		switch e.Action {
+		case journal.ActionCreate:
+			err = undoCreate(e, begin)
+		case journal.ActionSelector:
+			err = undoSelector(e, begin)
+		case journal.ActionScale:
+			err = undoScale(e)
+		case journal.ActionEndpoints:
+			undoEndpoints(e, begin)
+		case journal.ActionWorkload:
+			err = undoWorkload(e)
+		case journal.ActionIstio:
+			err = ignoreNotFound(RemoveIstioRule(e.Kind, e.Name, e.Namespace, e.Patch))
+		case journal.ActionHosts, journal.ActionNameServer, journal.ActionRoute:
+			if !undoneLocal[e.Action] {
+				undoneLocal[e.Action] = true
+				err = undoLocal(e.Action)
+			}
+		default:
+			log.Debug().Msgf("Unknown journal action %s", e.Action)
+		}
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to roll back %s of %s %s", e.Action, e.Kind, e.Name)
+		}
+	}
+	forgetOriginEndpoints()
+	if begin.Lease != "" {
+		if err := cluster.Ins().RemoveLease(begin.Lease, begin.Namespace); err == nil {
+			log.Info().Msgf("Session lease %s removed", begin.Lease)
+		}
+	}
+}
+
+func undoCreate(e, begin journal.Entry) error {
+	var annotations map[string]string
+	var err error
+	switch e.Kind {
+	case "pod":
+		if pod, err2 := cluster.Ins().GetPod(e.Name, e.Namespace); err2 == nil {
+			annotations = pod.Annotations
+		} else {
+			err = err2
+		}
+	case "deployment":
+		if app, err2 := cluster.Ins().GetDeployment(e.Name, e.Namespace); err2 == nil {
+			annotations = app.Annotations
+		} else {
+			err = err2
+		}
+	case "service":
+		if svc, err2 := cluster.Ins().GetService(e.Name, e.Namespace); err2 == nil {
+			annotations = svc.Annotations
+		} else {
+			err = err2
+		}
+	case "configmap":
+		if cm, err2 := cluster.Ins().GetConfigMap(e.Name, e.Namespace); err2 == nil {
+			annotations = cm.Annotations
+		} else {
+			err = err2
+		}
+	case "endpointslice":
+		return ignoreNotFound(cluster.Ins().RemoveEndpointSlice(e.Name, e.Namespace))
+	case "lease":
+		lease, err2 := cluster.Ins().GetLease(e.Name, e.Namespace)
+		if err2 != nil {
+			return ignoreNotFound(err2)
+		}
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != begin.Holder {
+			return nil
+		}
+		log.Info().Msgf("Removing lease %s", e.Name)
+		return cluster.Ins().RemoveLease(e.Name, e.Namespace)
+	default:
+		return fmt.Errorf("unknown resource kind %s", e.Kind)
+	}
+	if err != nil {
+		return ignoreNotFound(err)
+	}
+	// resource shared with other sessions would be cleaned by the last of them
+	if isSharedWithOthers(annotations, begin.Lease) {
+		log.Debug().Msgf("%s %s is shared with other sessions, skipped", e.Kind, e.Name)
+		return nil
+	}
+	log.Info().Msgf("Removing %s %s", e.Kind, e.Name)
+	switch e.Kind {
+	case "pod":
+		return cluster.Ins().RemovePod(e.Name, e.Namespace)
+	case "deployment":
+		return cluster.Ins().RemoveDeployment(e.Name, e.Namespace)
+	case "service":
+		return cluster.Ins().RemoveService(e.Name, e.Namespace)
+	default:
+		return cluster.Ins().RemoveConfigMap(e.Name, e.Namespace)
+	}
+}
+
+// isSharedWithOthers resource without lease annotation was created when session lease is unavailable,
+// it's tracked by heart beat and shared only if referenced more than once
+func isSharedWithOthers(annotations map[string]string, lease string) bool {
+	if leases, exists := annotations[util.KtLease]; exists {
+		return leases != lease
+	}
+	count, err := strconv.Atoi(annotations[util.KtRefCount])
+	return err == nil && count > 1
+}
+
+func undoSelector(e, begin journal.Entry) error {
+	svc, err := cluster.Ins().GetService(e.Name, e.Namespace)
+	if err != nil {
+		return ignoreNotFound(err)
+	}
+	originSelector, exists := svc.Annotations[util.KtSelector]
+	if !exists {
+		log.Debug().Msgf("Service %s already recovered", e.Name)
+		return nil
+	}
+	if pods, err2 := cluster.Ins().GetPodsByLabel(svc.Spec.Selector, e.Namespace); err2 == nil {
+		leases, _ := cluster.GetKtLeases(e.Namespace)
+		delete(leases, begin.Lease)
+		for _, p := range pods.Items {
+			if _, alive := cluster.CheckSessionLeases(p.Annotations, leases); alive {
+				log.Info().Msgf("Service %s is still used by other session, skipped", e.Name)
+				return nil
+			}
+		}
+	}
+	var selector map[string]string
+	if err = json.Unmarshal([]byte(originSelector), &selector); err != nil {
+		log.Debug().Msgf("Invalid %s annotation of service %s, using journal record", util.KtSelector, e.Name)
+		selector = e.Selector
+	}
+	svc.Spec.Selector = selector
+	delete(svc.Annotations, util.KtSelector)
+	if _, err = cluster.Ins().UpdateService(svc); err != nil {
+		return err
+	}
+	log.Info().Msgf("Selector of service %s recovered", e.Name)
+	return nil
+}
+
+func undoEndpoints(e, begin journal.Entry) {
+	if e.Kind != "service" {
+		// original endpoints recorded before taking over, entries are replayed in reverse order,
+		// so they are remembered before endpoints of the service being recovered
+		rememberOriginEndpoints(e.Kind, e.Name, e.Namespace, e.Origin)
+		return
+	}
+	slices, err := cluster.Ins().GetEndpointSlicesOfService(e.Name, e.Namespace)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to fetch endpoint slices of service %s", e.Name)
+		return
+	}
+	leases, _ := cluster.GetKtLeases(e.Namespace)
+	delete(leases, begin.Lease)
+	for _, s := range slices {
+		if _, alive := cluster.CheckSessionLeases(s.Annotations, leases); alive {
+			log.Info().Msgf("Service %s is still used by other session, skipped", e.Name)
+			return
+		}
+	}
+	RecoverServiceEndpoints(e.Name, e.Namespace)
+	log.Info().Msgf("Endpoints of service %s recovered", e.Name)
+}
+
+func undoWorkload(e journal.Entry) error {
+	plugin, exists := workload.Get(e.Kind)
+	if !exists {
+		return fmt.Errorf("unknown workload type %s", e.Kind)
+	}
+	log.Info().Msgf("Resuming %s %s", e.Kind, e.Name)
+	return ignoreNotFound(cluster.Ins().PatchCustomResource(plugin.GVR(), e.Name, e.Namespace, []byte(e.Patch)))
+}
+
+func undoScale(e journal.Entry) error {
+	app, err := cluster.Ins().GetDeployment(e.Name, e.Namespace)
+	if err != nil {
+		return ignoreNotFound(err)
+	}
+	if app.Spec.Replicas != nil && *app.Spec.Replicas == e.Replicas {
+		return nil
+	}
+	return cluster.Ins().ScaleTo(e.Name, e.Namespace, &e.Replicas)
+}
+
+func undoLocal(action string) error {
+	if util.GetDaemonRunning(util.ComponentConnect) > 0 {
+		log.Info().Msgf("Another connect process is running, skip rolling back local %s", action)
+		return nil
+	}
+	switch action {
+	case journal.ActionHosts:
+		log.Info().Msgf("Dropping hosts records")
+		dns.DropHosts()
+	case journal.ActionNameServer:
+		log.Info().Msgf("Restoring dns configuration")
+		dns.RestoreNameServer()
+	case journal.ActionRoute:
+		log.Info().Msgf("Restoring route table")
+		return tun.Ins().RestoreRoute()
+	}
+	return nil
+}
+
+func needAdmin(entries []journal.Entry) bool {
+	for _, e := range entries {
+		if e.Action == journal.ActionHosts || e.Action == journal.ActionNameServer || e.Action == journal.ActionRoute {
+			return true
+		}
+	}
+	return false
+}
+
+func ignoreNotFound(err error) error {
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -3,56 +3,53 @@ package general
 import (
 	"fmt"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
 	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"time"
 )
 
-// LockTimeout 3 minutes
-const LockTimeout = 3 * 60
+// lockRetryTimes times of waiting for lock held by others
+const lockRetryTimes = 10
 
-func LockService(serviceName, namespace string, times int) (*coreV1.Service, error) {
-	if times > 10 {
-		return nil, fmt.Errorf("failed to obtain kt lock of service %s, please try again later", serviceName)
-	}
-	svc, err := cluster.Ins().GetService(serviceName, namespace)
-	if err != nil {
-		return nil, err
-	}
-
-	if svc.Annotations == nil {
-		svc.Annotations = make(map[string]string)
-	}
-	if lock, exists := svc.Annotations[util.KtLock]; exists && util.GetTime() - util.ParseTimestamp(lock) < LockTimeout {
-		log.Info().Msgf("Another user is occupying service %s, waiting for lock ...", serviceName)
-		time.Sleep(3 * time.Second)
-		return LockService(serviceName, namespace, times + 1)
-	} else {
-		svc.Annotations[util.KtLock] = util.GetTimestamp()
-		if svc, err = cluster.Ins().UpdateService(svc); err != nil {
+// LockService obtain the lock lease of service, wait if it's held by others
+func LockService(serviceName, namespace string) (*coreV1.Service, error) {
+	leaseName := cluster.LockLeaseName(serviceName)
+	journal.Record(journal.Entry{Action: journal.ActionCreate, Kind: "lease", Name: leaseName, Namespace: namespace})
+	for i := 0; i <= lockRetryTimes; i++ {
+		if i > 0 {
+			time.Sleep(3 * time.Second)
+		}
+		ok, err := cluster.Ins().AcquireLease(leaseName, namespace, util.RoleLock, util.LockLeaseDurationSec)
+		if err != nil {
 			log.Warn().Err(err).Msgf("Failed to lock service %s", serviceName)
-			return LockService(serviceName, namespace, times + 1)
+			continue
+		} else if !ok {
+			log.Info().Msgf("Another user is occupying service %s, waiting for lock ...", serviceName)
+			continue
+		}
+		svc, err := cluster.Ins().GetService(serviceName, namespace)
+		if err != nil {
+			UnlockService(serviceName, namespace)
+			return nil, err
 		}
+		log.Info().Msgf("Service %s locked", serviceName)
+		return svc, nil
 	}
-	log.Info().Msgf("Service %s locked", serviceName)
-	return svc, nil
+	return nil, fmt.Errorf("failed to obtain kt lock of service %s, please try again later", serviceName)
 }
 
+// UnlockService release the lock lease of service
 func UnlockService(serviceName, namespace string) {
-	svc, err := cluster.Ins().GetService(serviceName, namespace)
-	if err != nil {
-		log.Warn().Err(err).Msgf("Failed to get service %s for unlock", serviceName)
-		return
-	}
-	if _, exists := svc.Annotations[util.KtLock]; exists {
-		delete(svc.Annotations, util.KtLock)
-		if _, err = cluster.Ins().UpdateService(svc); err != nil {
-			log.Warn().Err(err).Msgf("Failed to unlock service %s", serviceName)
+	if err := cluster.Ins().ReleaseLease(cluster.LockLeaseName(serviceName), namespace); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			log.Info().Msgf("Service %s doesn't have lock", serviceName)
 		} else {
-			log.Info().Msgf("Service %s unlocked", serviceName)
+			log.Warn().Err(err).Msgf("Failed to unlock service %s", serviceName)
 		}
 	} else {
-		log.Info().Msgf("Service %s doesn't have lock", serviceName)
+		log.Info().Msgf("Service %s unlocked", serviceName)
 	}
 }
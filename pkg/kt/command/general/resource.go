@@ -24,9 +24,14 @@ func CreateShadowAndInbound(shadowPodName, portsToExpose string, labels, annotat
 		return err
 	}
 
-	if _, err = transmission.ForwardPodToLocal(portsToExpose, podName, privateKeyPath); err != nil {
+	if cluster.IsDryRun() {
+		return nil
+	}
+	_, gone, err := transmission.ForwardPodToLocal(portsToExpose, podName, privateKeyPath)
+	if err != nil {
 		return err
 	}
+	ExitOnPortForwardGone(gone)
 	return nil
 }
 
@@ -57,7 +62,13 @@ func GetServiceByResourceName(resourceName, namespace string) (*coreV1.Service,
 		}
 		return svc, err2
 	default:
-		return nil, fmt.Errorf("invalid resource type: %s", resourceType)
+		obj, plugin, isWorkload, err2 := GetWorkload(resourceName, namespace)
+		if !isWorkload {
+			return nil, fmt.Errorf("invalid resource type: %s", resourceType)
+		} else if err2 != nil {
+			return nil, err2
+		}
+		return plugin.GetService(obj)
 	}
 }
 
@@ -133,7 +144,13 @@ func UpdateServiceSelector(svcName, namespace string, selector map[string]string
 		}
 	}
 
+	if opt.Get().Global.UseSessionController {
+		// selector will be changed and kept by kt controller
+		return recordServiceChange(svcName, namespace, selector, marshaledSelector)
+	}
+
 	if isServiceChanged(svc, selector, marshaledSelector) {
+		recordSelectorChange(svc.Name, namespace, marshaledSelector)
 		svc.Spec.Selector = selector
 		if _, err = cluster.Ins().UpdateService(svc); err != nil {
 			return err
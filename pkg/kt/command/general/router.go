@@ -0,0 +1,60 @@
+package general
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/router"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+)
+
+// CreateRouterConfig create configmap holding route configuration of router pod,
+// configmap left by previous router pod is overwritten
+func CreateRouterConfig(name, namespace string, ktConf *router.KtConf) error {
+	data, err := json.Marshal(ktConf)
+	if err != nil {
+		return err
+	}
+	_, err = cluster.Ins().CreateConfigMap(name, namespace, map[string]string{}, map[string]string{},
+		map[string]string{router.ConfigKey: string(data)})
+	if k8sErrors.IsAlreadyExists(err) {
+		log.Debug().Msgf("Router config %s already exists, overwrite it", name)
+		return UpdateRouterConfig(name, namespace, func(c *router.KtConf) error {
+			*c = *ktConf
+			return nil
+		})
+	}
+	return err
+}
+
+// UpdateRouterConfig modify route configuration of router pod, retry if configmap is modified by others meanwhile
+func UpdateRouterConfig(name, namespace string, change func(*router.KtConf) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := cluster.Ins().GetConfigMap(name, namespace)
+		if k8sErrors.IsNotFound(err) {
+			return fmt.Errorf("route config of router pod %s not found, the router may be created by an earlier version of ktctl", name)
+		} else if err != nil {
+			return err
+		}
+		var ktConf router.KtConf
+		if err = json.Unmarshal([]byte(configMap.Data[router.ConfigKey]), &ktConf); err != nil {
+			return fmt.Errorf("invalid route config of router pod %s: %s", name, err)
+		}
+		if err = change(&ktConf); err != nil {
+			return err
+		}
+		data, err := json.Marshal(ktConf)
+		if err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[router.ConfigKey] = string(data)
+		configMap.Annotations = cluster.AttachSessionTracking(configMap.Annotations)
+		_, err = cluster.Ins().UpdateConfigMap(configMap)
+		return err
+	})
+}
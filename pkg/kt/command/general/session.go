@@ -0,0 +1,80 @@
+package general
+
+import (
+	"encoding/json"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// resolveSessionController changes are recorded in KtSession by default, fall back to changing services and
+// deployments directly when KtSession CRD is not installed or not accessible
+func resolveSessionController() {
+	if !opt.Get().Global.UseSessionController {
+		return
+	}
+	if _, err := cluster.Ins().GetKtSessions(opt.Get().Global.Namespace); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			log.Info().Msgf("KtSession is not installed in cluster, changing services and deployments directly")
+		} else {
+			log.Warn().Err(err).Msgf("Unable to access KtSession, changing services and deployments directly")
+		}
+		opt.Get().Global.UseSessionController = false
+	}
+}
+
+// RecordReplicasChange let kt controller scale deployment via KtSession
+func RecordReplicasChange(name, namespace string, replicas, originReplicas int32) error {
+	return cluster.Ins().RecordSessionChange(namespace, opt.Store.Component, sessionMode(), func(spec *cluster.KtSessionSpec) {
+		spec.Deployments = append(spec.Deployments, cluster.DeploymentChange{
+			Name:             name,
+			Replicas:         replicas,
+			OriginalReplicas: originReplicas,
+		})
+	})
+}
+
+// recordServiceChange let kt controller change selector of service via KtSession
+func recordServiceChange(svcName, namespace string, selector map[string]string, marshaledSelector string) error {
+	var originSelector map[string]string
+	if err := json.Unmarshal([]byte(marshaledSelector), &originSelector); err != nil {
+		return err
+	}
+	return cluster.Ins().RecordSessionChange(namespace, opt.Store.Component, sessionMode(), func(spec *cluster.KtSessionSpec) {
+		spec.Services = append(spec.Services, cluster.ServiceChange{
+			Name:             svcName,
+			Selector:         selector,
+			OriginalSelector: originSelector,
+		})
+	})
+}
+
+// removeKtSession remove KtSession of current process, and wait for kt controller recovering the cluster
+func removeKtSession() {
+	name := cluster.SessionLeaseName()
+	if err := cluster.Ins().RemoveKtSession(name, opt.Get().Global.Namespace); err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			log.Error().Err(err).Msgf("Failed to remove KtSession %s", name)
+		}
+		return
+	}
+	log.Info().Msgf("Waiting for KtSession %s recovered by kt controller ...", name)
+	if err := cluster.Ins().WaitKtSessionRemoved(name, opt.Get().Global.Namespace, util.SessionApplyTimeoutSec); err != nil {
+		log.Warn().Err(err).Msgf("KtSession not recovered in time")
+	} else {
+		log.Info().Msgf("KtSession %s recovered", name)
+	}
+}
+
+func sessionMode() string {
+	switch opt.Store.Component {
+	case util.ComponentExchange:
+		return opt.Get().Exchange.Mode
+	case util.ComponentMesh:
+		return opt.Get().Mesh.Mode
+	default:
+		return ""
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/transmission"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	k8sRuntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -27,6 +29,10 @@ func Prepare() error {
 	if err := combineKubeOpts(); err != nil {
 		return err
 	}
+	if opt.Get().Global.Transport != util.TransportSsh && opt.Get().Global.Transport != util.TransportMux {
+		return fmt.Errorf("invalid transport '%s', supportted are %s, %s", opt.Get().Global.Transport,
+			util.TransportSsh, util.TransportMux)
+	}
 
 	log.Info().Msgf("KtConnect %s start at %d (%s %s)",
 		opt.Store.Version, os.Getpid(), runtime.GOOS, runtime.GOARCH)
@@ -53,11 +59,38 @@ func SetupLogger() {
 	klog.LogToStderr(false)
 }
 
+// processSignal signal channel of current process, dead port forward is delivered to it as terminate signal
+var processSignal chan os.Signal
+
+// ExitOnPortForwardGone terminate current process once the port forward is given up,
+// instead of keep running with a broken tunnel
+func ExitOnPortForwardGone(gone chan int) {
+	go func() {
+		localPort := <-gone
+		if st, exists := transmission.GetPortForwardStatus(localPort); exists {
+			log.Error().Msgf("Port forward local:%d -> pod %s:%d is dead (%s), exiting",
+				localPort, st.PodName, st.RemotePort, st.LastError)
+		} else {
+			log.Error().Msgf("Port forward local:%d is dead, exiting", localPort)
+		}
+		if processSignal != nil {
+			processSignal <- syscall.SIGTERM
+		}
+	}()
+}
+
 // SetupProcess write pid file and set component type
 func SetupProcess(componentName string) (chan os.Signal, error) {
 	ch := make(chan os.Signal)
 	signal.Notify(ch, os.Interrupt, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGQUIT)
 	opt.Store.Component = componentName
+	processSignal = ch
+	if componentName == util.ComponentExchange || componentName == util.ComponentMesh {
+		resolveSessionController()
+	}
+	// roll back sessions of crashed processes before current one changes anything
+	ReplayJournals()
+	OpenJournal(componentName)
 	return ch, util.WritePidFile(componentName, ch)
 }
 
@@ -115,7 +148,12 @@ func combineKubeOpts() (err error) {
 	if err != nil {
 		return err
 	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
 	opt.Store.Clientset = clientSet
+	opt.Store.DynamicClient = dynamicClient
 	opt.Store.RestConfig = restConfig
 
 	if opt.Get().Global.IpVersion == 6 || strings.Contains(restConfig.Host, "[") {
@@ -6,8 +6,11 @@ import (
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/service/dns"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
+	"github.com/alibaba/kt-connect/pkg/kt/service/recorder"
 	"github.com/alibaba/kt-connect/pkg/kt/service/tun"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/alibaba/kt-connect/pkg/router"
 	"github.com/rs/zerolog/log"
 	"os"
 	"os/signal"
@@ -19,18 +22,38 @@ import (
 // CleanupWorkspace clean workspace
 func CleanupWorkspace() {
 	log.Debug().Msgf("Cleaning workspace")
+	stopLocalProcess()
+	recorder.Stop()
 	cleanLocalFiles()
 	if opt.Store.Component == util.ComponentConnect {
 		recoverGlobalHostsAndProxy()
 	}
 
+	if opt.Get().Global.UseSessionController && cluster.IsSessionLeaseCreated() {
+		removeKtSession()
+	}
 	if opt.Store.Component == util.ComponentExchange {
 		recoverExchangedTarget()
+		ResumeWorkload()
 	} else if opt.Store.Component == util.ComponentMesh {
 		recoverAutoMeshRoute()
+		recoverIstioRules()
 	}
 	cleanService()
 	cleanShadowPodAndConfigMap()
+	releaseSessionLease()
+	journal.Close()
+}
+
+func releaseSessionLease() {
+	if !cluster.IsSessionLeaseCreated() {
+		return
+	}
+	if err := cluster.Ins().ReleaseLease(cluster.SessionLeaseName(), opt.Get().Global.Namespace); err != nil {
+		log.Debug().Err(err).Msgf("Failed to release session lease")
+	} else {
+		log.Info().Msgf("Session lease %s released", cluster.SessionLeaseName())
+	}
 }
 
 func recoverGlobalHostsAndProxy() {
@@ -78,12 +101,15 @@ func recoverExchangedTarget() {
 		// process exit before target exchanged
 		return
 	}
+	RemoveFallbackEndpoints()
 	if opt.Get().Exchange.Mode == util.ExchangeModeScale {
-		log.Info().Msgf("Recovering origin deployment %s", opt.Store.Origin)
-		err := cluster.Ins().ScaleTo(opt.Store.Origin, opt.Get().Global.Namespace, &opt.Store.Replicas)
-		if err != nil {
-			log.Error().Err(err).Msgf("Scale deployment %s to %d failed",
-				opt.Store.Origin, opt.Store.Replicas)
+		if !opt.Get().Global.UseSessionController {
+			log.Info().Msgf("Recovering origin deployment %s", opt.Store.Origin)
+			err := cluster.Ins().ScaleTo(opt.Store.Origin, opt.Get().Global.Namespace, &opt.Store.Replicas)
+			if err != nil {
+				log.Error().Err(err).Msgf("Scale deployment %s to %d failed",
+					opt.Store.Origin, opt.Store.Replicas)
+			}
 		}
 		// wait for scale complete
 		ch := make(chan os.Signal, 1)
@@ -93,7 +119,10 @@ func recoverExchangedTarget() {
 			ch <- os.Interrupt
 		}()
 		_ = <-ch
-	} else if opt.Get().Exchange.Mode == util.ExchangeModeSelector {
+	} else if opt.Get().Exchange.Mode == util.ExchangeModeEndpoints {
+		RecoverServiceEndpoints(opt.Store.Origin, opt.Get().Global.Namespace)
+		log.Info().Msgf("Endpoints of service %s recovered", opt.Store.Origin)
+	} else if opt.Get().Exchange.Mode == util.ExchangeModeSelector && !opt.Get().Global.UseSessionController {
 		RecoverOriginalService(opt.Store.Origin, opt.Get().Global.Namespace)
 		log.Info().Msgf("Original service %s recovered", opt.Store.Origin)
 	}
@@ -110,6 +139,16 @@ func recoverAutoMeshRoute() {
 			}
 			return
 		}
+		if routerPod.Annotations[util.KtRefCount] != "1" {
+			// Remove route before decreasing reference, which let kubelet refresh the mounted route config immediately
+			if err = UpdateRouterConfig(opt.Store.Router, opt.Get().Global.Namespace, func(ktConf *router.KtConf) error {
+				_, version := router.SplitVersionMark(opt.Store.Mesh)
+				ktConf.Remove(version)
+				return nil
+			}); err != nil {
+				log.Warn().Err(err).Msgf("Failed to remove version %s from router pod", opt.Store.Mesh)
+			}
+		}
 		if shouldDelRouter, err2 := cluster.Ins().DecreasePodRef(opt.Store.Router, opt.Get().Global.Namespace); err2 != nil {
 			log.Error().Err(err2).Msgf("Decrease router pod %s reference failed", opt.Store.Shadow)
 		} else if shouldDelRouter {
@@ -119,21 +158,18 @@ func recoverAutoMeshRoute() {
 			if err = cluster.Ins().RemovePod(opt.Store.Router, opt.Get().Global.Namespace); err != nil {
 				log.Warn().Err(err).Msgf("Failed to remove router pod")
 			}
-		} else {
-			stdout, stderr, err3 := cluster.Ins().ExecInPod(util.DefaultContainer, opt.Store.Router, opt.Get().Global.Namespace,
-				util.RouterBin, "remove", opt.Store.Mesh)
-			log.Debug().Msgf("Stdout: %s", stdout)
-			log.Debug().Msgf("Stderr: %s", stderr)
-			if err3 != nil {
-				log.Warn().Err(err3).Msgf("Failed to remove version %s from router pod", opt.Store.Mesh)
+			if err = cluster.Ins().RemoveConfigMap(opt.Store.Router, opt.Get().Global.Namespace); err != nil {
+				log.Warn().Err(err).Msgf("Failed to remove router config")
 			}
 		}
 	}
 }
 
 func recoverService(originSvcName string) {
-	RecoverOriginalService(originSvcName, opt.Get().Global.Namespace)
-	log.Info().Msgf("Original service %s recovered", originSvcName)
+	if !opt.Get().Global.UseSessionController {
+		RecoverOriginalService(originSvcName, opt.Get().Global.Namespace)
+		log.Info().Msgf("Original service %s recovered", originSvcName)
+	}
 
 	stuntmanSvcName := originSvcName + util.StuntmanServiceSuffix
 	if err := cluster.Ins().RemoveService(stuntmanSvcName, opt.Get().Global.Namespace); err != nil {
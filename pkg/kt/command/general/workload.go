@@ -0,0 +1,81 @@
+package general
+
+import (
+	"fmt"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
+	"github.com/alibaba/kt-connect/pkg/kt/service/workload"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GetWorkload get custom workload and its plugin, return false if resource is not a custom workload
+func GetWorkload(resourceName, namespace string) (*unstructured.Unstructured, workload.Plugin, bool, error) {
+	resourceType, name, err := ParseResourceName(resourceName)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	plugin, exists := workload.Get(resourceType)
+	if !exists {
+		return nil, nil, false, nil
+	}
+	obj, err := cluster.Ins().GetCustomResource(plugin.GVR(), name, namespace)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil, true, fmt.Errorf("%s '%s' is not found in namespace %s", resourceType, name, namespace)
+		}
+		return nil, nil, true, err
+	}
+	return obj, plugin, true, nil
+}
+
+// CheckWorkloadMode check whether custom workload supports specified exchange mode, do nothing for other resource types
+func CheckWorkloadMode(resourceName, namespace, mode string) error {
+	obj, plugin, isWorkload, err := GetWorkload(resourceName, namespace)
+	if !isWorkload || err != nil {
+		return err
+	}
+	_, _, err = plugin.Suspend(obj, mode)
+	return err
+}
+
+// SuspendWorkload keep controller of custom workload from undoing exchange, do nothing for other resource types
+func SuspendWorkload(resourceName, namespace, mode string) error {
+	obj, plugin, isWorkload, err := GetWorkload(resourceName, namespace)
+	if !isWorkload || err != nil {
+		return err
+	}
+	suspend, restore, err := plugin.Suspend(obj, mode)
+	if err != nil {
+		return err
+	}
+	resourceType, _, _ := ParseResourceName(resourceName)
+	journal.Record(journal.Entry{Action: journal.ActionWorkload, Kind: resourceType, Name: obj.GetName(),
+		Namespace: namespace, Patch: string(restore)})
+	if err = cluster.Ins().PatchCustomResource(plugin.GVR(), obj.GetName(), namespace, suspend); err != nil {
+		return err
+	}
+	opt.Store.Workload = resourceName
+	opt.Store.WorkloadRestore = restore
+	log.Info().Msgf("%s suspended", resourceName)
+	return nil
+}
+
+// ResumeWorkload restore custom workload suspended by current process
+func ResumeWorkload() {
+	if opt.Store.Workload == "" {
+		return
+	}
+	_, plugin, _, err := GetWorkload(opt.Store.Workload, opt.Get().Global.Namespace)
+	if err == nil {
+		_, name, _ := ParseResourceName(opt.Store.Workload)
+		err = cluster.Ins().PatchCustomResource(plugin.GVR(), name, opt.Get().Global.Namespace, opt.Store.WorkloadRestore)
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to resume %s", opt.Store.Workload)
+	} else {
+		log.Info().Msgf("%s resumed", opt.Store.Workload)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	"github.com/alibaba/kt-connect/pkg/kt/command/mesh"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/inspector"
+	"github.com/alibaba/kt-connect/pkg/kt/service/recorder"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -37,6 +39,11 @@ func NewMeshCommand() *cobra.Command {
 
 //Mesh exchange kubernetes workload
 func Mesh(resourceName string) error {
+	if opt.Get().Mesh.DryRun {
+		return general.DryRun(util.ComponentMesh, func() error {
+			return meshResource(resourceName)
+		})
+	}
 	ch, err := general.SetupProcess(util.ComponentMesh)
 	if err != nil {
 		return err
@@ -48,7 +55,29 @@ func Mesh(resourceName string) error {
 		}
 	}
 
-  // Get service to mesh
+	if opt.Get().Mesh.Record != "" {
+		if err = recorder.Start(opt.Get().Mesh.Record); err != nil {
+			return err
+		}
+	}
+	if opt.Get().Mesh.Inspect != "" {
+		if err = inspector.Start(opt.Get().Mesh.Inspect); err != nil {
+			return err
+		}
+	}
+
+	if err = meshResource(resourceName); err != nil {
+		return err
+	}
+
+	// watch background process, clean the workspace and exit if background process occur exception
+	s := <-ch
+	log.Info().Msgf("Terminal Signal is %s", s)
+	return nil
+}
+
+func meshResource(resourceName string) error {
+	// Get service to mesh
 	svc, err := general.GetServiceByResourceName(resourceName, opt.Get().Global.Namespace)
 	if err != nil {
 		return err
@@ -57,23 +86,18 @@ func Mesh(resourceName string) error {
 	if port := util.FindInvalidRemotePort(opt.Get().Mesh.Expose, general.GetTargetPorts(svc)); port != "" {
 		return fmt.Errorf("target port %s not exists in service %s", port, svc.Name)
 	}
+	if err = mesh.CheckRouterOptions(); err != nil {
+		return err
+	}
 
 	log.Info().Msgf("Using %s mode", opt.Get().Mesh.Mode)
 	if opt.Get().Mesh.Mode == util.MeshModeManual {
-		err = mesh.ManualMesh(svc)
-	} else if opt.Get().Mesh.Mode == util.MeshModeAuto {
-		err = mesh.AutoMesh(svc)
-	} else {
-		err = fmt.Errorf("invalid mesh method '%s', supportted are %s, %s", opt.Get().Mesh.Mode,
-			util.MeshModeAuto, util.MeshModeManual)
-	}
-	if err != nil {
-		return err
+		return mesh.ManualMesh(svc)
+	} else if opt.Get().Mesh.Mode == util.MeshModeAuto || opt.Get().Mesh.Mode == util.MeshModeMirror {
+		return mesh.AutoMesh(svc)
+	} else if opt.Get().Mesh.Mode == util.MeshModeIstio {
+		return mesh.IstioMesh(svc)
 	}
-
-	// watch background process, clean the workspace and exit if background process occur exception
-	s := <-ch
-	log.Info().Msgf("Terminal Signal is %s", s)
-	return nil
+	return fmt.Errorf("invalid mesh method '%s', supportted are %s, %s, %s, %s", opt.Get().Mesh.Mode,
+		util.MeshModeAuto, util.MeshModeManual, util.MeshModeIstio, util.MeshModeMirror)
 }
-
@@ -6,18 +6,18 @@ import (
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/alibaba/kt-connect/pkg/router"
 	"github.com/rs/zerolog/log"
 	coreV1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"strconv"
 	"strings"
-	"time"
 )
 
 func AutoMesh(svc *coreV1.Service) error {
 	// Lock service to avoid conflict, must be first step
-	svc, err := general.LockService(svc.Name, opt.Get().Global.Namespace, 0)
+	svc, err := general.LockService(svc.Name, opt.Get().Global.Namespace)
 	if err != nil {
 		return err
 	}
@@ -30,8 +30,6 @@ func AutoMesh(svc *coreV1.Service) error {
 
 	// Parse or generate mesh kv
 	meshKey, meshVersion := getVersion(opt.Get().Mesh.VersionMark)
-	versionMark := meshKey + ":" + meshVersion
-	opt.Store.Mesh = versionMark
 
 	portToNames := general.GetTargetPorts(svc)
 	ports := make(map[int]int)
@@ -55,9 +53,11 @@ func AutoMesh(svc *coreV1.Service) error {
 	}
 
 	// Check name usable
-	if err = isNameUsable(svc.Name, meshVersion, 0); err != nil {
+	if meshVersion, err = resolveVersion(svc.Name, meshVersion); err != nil {
 		return err
 	}
+	versionMark := meshKey + ":" + meshVersion
+	opt.Store.Mesh = versionMark
 
 	// Create stuntman service
 	if err = createStuntmanService(svc, ports); err != nil {
@@ -100,28 +100,16 @@ func AutoMesh(svc *coreV1.Service) error {
 		return err
 	}
 	log.Info().Msg("---------------------------------------------------------------")
-	log.Info().Msgf(" Now you can access your service by header '%s: %s' ", strings.ToUpper(meshKey), meshVersion)
-	log.Info().Msg("---------------------------------------------------------------")
-	return nil
-}
-
-func isNameUsable(name, meshVersion string, times int) error {
-	if times > 10 {
-		return fmt.Errorf("meshing pod for service %s still terminating, please try again later", name)
-	}
-	shadowName := name + util.MeshPodInfix + meshVersion
-	if pod, err := cluster.Ins().GetPod(shadowName, opt.Get().Global.Namespace); err == nil {
-		if pod.DeletionTimestamp == nil {
-			msg := fmt.Sprintf("Another user is meshing service '%s' via version '%s'", name, meshVersion)
-			if opt.Get().Mesh.VersionMark != "" {
-				return fmt.Errorf("%s, please specify a different version mark", msg)
-			}
-			return fmt.Errorf( "%s, please retry or use '--versionMark' parameter to spcify an uniq one", msg)
-		}
-		log.Info().Msgf("Previous meshing pod for service '%s' not finished yet, waiting ...", name)
-		time.Sleep(3 * time.Second)
-		return isNameUsable(name, meshVersion, times + 1)
+	if opt.Get().Mesh.Mode == util.MeshModeMirror {
+		log.Info().Msgf(" Now all requests to service '%s' are mirrored to local ", svc.Name)
+	} else {
+		log.Info().Msgf(" Now you can access your service by header '%s: %s' ", strings.ToUpper(meshKey), meshVersion)
+		log.Info().Msgf(" or visit below url in browser to route following requests via cookie: ")
+		log.Info().Msgf(" %s ", router.SessionUrl(svc.Name+"."+opt.Get().Global.Namespace,
+			int(svc.Spec.Ports[0].Port), meshVersion))
+		printAccessExamples(svc, meshKey, meshVersion)
 	}
+	log.Info().Msg("---------------------------------------------------------------")
 	return nil
 }
 
@@ -169,6 +157,15 @@ func createRouter(routerPodName string, svcName string, ports map[int]int, label
 			return err
 		}
 		// Router not exist or just terminated
+		// Route config must be ready before router pod created, which is mounted and loaded at pod startup
+		ktConf, err2 := newRouteConfig(svcName, ports, versionMark)
+		if err2 != nil {
+			return err2
+		}
+		if err = general.CreateRouterConfig(routerPodName, namespace, ktConf); err != nil {
+			log.Error().Err(err).Msgf("Failed to create router config")
+			return err
+		}
 		labels[util.KtTarget] = util.RandomString(20)
 		annotations := map[string]string{util.KtRefCount: "1", util.KtConfig: fmt.Sprintf("service=%s", svcName)}
 		if _, err = cluster.Ins().CreateRouterPod(routerPodName, labels, annotations, ports); err != nil {
@@ -176,33 +173,27 @@ func createRouter(routerPodName string, svcName string, ports map[int]int, label
 			return err
 		}
 		log.Info().Msgf("Router pod is ready")
-
-		stdout, stderr, err2 := cluster.Ins().ExecInPod(util.DefaultContainer, routerPodName, namespace,
-			util.RouterBin, "setup", svcName, toPortMapParameter(ports), versionMark)
-		log.Debug().Msgf("Stdout: %s", stdout)
-		log.Debug().Msgf("Stderr: %s", stderr)
-		if err2 != nil {
-			return err2
-		}
 	} else {
 		// Router pod exist
 		labels[util.KtTarget] = routerPod.Labels[util.KtTarget]
-		cluster.Ins().UpdatePodHeartBeat(routerPodName, namespace)
 		if _, err = strconv.Atoi(routerPod.Annotations[util.KtRefCount]); err != nil {
 			log.Error().Msgf("Router pod exists, but do not have ref count")
 			return err
-		} else if err = cluster.Ins().IncreasePodRef(routerPodName, namespace); err != nil {
-			log.Error().Msgf("Failed to increase router pod ref count")
-			return err
 		}
 		log.Info().Msgf("Router pod already exists")
-
-		stdout, stderr, err2 := cluster.Ins().ExecInPod(util.DefaultContainer, routerPodName, namespace,
-			util.RouterBin, "add", versionMark)
-		log.Debug().Msgf("Stdout: %s", stdout)
-		log.Debug().Msgf("Stderr: %s", stderr)
-		if err2 != nil {
-			return err2
+		if opt.Get().Mesh.RouterTimeout != "" || opt.Get().Mesh.RouterMaxBody != "" {
+			log.Warn().Msgf("Router timeout and max body size only take effect when router pod is created, ignored")
+		}
+		if err = general.UpdateRouterConfig(routerPodName, namespace, func(ktConf *router.KtConf) error {
+			return addRouteVersion(ktConf, versionMark)
+		}); err != nil {
+			log.Error().Err(err).Msgf("Failed to update router config")
+			return err
+		}
+		// Updating pod annotation also let kubelet refresh the mounted route config immediately
+		if err = cluster.Ins().IncreasePodRef(routerPodName, namespace); err != nil {
+			log.Error().Msgf("Failed to increase router pod ref count")
+			return err
 		}
 	}
 	log.Info().Msgf("Router pod configuration done")
@@ -239,12 +230,94 @@ func createStuntmanService(svc *coreV1.Service, ports map[int]int) error {
 	} else if stuntmanSvc.Labels[util.ControlBy] != util.KubernetesToolkit {
 		return fmt.Errorf("service %s exists, but not created by kt", stuntmanSvcName)
 	} else {
-		cluster.Ins().UpdateServiceHeartBeat(stuntmanSvcName, namespace)
+		if err = cluster.Ins().AttachServiceToSession(stuntmanSvcName, namespace); err != nil {
+			log.Warn().Err(err).Msgf("Failed to attach service %s to current session", stuntmanSvcName)
+		}
 		log.Info().Msgf("Stuntman service already exists")
 	}
 	return nil
 }
 
+// newRouteConfig route configuration of a new router pod, timeout and body size options only take effect here
+func newRouteConfig(svcName string, ports map[int]int, versionMark string) (*router.KtConf, error) {
+	header, _ := router.SplitVersionMark(versionMark)
+	ktConf := &router.KtConf{
+		Service:  svcName,
+		Ports:    router.ParsePorts(toPortMapParameter(ports)),
+		Header:   header,
+		Versions: []string{},
+		Mirrors:  []string{},
+		Faults:   []router.Fault{},
+		Watches:  []router.Watch{},
+	}
+	if err := applyProxyOptions(ktConf); err != nil {
+		return nil, err
+	}
+	ktConf.SetProxyDefaults()
+	if err := addRouteVersion(ktConf, versionMark); err != nil {
+		return nil, err
+	}
+	return ktConf, nil
+}
+
+// addRouteVersion requests are copied to shadow pod in mirror mode, faults are injected if specified,
+// and route is removed by router watchdog once local service unreachable for long
+func addRouteVersion(ktConf *router.KtConf, versionMark string) error {
+	header, version := router.SplitVersionMark(versionMark)
+	mirror := opt.Get().Mesh.Mode == util.MeshModeMirror
+	var fault *router.Fault
+	if opt.Get().Mesh.Inject != "" {
+		var err error
+		if fault, err = router.ParseFault(version, opt.Get().Mesh.Inject); err != nil {
+			return err
+		}
+	}
+	var watch *router.Watch
+	failover := opt.Get().Mesh.Failover && !mirror
+	if failover || opt.Get().Mesh.RouteExpire > 0 {
+		watch = &router.Watch{Version: version, Failover: failover, Expire: opt.Get().Mesh.RouteExpire}
+	}
+	return ktConf.AddVersion(header, version, mirror, fault, watch)
+}
+
+func applyProxyOptions(ktConf *router.KtConf) error {
+	var options []string
+	if opt.Get().Mesh.RouterTimeout != "" {
+		options = append(options, "read-timeout="+opt.Get().Mesh.RouterTimeout, "send-timeout="+opt.Get().Mesh.RouterTimeout)
+	}
+	if opt.Get().Mesh.RouterMaxBody != "" {
+		options = append(options, "max-body-size="+opt.Get().Mesh.RouterMaxBody)
+	}
+	for _, option := range options {
+		if _, err := ktConf.ApplyProxyOption(option); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckRouterOptions verify options passed to router
+func CheckRouterOptions() error {
+	if err := applyProxyOptions(&router.KtConf{}); err != nil {
+		return err
+	}
+	if opt.Get().Mesh.Inject == "" {
+		return nil
+	}
+	if opt.Get().Mesh.Mode != util.MeshModeAuto && opt.Get().Mesh.Mode != util.MeshModeMirror {
+		return fmt.Errorf("fault injection is only available in %s and %s mode", util.MeshModeAuto, util.MeshModeMirror)
+	}
+	fault, err := router.ParseFault("", opt.Get().Mesh.Inject)
+	if err != nil {
+		return err
+	}
+	if opt.Get().Mesh.Mode == util.MeshModeMirror && !fault.Stuntman {
+		return fmt.Errorf("response of mirrored requests is discarded, please use 'target=%s' to inject faults to original pods",
+			router.FaultTargetStuntman)
+	}
+	return nil
+}
+
 func toPortMapParameter(ports map[int]int) string {
 	// input: { 80:8080, 70:7000 }
 	// output: "80:8080,70:7000"
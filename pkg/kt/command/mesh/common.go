@@ -1,15 +1,33 @@
 package mesh
 
 import (
+	"fmt"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
+	coordV1 "k8s.io/api/coordination/v1"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// maxVersionCandidates times of trying suffixed default version when it's occupied by others
+const maxVersionCandidates = 10
+
+// maxUserVersionLength keep mesh pod and service name derived from default version short
+const maxUserVersionLength = 20
+
+var invalidVersionChars = regexp.MustCompile("[^a-z0-9]+")
+
+// getVersion parse version mark, version is empty if not specified
 func getVersion(versionMark string) (string, string) {
 	versionKey := "version"
-	versionVal := strings.ToLower(util.RandomString(5))
+	versionVal := ""
 	if len(versionMark) != 0 {
 		versionParts := strings.Split(versionMark, ":")
 		if len(versionParts) > 1 {
@@ -18,9 +36,7 @@ func getVersion(versionMark string) (string, string) {
 			} else {
 				log.Warn().Msgf("mark key '%s' is invalid, using default key '%s'", versionParts[0], versionKey)
 			}
-			if len(versionParts[1]) > 0 {
-				versionVal = versionParts[1]
-			}
+			versionVal = versionParts[1]
 		} else {
 			versionVal = versionParts[0]
 		}
@@ -32,3 +48,149 @@ func isValidKey(key string) bool {
 	ok, err := regexp.MatchString("^[a-z][a-z0-9_-]*$", key)
 	return err == nil && ok
 }
+
+// resolveVersion use specified version if it's not occupied, otherwise use the default version of current user,
+// or the first available one suffixed with sequence number if default version is occupied by others
+func resolveVersion(svcName, version string) (string, error) {
+	if version != "" {
+		return version, isNameUsable(svcName, version)
+	}
+	base := userVersion()
+	for i := 1; i <= maxVersionCandidates; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+		occupied, err := isVersionOccupied(svcName, candidate)
+		if err != nil {
+			return "", err
+		} else if !occupied {
+			if i > 1 {
+				log.Warn().Msgf("Version '%s' of service '%s' is in use, using '%s' instead", base, svcName, candidate)
+			}
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("too many users meshing service '%s' with version '%s', please use '--versionMark' parameter to specify an uniq one",
+		svcName, base)
+}
+
+func isNameUsable(name, meshVersion string) error {
+	occupied, err := isVersionOccupied(name, meshVersion)
+	if err != nil {
+		return err
+	} else if occupied {
+		return fmt.Errorf("another session is meshing service '%s' via version '%s', please specify a different version mark",
+			name, meshVersion)
+	}
+	return nil
+}
+
+// isVersionOccupied check whether mesh pod of the version exists, wait if it's still terminating,
+// mesh pod left by previous session of current user is removed instead of being treated as occupied
+func isVersionOccupied(name, meshVersion string) (bool, error) {
+	shadowName := name + util.MeshPodInfix + meshVersion
+	namespace := opt.Get().Global.Namespace
+	for times := 0; times <= 10; times++ {
+		pod, err := cluster.Ins().GetPod(shadowName, namespace)
+		if k8sErrors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if pod.DeletionTimestamp == nil {
+			leases, err2 := cluster.GetKtLeases(namespace)
+			if err2 != nil {
+				return false, err2
+			}
+			if !isStaleMeshPodOfCurrentUser(pod, leases) {
+				return true, nil
+			}
+			log.Info().Msgf("Removing meshing pod '%s' left by previous session", shadowName)
+			if err2 = removeStaleMeshResources(shadowName, namespace); err2 != nil {
+				return false, err2
+			}
+			continue
+		}
+		log.Info().Msgf("Previous meshing pod for service '%s' not finished yet, waiting ...", name)
+		time.Sleep(3 * time.Second)
+	}
+	return false, fmt.Errorf("meshing pod for service %s still terminating, please try again later", name)
+}
+
+// isStaleMeshPodOfCurrentUser mesh pod created by current user, whose session lease or heart beat is no longer alive
+func isStaleMeshPodOfCurrentUser(pod *coreV1.Pod, leases map[string]coordV1.Lease) bool {
+	if user := util.GetLocalUserName(); user == "" || pod.Annotations[util.KtUser] != user {
+		return false
+	}
+	if tracked, alive := cluster.CheckSessionLeases(pod.Annotations, leases); tracked {
+		return !alive
+	}
+	lastHeartBeat, err := strconv.ParseInt(pod.Annotations[util.KtLastHeartBeat], 10, 64)
+	return err != nil || util.GetTime()-lastHeartBeat > util.SessionLeaseDurationSec
+}
+
+// removeStaleMeshResources remove mesh pod together with its ssh key config map and shadow service of the same name
+func removeStaleMeshResources(shadowName, namespace string) error {
+	if err := cluster.Ins().RemovePod(shadowName, namespace); err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	if err := cluster.Ins().RemoveConfigMap(shadowName, namespace); err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	if err := cluster.Ins().RemoveService(shadowName, namespace); err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// userVersion default version of current user, which keeps the same across restarts
+func userVersion() string {
+	if version := toVersion(util.GetLocalUserName()); version != "" {
+		return version
+	}
+	// user name not available, use a random version and save it for later runs
+	if data, err := os.ReadFile(util.KtMeshVersionFile); err == nil && toVersion(string(data)) == string(data) && len(data) > 0 {
+		return string(data)
+	}
+	version := strings.ToLower(util.RandomString(5))
+	if err := os.WriteFile(util.KtMeshVersionFile, []byte(version), 0644); err != nil {
+		log.Debug().Err(err).Msgf("Failed to save default mesh version")
+	}
+	return version
+}
+
+// toVersion convert user name to version usable in pod and service name
+func toVersion(name string) string {
+	version := strings.Trim(invalidVersionChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(version) > maxUserVersionLength {
+		version = strings.TrimRight(version[:maxUserVersionLength], "-")
+	}
+	return version
+}
+
+// accessExamples commands to access local service via version header for each port of the service
+func accessExamples(svc *coreV1.Service, namespace, meshKey, meshVersion string) []string {
+	var examples []string
+	for _, port := range svc.Spec.Ports {
+		if port.Protocol == coreV1.ProtocolUDP || port.Protocol == coreV1.ProtocolSCTP {
+			continue
+		}
+		address := fmt.Sprintf("%s.%s:%d", svc.Name, namespace, port.Port)
+		examples = append(examples,
+			fmt.Sprintf("curl -H '%s: %s' http://%s/", meshKey, meshVersion, address),
+			fmt.Sprintf("grpcurl -plaintext -H '%s: %s' %s list", meshKey, meshVersion, address))
+	}
+	return examples
+}
+
+func printAccessExamples(svc *coreV1.Service, meshKey, meshVersion string) {
+	examples := accessExamples(svc, opt.Get().Global.Namespace, meshKey, meshVersion)
+	if len(examples) == 0 {
+		return
+	}
+	log.Info().Msgf(" Examples: ")
+	for _, example := range examples {
+		log.Info().Msgf("   %s", example)
+	}
+}
@@ -1,7 +1,17 @@
 package mesh
 
 import (
+	"errors"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+	"strconv"
 	"testing"
 )
 
@@ -20,14 +30,82 @@ func Test_getVersion(t *testing.T) {
 	var k, v string
 	k, v = getVersion("")
 	require.Equal(t, k, "version")
-	require.Equal(t, len(v), 5)
+	require.Equal(t, v, "")
 	k, v = getVersion("test")
 	require.Equal(t, k, "version")
 	require.Equal(t, v, "test")
 	k, v = getVersion("mark:")
 	require.Equal(t, k, "mark")
-	require.Equal(t, len(v), 5)
+	require.Equal(t, v, "")
 	k, v = getVersion("mark:test")
 	require.Equal(t, k, "mark")
 	require.Equal(t, v, "test")
 }
+
+func Test_toVersion(t *testing.T) {
+	require.Equal(t, "alice", toVersion("alice"))
+	require.Equal(t, "corp-bob-smith", toVersion("CORP\\Bob.Smith"))
+	require.Equal(t, "a-very-long-user-nam", toVersion("a.very.long.user.name.over.limit"))
+	require.Equal(t, "abcdefghijklmnopqrs", toVersion("abcdefghijklmnopqrs_tuv"))
+	require.Equal(t, "", toVersion("__"))
+}
+
+func Test_accessExamples(t *testing.T) {
+	svc := &coreV1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "tomcat"},
+		Spec: coreV1.ServiceSpec{Ports: []coreV1.ServicePort{
+			{Port: 8080, Protocol: coreV1.ProtocolTCP},
+			{Port: 53, Protocol: coreV1.ProtocolUDP},
+			{Port: 9090},
+		}},
+	}
+	require.Equal(t, []string{
+		"curl -H 'kt-mark: alice' http://tomcat.default:8080/",
+		"grpcurl -plaintext -H 'kt-mark: alice' tomcat.default:8080 list",
+		"curl -H 'kt-mark: alice' http://tomcat.default:9090/",
+		"grpcurl -plaintext -H 'kt-mark: alice' tomcat.default:9090 list",
+	}, accessExamples(svc, "default", "kt-mark", "alice"))
+}
+
+func Test_isVersionOccupied(t *testing.T) {
+	meshPod := func(version, user, heartBeat string) *coreV1.Pod {
+		return &coreV1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "tomcat" + util.MeshPodInfix + version, Namespace: "default",
+			Annotations: map[string]string{util.KtUser: user, util.KtLastHeartBeat: heartBeat}}}
+	}
+	staleHeartBeat := strconv.FormatInt(util.GetTime()-600, 10)
+	clientset := testclient.NewSimpleClientset(
+		meshPod("others", "others", staleHeartBeat),
+		meshPod("alive", util.GetLocalUserName(), util.GetTimestamp()),
+		meshPod("stale", util.GetLocalUserName(), staleHeartBeat),
+		&coreV1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "tomcat" + util.MeshPodInfix + "stale", Namespace: "default"}},
+	)
+	clientset.PrependReactor("get", "pods", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		if action.(k8sTesting.GetAction).GetName() == "tomcat"+util.MeshPodInfix+"forbidden" {
+			return true, nil, errors.New("forbidden")
+		}
+		return false, nil, nil
+	})
+	opt.Store.Clientset = clientset
+	opt.Get().Global.Namespace = "default"
+
+	tests := []struct {
+		version      string
+		wantOccupied bool
+		wantErr      bool
+	}{
+		{version: "free", wantOccupied: false},
+		{version: "others", wantOccupied: true},
+		{version: "alive", wantOccupied: true},
+		{version: "stale", wantOccupied: false},
+		{version: "forbidden", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			occupied, err := isVersionOccupied("tomcat", tt.version)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Equal(t, tt.wantOccupied, occupied)
+		})
+	}
+	_, err := cluster.Ins().GetConfigMap("tomcat"+util.MeshPodInfix+"stale", "default")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,124 @@
+package mesh
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/command/general"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"strings"
+)
+
+func IstioMesh(svc *coreV1.Service) error {
+	// Lock service to avoid conflict, must be first step
+	svc, err := general.LockService(svc.Name, opt.Get().Global.Namespace)
+	if err != nil {
+		return err
+	}
+	defer general.UnlockService(svc.Name, opt.Get().Global.Namespace)
+
+	meshKey, meshVersion := getVersion(opt.Get().Mesh.VersionMark)
+	if meshVersion, err = resolveVersion(svc.Name, meshVersion); err != nil {
+		return err
+	}
+	opt.Store.Mesh = meshKey + ":" + meshVersion
+
+	shadowPodName := svc.Name + util.MeshPodInfix + meshVersion
+	labels := getMeshLabels(meshKey, meshVersion, svc)
+	if err = general.CreateShadowAndInbound(shadowPodName, opt.Get().Mesh.Expose, labels,
+		map[string]string{}, general.GetTargetPorts(svc)); err != nil {
+		return err
+	}
+
+	// Subset must be ready before virtual service route to it
+	if err = addDestinationRuleSubset(svc.Name, meshKey, meshVersion); err != nil {
+		return err
+	}
+	if err = addVirtualServiceRoute(svc.Name, meshKey, meshVersion); err != nil {
+		return err
+	}
+	log.Info().Msg("---------------------------------------------------------------")
+	log.Info().Msgf(" Now you can access your service by header '%s: %s' ", strings.ToUpper(meshKey), meshVersion)
+	printAccessExamples(svc, meshKey, meshVersion)
+	log.Info().Msg("---------------------------------------------------------------")
+	return nil
+}
+
+func addDestinationRuleSubset(svcName, meshKey, meshVersion string) error {
+	namespace := opt.Get().Global.Namespace
+	subset := map[string]any{
+		"name":   general.IstioRuleName(meshVersion),
+		"labels": map[string]any{meshKey: meshVersion},
+	}
+	rule, err := general.FindIstioRule(general.KindDestinationRule, svcName, namespace)
+	if err != nil {
+		return err
+	} else if rule == nil {
+		rule = newIstioRule("DestinationRule", svcName, meshVersion, map[string]any{
+			"host":    svcName,
+			"subsets": []any{subset},
+		})
+		general.RecordIstioChange(general.KindDestinationRule, rule.GetName(), namespace)
+		log.Info().Msgf("Creating destination rule %s", rule.GetName())
+		return cluster.Ins().CreateCustomResource(general.DestinationRuleGVR, rule)
+	}
+	subsets, _, _ := unstructured.NestedSlice(rule.Object, "spec", "subsets")
+	if err = unstructured.SetNestedSlice(rule.Object, append(subsets, subset), "spec", "subsets"); err != nil {
+		return err
+	}
+	general.RecordIstioChange(general.KindDestinationRule, rule.GetName(), namespace)
+	log.Info().Msgf("Adding subset %s to destination rule %s", general.IstioRuleName(meshVersion), rule.GetName())
+	return cluster.Ins().UpdateCustomResource(general.DestinationRuleGVR, rule)
+}
+
+func addVirtualServiceRoute(svcName, meshKey, meshVersion string) error {
+	namespace := opt.Get().Global.Namespace
+	route := map[string]any{
+		"name": general.IstioRuleName(meshVersion),
+		"match": []any{map[string]any{
+			"headers": map[string]any{meshKey: map[string]any{"exact": meshVersion}},
+		}},
+		"route": []any{map[string]any{
+			"destination": map[string]any{"host": svcName, "subset": general.IstioRuleName(meshVersion)},
+		}},
+	}
+	vs, err := general.FindIstioRule(general.KindVirtualService, svcName, namespace)
+	if err != nil {
+		return err
+	} else if vs == nil {
+		vs = newIstioRule("VirtualService", svcName, meshVersion, map[string]any{
+			"hosts": []any{svcName},
+			"http": []any{route, map[string]any{
+				"name":  general.IstioDefaultRoute,
+				"route": []any{map[string]any{"destination": map[string]any{"host": svcName}}},
+			}},
+		})
+		general.RecordIstioChange(general.KindVirtualService, vs.GetName(), namespace)
+		log.Info().Msgf("Creating virtual service %s", vs.GetName())
+		return cluster.Ins().CreateCustomResource(general.VirtualServiceGVR, vs)
+	}
+	// header match route must take precedence over existing ones
+	routes, _, _ := unstructured.NestedSlice(vs.Object, "spec", "http")
+	if err = unstructured.SetNestedSlice(vs.Object, append([]any{route}, routes...), "spec", "http"); err != nil {
+		return err
+	}
+	general.RecordIstioChange(general.KindVirtualService, vs.GetName(), namespace)
+	log.Info().Msgf("Adding route %s to virtual service %s", general.IstioRuleName(meshVersion), vs.GetName())
+	return cluster.Ins().UpdateCustomResource(general.VirtualServiceGVR, vs)
+}
+
+func newIstioRule(kind, svcName, meshVersion string, spec map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": general.VirtualServiceGVR.GroupVersion().String(),
+		"kind":       kind,
+		"metadata": map[string]any{
+			"name":        fmt.Sprintf("%s%s%s", svcName, util.MeshPodInfix, meshVersion),
+			"namespace":   opt.Get().Global.Namespace,
+			"annotations": map[string]any{util.KtConfig: fmt.Sprintf("service=%s", svcName)},
+		},
+		"spec": spec,
+	}}
+}
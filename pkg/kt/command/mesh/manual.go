@@ -10,10 +10,14 @@ import (
 
 func ManualMesh(svc *coreV1.Service) error {
 	meshKey, meshVersion := getVersion(opt.Get().Mesh.VersionMark)
+	meshVersion, err := resolveVersion(svc.Name, meshVersion)
+	if err != nil {
+		return err
+	}
 	shadowPodName := svc.Name + util.MeshPodInfix + meshVersion
 	labels := getMeshLabels(meshKey, meshVersion, svc)
 	annotations := make(map[string]string)
-	if err := general.CreateShadowAndInbound(shadowPodName, opt.Get().Mesh.Expose, labels,
+	if err = general.CreateShadowAndInbound(shadowPodName, opt.Get().Mesh.Expose, labels,
 		annotations, general.GetTargetPorts(svc)); err != nil {
 		return err
 	}
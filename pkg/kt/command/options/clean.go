@@ -21,6 +21,16 @@ func CleanFlags() []OptionConfig {
 			DefaultValue: false,
 			Description:  "Only check and restore local changes made by kt",
 		},
+		{
+			Target:       "Watch",
+			DefaultValue: false,
+			Description:  "Keep checking and cleaning unavailing resources in all namespaces",
+		},
+		{
+			Target:       "WatchInterval",
+			DefaultValue: 300,
+			Description:  "Interval of each check in seconds when watch mode enabled",
+		},
 	}
 	return flags
 }
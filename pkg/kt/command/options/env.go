@@ -0,0 +1,22 @@
+package options
+
+func EnvFlags() []OptionConfig {
+	flags := []OptionConfig{
+		{
+			Target:       "Container",
+			DefaultValue: "",
+			Description:  "Name of container to fetch environment from, use the first container by default",
+		},
+		{
+			Target:       "MountRoot",
+			DefaultValue: "",
+			Description:  "Local directory to put files mounted by target pod, use a folder under ~/.kt/mount by default",
+		},
+		{
+			Target:       "Run",
+			DefaultValue: "",
+			Description:  "Command to run with the environment, print environment variables if not specified",
+		},
+	}
+	return flags
+}
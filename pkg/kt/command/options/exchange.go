@@ -13,7 +13,7 @@ func ExchangeFlags() []OptionConfig {
 		{
 			Target:       "Mode",
 			DefaultValue: util.ExchangeModeSelector,
-			Description:  "Exchange method 'selector', 'scale' or 'ephemeral'(experimental)",
+			Description:  "Exchange method 'selector', 'scale', 'endpoints'(service without selector only) or 'ephemeral'(experimental)",
 		},
 		{
 			Target:       "SkipPortChecking",
@@ -25,6 +25,31 @@ func ExchangeFlags() []OptionConfig {
 			DefaultValue: 120,
 			Description:  "(scale method only) Seconds to wait for original deployment recover before turn off the shadow pod",
 		},
+		{
+			Target:       "Fallback",
+			DefaultValue: false,
+			Description:  "(selector method only) Route traffic back to original pods while local endpoint is unreachable",
+		},
+		{
+			Target:       "Run",
+			DefaultValue: "",
+			Description:  "Command to run at local with environment variables and files of target workload, exit when it stops",
+		},
+		{
+			Target:       "Record",
+			DefaultValue: "",
+			Description:  descRecord,
+		},
+		{
+			Target:       "Inspect",
+			DefaultValue: "",
+			Description:  descInspect,
+		},
+		{
+			Target:       "DryRun",
+			DefaultValue: false,
+			Description:  descDryRun,
+		},
 	}
 	return flags
 }
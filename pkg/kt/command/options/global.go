@@ -82,6 +82,21 @@ func GlobalFlags() []OptionConfig {
 			DefaultValue: false,
 			Description:  "Use local time for resource heartbeat timestamp",
 		},
+		{
+			Target:       "RestrictedShadow",
+			DefaultValue: false,
+			Description:  "Run shadow pod as non-root with read-only root filesystem, for 'restricted' pod security level",
+		},
+		{
+			Target:       "Transport",
+			DefaultValue: util.TransportSsh,
+			Description:  "Tunnel protocol between ktctl and shadow pod, 'ssh' or 'mux'",
+		},
+		{
+			Target:       "UseSessionController",
+			DefaultValue: true,
+			Description:  "Record service and deployment changes in KtSession resource and let kt controller apply them, change them directly if KtSession is not installed",
+		},
 		{
 			Target:       "ForceUpdate",
 			Alias:        "f",
@@ -16,7 +16,7 @@ func MeshFlags() []OptionConfig {
 		{
 			Target:       "Mode",
 			DefaultValue: util.MeshModeAuto,
-			Description:  "Mesh method 'auto' or 'manual'",
+			Description:  "Mesh method 'auto', 'manual', 'istio' or 'mirror'",
 		},
 		{
 			Target:       "VersionMark",
@@ -31,7 +31,47 @@ func MeshFlags() []OptionConfig {
 		{
 			Target:       "RouterImage",
 			DefaultValue: fmt.Sprintf("%s:v%s", util.ImageKtRouter, Store.Version),
-			Description:  "(auto method only) Customize router image",
+			Description:  "(auto and mirror method only) Customize router image",
+		},
+		{
+			Target:       "RouterTimeout",
+			DefaultValue: "",
+			Description:  "(auto and mirror method only) Timeout of router reading from or writing to upstream, e.g. '1h' (default: 1h)",
+		},
+		{
+			Target:       "RouterMaxBody",
+			DefaultValue: "",
+			Description:  "(auto and mirror method only) Max request body size allowed by router, e.g. '100m', '0' means unlimited (default: 0)",
+		},
+		{
+			Target:       "Inject",
+			DefaultValue: "",
+			Description:  "(auto and mirror method only) Faults to inject via router, e.g. 'delay=500ms,abort=503:10%,target=version'",
+		},
+		{
+			Target:       "Failover",
+			DefaultValue: false,
+			Description:  "(auto method only) Route marked requests to original pods while local service is unreachable",
+		},
+		{
+			Target:       "RouteExpire",
+			DefaultValue: util.ResourceHeartBeatIntervalMinus*2 + 1,
+			Description:  "(auto and mirror method only) Minutes before router removes the route to local service which keeps unreachable, 0 means never",
+		},
+		{
+			Target:       "Record",
+			DefaultValue: "",
+			Description:  descRecord,
+		},
+		{
+			Target:       "Inspect",
+			DefaultValue: "",
+			Description:  descInspect,
+		},
+		{
+			Target:       "DryRun",
+			DefaultValue: false,
+			Description:  descDryRun,
 		},
 	}
 	return flags
@@ -8,6 +8,13 @@ import (
 	"unsafe"
 )
 
+// description of flags shared by exchange, mesh and preview commands
+const (
+	descRecord  = "File to record http requests and responses of local service, which can be replayed via 'ktctl replay'"
+	descInspect = "Address to serve web ui showing http requests to local service, e.g. ':4040'"
+	descDryRun  = "Only print changes to be applied to cluster, without actually making them"
+)
+
 type OptionConfig struct {
 	Target string
 	Alias string
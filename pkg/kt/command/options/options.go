@@ -36,6 +36,11 @@ type ExchangeOptions struct {
 	Expose           string
 	RecoverWaitTime  int
 	SkipPortChecking bool
+	DryRun           bool
+	Run              string
+	Fallback         bool
+	Record           string
+	Inspect          string
 }
 
 // MeshOptions ...
@@ -45,10 +50,34 @@ type MeshOptions struct {
 	VersionMark      string
 	RouterImage      string
 	SkipPortChecking bool
+	DryRun           bool
+	Record           string
+	Inspect          string
+	Inject           string
+	Failover         bool
+	RouteExpire      int
+	RouterTimeout    string
+	RouterMaxBody    string
+}
+
+// EnvOptions ...
+type EnvOptions struct {
+	Container string
+	MountRoot string
+	Run       string
+}
+
+// ReplayOptions ...
+type ReplayOptions struct {
+	To         string
+	Timing     bool
+	DropHeader string
+	Path       string
 }
 
 // RecoverOptions ...
 type RecoverOptions struct {
+	Local bool
 }
 
 // PreviewOptions ...
@@ -56,6 +85,7 @@ type PreviewOptions struct {
 	External         bool
 	Expose           string
 	SkipPortChecking bool
+	DryRun           bool
 }
 
 // ForwardOptions ...
@@ -67,6 +97,8 @@ type CleanOptions struct {
 	DryRun           bool
 	ThresholdInMinus int64
 	LocalOnly        bool
+	Watch            bool
+	WatchInterval    int
 }
 
 // ConfigOptions ...
@@ -82,25 +114,28 @@ type BirdseyeOptions struct {
 
 // GlobalOptions ...
 type GlobalOptions struct {
-	AsWorker            bool
-	Kubeconfig          string
-	Namespace           string
-	ServiceAccount      string
-	Debug               bool
-	Image               string
-	ImagePullSecret     string
-	NodeSelector        string
-	WithLabel           string
-	WithAnnotation      string
-	PortForwardTimeout  int
-	PodCreationTimeout  int
-	UseShadowDeployment bool
-	ForceUpdate         bool
-	UseLocalTime        bool
-	Context             string
-	PodQuota            string
-	ListenCheck         bool
-	IpVersion           int
+	AsWorker             bool
+	Kubeconfig           string
+	Namespace            string
+	ServiceAccount       string
+	Debug                bool
+	Image                string
+	ImagePullSecret      string
+	NodeSelector         string
+	WithLabel            string
+	WithAnnotation       string
+	PortForwardTimeout   int
+	PodCreationTimeout   int
+	UseShadowDeployment  bool
+	ForceUpdate          bool
+	UseLocalTime         bool
+	RestrictedShadow     bool
+	Transport            string
+	Context              string
+	PodQuota             string
+	ListenCheck          bool
+	IpVersion            int
+	UseSessionController bool
 }
 
 // DaemonOptions cli options
@@ -110,6 +145,8 @@ type DaemonOptions struct {
 	Mesh     *MeshOptions
 	Preview  *PreviewOptions
 	Forward  *ForwardOptions
+	Env      *EnvOptions
+	Replay   *ReplayOptions
 	Recover  *RecoverOptions
 	Clean    *CleanOptions
 	Config   *ConfigOptions
@@ -129,6 +166,8 @@ func Get() *DaemonOptions {
 			Mesh:     &MeshOptions{},
 			Preview:  &PreviewOptions{},
 			Forward:  &ForwardOptions{},
+			Env:      &EnvOptions{},
+			Replay:   &ReplayOptions{},
 			Recover:  &RecoverOptions{},
 			Clean:    &CleanOptions{},
 			Birdseye: &BirdseyeOptions{},
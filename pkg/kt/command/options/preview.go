@@ -18,6 +18,11 @@ func PreviewFlags() []OptionConfig {
 			DefaultValue: false,
 			Description:  "Do not check whether specified local ports are listened",
 		},
+		{
+			Target:       "DryRun",
+			DefaultValue: false,
+			Description:  descDryRun,
+		},
 	}
 	return flags
 }
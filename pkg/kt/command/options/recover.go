@@ -2,6 +2,11 @@ package options
 
 func RecoverFlags() []OptionConfig {
 	flags := []OptionConfig{
+		{
+			Target:       "Local",
+			DefaultValue: false,
+			Description:  "Roll back changes left by ktctl processes exited unexpectedly, according to local journal",
+		},
 	}
 	return flags
 }
\ No newline at end of file
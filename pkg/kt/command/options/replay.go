@@ -0,0 +1,28 @@
+package options
+
+func ReplayFlags() []OptionConfig {
+	flags := []OptionConfig{
+		{
+			Target:       "To",
+			DefaultValue: "",
+			Description:  "Address of local service to send requests to, e.g. localhost:8080",
+			Required:     true,
+		},
+		{
+			Target:       "Timing",
+			DefaultValue: false,
+			Description:  "Keep the original intervals between requests",
+		},
+		{
+			Target:       "DropHeader",
+			DefaultValue: "",
+			Description:  "Request headers not to replay, use ',' separated, e.g. Authorization,Cookie",
+		},
+		{
+			Target:       "Path",
+			DefaultValue: "",
+			Description:  "Only replay requests whose uri matches this regular expression",
+		},
+	}
+	return flags
+}
@@ -1,8 +1,10 @@
 package options
 
 import (
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"os"
 )
 
 var Store = &RuntimeStore{}
@@ -11,6 +13,8 @@ var Store = &RuntimeStore{}
 type RuntimeStore struct {
 	// Clientset for kubernetes operation
 	Clientset kubernetes.Interface
+	// DynamicClient for custom resource operation
+	DynamicClient dynamic.Interface
 	// RestConfig kubectl config
 	RestConfig *rest.Config
 	// Version ktctl version
@@ -27,6 +31,16 @@ type RuntimeStore struct {
 	Origin string
 	// Replicas the origin replicas
 	Replicas int32
+	// Workload custom workload suspended during exchange, in <type>/<name> format
+	Workload string
+	// WorkloadRestore patch to restore the suspended workload
+	WorkloadRestore []byte
+	// IstioRules istio resources changed by mesh, in <kind>/<name> format
+	IstioRules string
+	// LocalProcess process started with environment of target workload
+	LocalProcess *os.Process
+	// MountRoot temporary directory of files mirrored from target workload
+	MountRoot string
 	// Service exposed service name
 	Service string
 	// isIpv6Cluster
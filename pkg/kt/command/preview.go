@@ -38,6 +38,11 @@ func NewPreviewCommand() *cobra.Command {
 
 // Preview create a new service in cluster
 func Preview(serviceName string) error {
+	if opt.Get().Preview.DryRun {
+		return general.DryRun(util.ComponentPreview, func() error {
+			return preview.Expose(serviceName)
+		})
+	}
 	ch, err := general.SetupProcess(util.ComponentPreview)
 	if err != nil {
 		return err
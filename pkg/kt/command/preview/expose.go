@@ -2,6 +2,7 @@ package preview
 
 import (
 	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/command/general"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/transmission"
@@ -61,9 +62,14 @@ func exposeLocalService(serviceName, shadowPodName string, labels, annotations m
 	}
 	opt.Store.Service = serviceName
 
-	if _, err = transmission.ForwardPodToLocal(opt.Get().Preview.Expose, podName, privateKeyPath); err != nil {
+	if cluster.IsDryRun() {
+		return nil
+	}
+	_, gone, err := transmission.ForwardPodToLocal(opt.Get().Preview.Expose, podName, privateKeyPath)
+	if err != nil {
 		return err
 	}
+	general.ExitOnPortForwardGone(gone)
 
 	log.Info().Msgf("Forward remote %s:%v -> 127.0.0.1:%v", podName, opt.Get().Preview.Expose, opt.Get().Preview.Expose)
 	return nil
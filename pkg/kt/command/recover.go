@@ -21,7 +21,11 @@ func NewRecoverCommand() *cobra.Command {
 		Use:  "recover",
 		Short: "Restore traffic of specified kubernetes service changed by exchange or mesh",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 0 {
+			if opt.Get().Recover.Local {
+				if len(args) > 0 {
+					return fmt.Errorf("service name should not be specified with '--local' option")
+				}
+			} else if len(args) == 0 {
 				return fmt.Errorf("name of service to recover is required")
 			} else if len(args) > 1 {
 				return fmt.Errorf("too many service names are spcified (%s), should be one", strings.Join(args, ",") )
@@ -30,6 +34,10 @@ func NewRecoverCommand() *cobra.Command {
 			return general.Prepare()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opt.Get().Recover.Local {
+				general.ReplayJournals()
+				return nil
+			}
 			return Recover(args[0])
 		},
 		Example: "ktctl recover [command options]",
@@ -47,6 +55,13 @@ func Recover(serviceName string) error {
 		log.Error().Err(err).Msgf("Failed to fetch service '%s'", serviceName)
 	}
 
+	if general.IsEndpointsTakenOver(serviceName, opt.Get().Global.Namespace) {
+		checkAndMarkUnlock(serviceName, svc)
+		log.Info().Msgf("Service %s is exchanged by endpoints, recovering", serviceName)
+		general.RecoverServiceEndpoints(serviceName, opt.Get().Global.Namespace)
+		return nil
+	}
+
 	apps, err := cluster.Ins().GetDeploymentsByLabel(svc.Spec.Selector, svc.Namespace)
 	if err != nil {
 		return err
@@ -57,6 +72,9 @@ func Recover(serviceName string) error {
 	}
 	targetDeployment, targetPod, targetRole := fetchTargetRole(apps, pods)
 	log.Debug().Msgf("Target role is: %s", targetRole)
+	if isTargetInUse(targetDeployment, targetPod, svc.Namespace) {
+		log.Warn().Msgf("Service %s is still used by an active kt session, recovering anyway", serviceName)
+	}
 
 	if svc.Annotations == nil {
 		// put an empty map to avoid npe
@@ -127,7 +145,30 @@ func fetchTargetRole(apps *appV1.DeploymentList, pods *coreV1.PodList) (*appV1.D
 	return nil, nil, ""
 }
 
+func isTargetInUse(deployment *appV1.Deployment, pod *coreV1.Pod, namespace string) bool {
+	leases, err := cluster.GetKtLeases(namespace)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to fetch kt leases")
+		return false
+	}
+	if deployment != nil {
+		if _, alive := cluster.CheckSessionLeases(deployment.Annotations, leases); alive {
+			return true
+		}
+	}
+	if pod != nil {
+		if _, alive := cluster.CheckSessionLeases(pod.Annotations, leases); alive {
+			return true
+		}
+	}
+	return false
+}
+
 func checkAndMarkUnlock(serviceName string, svc *coreV1.Service) bool {
+	if err := cluster.Ins().RemoveLease(cluster.LockLeaseName(serviceName), svc.Namespace); err == nil {
+		log.Info().Msgf("Service %s unlocked", serviceName)
+	}
+	// lock annotation is used by earlier version
 	if _, exists := svc.Annotations[util.KtLock]; exists {
 		log.Info().Msgf("Unlocking service %s", serviceName)
 		delete(svc.Annotations, util.KtLock)
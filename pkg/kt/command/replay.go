@@ -0,0 +1,101 @@
+package command
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/command/general"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/recorder"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// NewReplayCommand return new replay command
+func NewReplayCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Re-send requests recorded by exchange or mesh to local service",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("record file to replay is required")
+			} else if len(args) > 1 {
+				return fmt.Errorf("too many record files are spcified (%s), should be one", strings.Join(args, ","))
+			}
+			general.SetupLogger()
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return Replay(args[0])
+		},
+		Example: "ktctl replay <record-file> --to localhost:8080 [command options]",
+	}
+
+	cmd.SetUsageTemplate(general.UsageTemplate(false))
+	opt.SetOptions(cmd, cmd.Flags(), opt.Get().Replay, opt.ReplayFlags())
+	return cmd
+}
+
+// Replay send recorded requests to local service one by one
+func Replay(file string) error {
+	entries, err := recorder.Load(file)
+	if err != nil {
+		return fmt.Errorf("failed to load record file %s: %s", file, err)
+	}
+	var pathPattern *regexp.Regexp
+	if opt.Get().Replay.Path != "" {
+		if pathPattern, err = regexp.Compile(opt.Get().Replay.Path); err != nil {
+			return fmt.Errorf("invalid path pattern '%s': %s", opt.Get().Replay.Path, err)
+		}
+	}
+	var dropHeaders []string
+	if opt.Get().Replay.DropHeader != "" {
+		dropHeaders = strings.Split(opt.Get().Replay.DropHeader, ",")
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	recorder.SortByTime(entries)
+	var first *recorder.Entry
+	var begin time.Time
+	replayed, mismatched, failed := 0, 0, 0
+	for i := range entries {
+		entry := &entries[i]
+		if pathPattern != nil && !pathPattern.MatchString(entry.Request.Uri) {
+			continue
+		}
+		if first == nil {
+			first, begin = entry, time.Now()
+		} else if opt.Get().Replay.Timing {
+			time.Sleep(recorder.Delay(first, entry, time.Since(begin)))
+		}
+		replayed++
+		start := time.Now()
+		status, err2 := recorder.Replay(client, entry, opt.Get().Replay.To, dropHeaders)
+		if err2 != nil {
+			failed++
+			log.Error().Err(err2).Msgf("%s %s failed", entry.Request.Method, entry.Request.Uri)
+			continue
+		}
+		recorded := 0
+		if entry.Response != nil {
+			recorded = entry.Response.Status
+		}
+		if recorded != 0 && recorded != status {
+			mismatched++
+			log.Warn().Msgf("%s %s -> %d (recorded %d) in %d ms", entry.Request.Method, entry.Request.Uri,
+				status, recorded, time.Since(start).Milliseconds())
+		} else {
+			log.Info().Msgf("%s %s -> %d in %d ms", entry.Request.Method, entry.Request.Uri,
+				status, time.Since(start).Milliseconds())
+		}
+	}
+	log.Info().Msgf("Replayed %d requests, %d with different status, %d failed", replayed, mismatched, failed)
+	return nil
+}
@@ -3,7 +3,6 @@ package cluster
 import (
 	"context"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
-	"github.com/rs/zerolog/log"
 	coreV1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	labelApi "k8s.io/apimachinery/pkg/labels"
@@ -23,6 +22,38 @@ func (k *Kubernetes) GetConfigMapsByLabel(labels map[string]string, namespace st
 	})
 }
 
+// CreateConfigMap create configmap owned by current session
+func (k *Kubernetes) CreateConfigMap(name, namespace string, labels, annotations, data map[string]string) (*coreV1.ConfigMap, error) {
+	k.SetupSessionLease(namespace)
+	recordCreate("configmap", name, namespace)
+
+	labels = util.MergeMap(labels, map[string]string{util.ControlBy: util.KubernetesToolkit})
+	annotations = SessionTracking(annotations)
+	configMap, err := k.Clientset.CoreV1().ConfigMaps(namespace).Create(context.TODO(), &coreV1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Data: data,
+	}, metav1.CreateOptions{})
+	if err == nil {
+		setupResourceHeartBeat("configmap", name, namespace, k.patchConfigMap)
+	}
+	return configMap, err
+}
+
+// UpdateConfigMap update configmap, fail with conflict error if it's modified since fetched
+func (k *Kubernetes) UpdateConfigMap(configMap *coreV1.ConfigMap) (*coreV1.ConfigMap, error) {
+	updated, err := k.Clientset.CoreV1().ConfigMaps(configMap.Namespace).Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	if _, exists := configMap.Annotations[util.KtLastHeartBeat]; err == nil && exists {
+		// configmap attached to current session without lease should be kept alive by heart beat
+		setupResourceHeartBeat("configmap", configMap.Name, configMap.Namespace, k.patchConfigMap)
+	}
+	return updated, err
+}
+
 // RemoveConfigMap remove ConfigMap instance
 func (k *Kubernetes) RemoveConfigMap(name, namespace string) (err error) {
 	deletePolicy := metav1.DeletePropagationBackground
@@ -31,37 +62,31 @@ func (k *Kubernetes) RemoveConfigMap(name, namespace string) (err error) {
 	})
 }
 
-func (k *Kubernetes) UpdateConfigMapHeartBeat(name, namespace string) {
-	key := "configmap_" + name
-	if _, err := k.Clientset.CoreV1().ConfigMaps(namespace).
-		Patch(context.TODO(), name, types.JSONPatchType, []byte(resourceHeartbeatPatch()), metav1.PatchOptions{}); err != nil {
-		if healthy, exists := LastHeartBeatStatus.Get(key); healthy || !exists {
-			log.Warn().Err(err).Msgf("Failed to update heart beat of config map %s", name)
-		} else {
-			log.Debug().Err(err).Msgf("Config map %s heart beat interrupted", name)
-		}
-		LastHeartBeatStatus.Set(key, false)
-	} else {
-		log.Debug().Msgf("Heartbeat configmap %s ticked at %s", name, util.FormattedTime())
-		LastHeartBeatStatus.Set(key, true)
-	}
-}
-
 func (k *Kubernetes) createConfigMapWithSshKey(labels map[string]string, sshcm string, namespace string,
 	generator *util.SSHGenerator) (configMap *coreV1.ConfigMap, err error) {
-	SetupHeartBeat(sshcm, namespace, k.UpdateConfigMapHeartBeat)
+	k.SetupSessionLease(namespace)
+	recordCreate("configmap", sshcm, namespace)
 
 	labels = util.MergeMap(labels, map[string]string{util.ControlBy: util.KubernetesToolkit})
-	return k.Clientset.CoreV1().ConfigMaps(namespace).Create(context.TODO(), &coreV1.ConfigMap{
+	configMap, err = k.Clientset.CoreV1().ConfigMaps(namespace).Create(context.TODO(), &coreV1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        sshcm,
 			Namespace:   namespace,
 			Labels:      labels,
-			Annotations: map[string]string{util.KtLastHeartBeat: util.GetTimestamp()},
+			Annotations: SessionTracking(map[string]string{}),
 		},
 		Data: map[string]string{
 			util.SshAuthKey:        string(generator.PublicKey),
 			util.SshAuthPrivateKey: string(generator.PrivateKey),
 		},
 	}, metav1.CreateOptions{})
+	if err == nil {
+		setupResourceHeartBeat("configmap", sshcm, namespace, k.patchConfigMap)
+	}
+	return configMap, err
+}
+
+func (k *Kubernetes) patchConfigMap(name, namespace string, patch []byte) error {
+	_, err := k.Clientset.CoreV1().ConfigMaps(namespace).Patch(context.TODO(), name, types.JSONPatchType, patch, metav1.PatchOptions{})
+	return err
 }
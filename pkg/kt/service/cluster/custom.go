@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"context"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// GetCustomResource get custom resource via dynamic client
+func (k *Kubernetes) GetCustomResource(gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+	return k.DynamicClient.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// PatchCustomResource apply json merge patch to custom resource
+func (k *Kubernetes) PatchCustomResource(gvr schema.GroupVersionResource, name, namespace string, patch []byte) error {
+	_, err := k.DynamicClient.Resource(gvr).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType,
+		patch, metav1.PatchOptions{})
+	return err
+}
+
+// ListCustomResources list all custom resources of specified type in namespace
+func (k *Kubernetes) ListCustomResources(gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
+	list, err := k.DynamicClient.Resource(gvr).Namespace(namespace).List(context.TODO(), metav1.ListOptions{
+		TimeoutSeconds: &apiTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// CreateCustomResource create custom resource, which is kept alive with current session
+func (k *Kubernetes) CreateCustomResource(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	k.SetupSessionLease(obj.GetNamespace())
+	obj.SetLabels(util.MergeMap(obj.GetLabels(), map[string]string{util.ControlBy: util.KubernetesToolkit}))
+	obj.SetAnnotations(SessionTracking(obj.GetAnnotations()))
+	_, err := k.DynamicClient.Resource(gvr).Namespace(obj.GetNamespace()).Create(context.TODO(), obj, metav1.CreateOptions{})
+	return err
+}
+
+// UpdateCustomResource ...
+func (k *Kubernetes) UpdateCustomResource(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	_, err := k.DynamicClient.Resource(gvr).Namespace(obj.GetNamespace()).Update(context.TODO(), obj, metav1.UpdateOptions{})
+	return err
+}
+
+// RemoveCustomResource ...
+func (k *Kubernetes) RemoveCustomResource(gvr schema.GroupVersionResource, name, namespace string) error {
+	return k.DynamicClient.Resource(gvr).Namespace(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
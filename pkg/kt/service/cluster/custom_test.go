@@ -0,0 +1,45 @@
+package cluster
+
+import (
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func TestKubernetes_CustomResources(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "virtualservices"}
+	k := &Kubernetes{
+		Clientset: testclient.NewSimpleClientset(),
+		DynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{gvr: "VirtualServiceList"}),
+	}
+	vs := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "VirtualService",
+		"metadata":   map[string]any{"name": "tomcat-kt-mesh-abc", "namespace": "default"},
+		"spec":       map[string]any{"hosts": []any{"tomcat"}},
+	}}
+	require.Nil(t, k.CreateCustomResource(gvr, vs))
+
+	items, err := k.ListCustomResources(gvr, "default")
+	require.Nil(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, util.KubernetesToolkit, items[0].GetLabels()[util.ControlBy])
+	require.Equal(t, SessionLeaseName(), items[0].GetAnnotations()[util.KtLease])
+
+	require.Nil(t, unstructured.SetNestedStringSlice(items[0].Object, []string{"tomcat", "tomcat.default"}, "spec", "hosts"))
+	require.Nil(t, k.UpdateCustomResource(gvr, &items[0]))
+	obj, err := k.GetCustomResource(gvr, "tomcat-kt-mesh-abc", "default")
+	require.Nil(t, err)
+	hosts, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "hosts")
+	require.Equal(t, []string{"tomcat", "tomcat.default"}, hosts)
+
+	require.Nil(t, k.RemoveCustomResource(gvr, "tomcat-kt-mesh-abc", "default"))
+	items, _ = k.ListCustomResources(gvr, "default")
+	require.Empty(t, items)
+}
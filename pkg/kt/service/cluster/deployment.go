@@ -58,8 +58,18 @@ func (k *Kubernetes) IncreaseDeploymentRef(name string, namespace string) error
 		return err
 	}
 
+	k.SetupSessionLease(namespace)
 	app.Annotations[util.KtRefCount] = strconv.Itoa(count + 1)
-	_, err = k.UpdateDeployment(app)
+	app.Annotations = AttachSessionTracking(app.Annotations)
+	if _, err = k.UpdateDeployment(app); err != nil {
+		return err
+	}
+	setupResourceHeartBeat("deployment", name, namespace, k.patchDeployment)
+	return nil
+}
+
+func (k *Kubernetes) patchDeployment(name, namespace string, patch []byte) error {
+	_, err := k.Clientset.AppsV1().Deployments(namespace).Patch(context.TODO(), name, types.JSONPatchType, patch, metav1.PatchOptions{})
 	return err
 }
 
@@ -85,22 +95,6 @@ func (k *Kubernetes) DecreaseDeploymentRef(name string, namespace string) (clean
 	}
 }
 
-func (k *Kubernetes) UpdateDeploymentHeartBeat(name, namespace string) {
-	key := "deployment_" + name
-	if _, err := k.Clientset.AppsV1().Deployments(namespace).
-		Patch(context.TODO(), name, types.JSONPatchType, []byte(resourceHeartbeatPatch()), metav1.PatchOptions{}); err != nil {
-		if healthy, exists := LastHeartBeatStatus.Get(key); healthy || !exists {
-			log.Warn().Err(err).Msgf("Failed to update heart beat of deployment %s", name)
-		} else {
-			log.Debug().Err(err).Msgf("Deployment %s heart beat interrupted", name)
-		}
-		LastHeartBeatStatus.Set(key, false)
-	} else {
-		log.Debug().Msgf("Heartbeat deployment %s ticked at %s", name, util.FormattedTime())
-		LastHeartBeatStatus.Set(key, true)
-	}
-}
-
 // ScaleTo scale deployment to
 func (k *Kubernetes) ScaleTo(name, namespace string, replicas *int32) (err error) {
 	deployment, err := k.GetDeployment(name, namespace)
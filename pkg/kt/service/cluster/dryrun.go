@@ -0,0 +1,656 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	appV1 "k8s.io/api/apps/v1"
+	coordV1 "k8s.io/api/coordination/v1"
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	extV1 "k8s.io/api/extensions/v1beta1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// PlanCreate resource would be created
+	PlanCreate = "+"
+	// PlanUpdate resource would be modified
+	PlanUpdate = "~"
+	// PlanDelete resource would be deleted
+	PlanDelete = "-"
+	// PlanExec command would be executed in pod
+	PlanExec = "!"
+)
+
+// PlanItem a change which would be made to cluster
+type PlanItem struct {
+	Operation string
+	Kind      string
+	Name      string
+	Namespace string
+	Details   []string
+}
+
+// String format the plan item in diff style
+func (p PlanItem) String() string {
+	line := fmt.Sprintf("%s %s/%s (namespace: %s)", p.Operation, p.Kind, p.Name, p.Namespace)
+	for _, d := range p.Details {
+		line += "\n    " + d
+	}
+	return line
+}
+
+// DryRunKubernetes read from cluster, but only record changes instead of applying them,
+// every method is implemented explicitly, so that no mutating call could reach the cluster by accident
+type DryRunKubernetes struct {
+	origin     KubernetesInterface
+	plan       []PlanItem
+	created    map[string]*coreV1.Pod
+	leaseSetup bool
+	lock       sync.Mutex
+}
+
+var _ KubernetesInterface = &DryRunKubernetes{}
+
+var dryRunInstance *DryRunKubernetes
+
+// EnableDryRun let Ins() return a recording instance, all following changes are recorded to plan only
+func EnableDryRun() *DryRunKubernetes {
+	dryRunInstance = NewDryRunKubernetes(Ins())
+	return dryRunInstance
+}
+
+// IsDryRun check whether dry run mode enabled
+func IsDryRun() bool {
+	return dryRunInstance != nil
+}
+
+// NewDryRunKubernetes create recording instance based on real cluster
+func NewDryRunKubernetes(k KubernetesInterface) *DryRunKubernetes {
+	return &DryRunKubernetes{origin: k, created: map[string]*coreV1.Pod{}}
+}
+
+// Plan all recorded changes
+func (d *DryRunKubernetes) Plan() []PlanItem {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return append([]PlanItem{}, d.plan...)
+}
+
+func (d *DryRunKubernetes) record(operation, kind, name, namespace string, details ...string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.plan = append(d.plan, PlanItem{operation, kind, name, namespace, details})
+}
+
+// GetPod ...
+func (d *DryRunKubernetes) GetPod(name, namespace string) (*coreV1.Pod, error) {
+	d.lock.Lock()
+	pod, ok := d.created[name]
+	d.lock.Unlock()
+	if ok {
+		return pod, nil
+	}
+	return d.origin.GetPod(name, namespace)
+}
+
+// UpdatePod ...
+func (d *DryRunKubernetes) UpdatePod(pod *coreV1.Pod) (*coreV1.Pod, error) {
+	var details []string
+	if origin, err := d.origin.GetPod(pod.Name, pod.Namespace); err == nil {
+		details = append(diffMap("label", origin.Labels, pod.Labels), diffMap("annotation", origin.Annotations, pod.Annotations)...)
+	}
+	d.record(PlanUpdate, "pod", pod.Name, pod.Namespace, details...)
+	return pod, nil
+}
+
+// RemovePod ...
+func (d *DryRunKubernetes) RemovePod(name, namespace string) error {
+	d.record(PlanDelete, "pod", name, namespace)
+	return nil
+}
+
+// GetOrCreateShadow ...
+func (d *DryRunKubernetes) GetOrCreateShadow(name string, labels, annotations, envs map[string]string,
+	portsToExpose string, portNameDict map[int]string) (string, string, string, error) {
+	opt.Store.Shadow = name
+	kind := "pod"
+	if opt.Get().Global.UseShadowDeployment {
+		kind = "deployment"
+	}
+	d.record(PlanCreate, "configmap", name, opt.Get().Global.Namespace, "ssh key of shadow pod")
+	d.record(PlanCreate, kind, name, opt.Get().Global.Namespace,
+		fmt.Sprintf("labels: %s", formatMap(labels)), fmt.Sprintf("expose: %s", portsToExpose))
+	d.lock.Lock()
+	d.created[name] = &coreV1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      name,
+		Namespace: opt.Get().Global.Namespace,
+		Labels:    labels,
+	}}
+	d.lock.Unlock()
+	return "", name, "", nil
+}
+
+// CreateRouterPod ...
+func (d *DryRunKubernetes) CreateRouterPod(name string, labels, annotations map[string]string, ports map[int]int) (*coreV1.Pod, error) {
+	pod := &coreV1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   opt.Get().Global.Namespace,
+		Labels:      labels,
+		Annotations: annotations,
+	}}
+	d.lock.Lock()
+	d.created[name] = pod
+	d.lock.Unlock()
+	d.record(PlanCreate, "pod", name, pod.Namespace, fmt.Sprintf("labels: %s", formatMap(labels)),
+		fmt.Sprintf("image: %s", opt.Get().Mesh.RouterImage))
+	return pod, nil
+}
+
+// CreateRectifierPod ...
+func (d *DryRunKubernetes) CreateRectifierPod(name string) (*coreV1.Pod, error) {
+	d.record(PlanCreate, "pod", name, opt.Get().Global.Namespace, "temporary pod for time difference")
+	return &coreV1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: opt.Get().Global.Namespace}}, nil
+}
+
+// WaitPodReady ...
+func (d *DryRunKubernetes) WaitPodReady(name, namespace string, timeoutSec int) (*coreV1.Pod, error) {
+	d.lock.Lock()
+	pod, ok := d.created[name]
+	d.lock.Unlock()
+	if ok {
+		return pod, nil
+	}
+	return d.origin.WaitPodReady(name, namespace, timeoutSec)
+}
+
+// WaitPodTerminate pretend the terminating pod is gone without waiting
+func (d *DryRunKubernetes) WaitPodTerminate(name, namespace string) (*coreV1.Pod, error) {
+	return nil, k8sErrors.NewNotFound(coreV1.Resource("pods"), name)
+}
+
+// WatchPod ...
+func (d *DryRunKubernetes) WatchPod(name, namespace string, fAdd, fDel, fMod func(*coreV1.Pod)) {
+}
+
+// ExecInPod ...
+func (d *DryRunKubernetes) ExecInPod(containerName, podName, namespace string, cmd ...string) (string, string, error) {
+	d.record(PlanExec, "pod", podName, namespace, fmt.Sprintf("exec: %s", strings.Join(cmd, " ")))
+	return "", "", nil
+}
+
+// AddEphemeralContainer ...
+func (d *DryRunKubernetes) AddEphemeralContainer(containerName, podName string, envs map[string]string) (string, error) {
+	d.record(PlanUpdate, "pod", podName, opt.Get().Global.Namespace, fmt.Sprintf("ephemeral container: + %s", containerName))
+	return "", nil
+}
+
+// RemoveEphemeralContainer ...
+func (d *DryRunKubernetes) RemoveEphemeralContainer(containerName, podName string, namespace string) error {
+	d.record(PlanUpdate, "pod", podName, namespace, fmt.Sprintf("ephemeral container: - %s", containerName))
+	return nil
+}
+
+// IncreasePodRef ...
+func (d *DryRunKubernetes) IncreasePodRef(name, namespace string) error {
+	d.record(PlanUpdate, "pod", name, namespace, fmt.Sprintf("annotation %s: +1", util.KtRefCount))
+	return nil
+}
+
+// DecreasePodRef ...
+func (d *DryRunKubernetes) DecreasePodRef(name, namespace string) (bool, error) {
+	d.record(PlanUpdate, "pod", name, namespace, fmt.Sprintf("annotation %s: -1", util.KtRefCount))
+	return false, nil
+}
+
+// UpdateDeployment ...
+func (d *DryRunKubernetes) UpdateDeployment(deployment *appV1.Deployment) (*appV1.Deployment, error) {
+	var details []string
+	if origin, err := d.origin.GetDeployment(deployment.Name, deployment.Namespace); err == nil {
+		details = diffReplicas(origin.Spec.Replicas, deployment.Spec.Replicas)
+		details = append(details, diffMap("annotation", origin.Annotations, deployment.Annotations)...)
+	}
+	d.record(PlanUpdate, "deployment", deployment.Name, deployment.Namespace, details...)
+	return deployment, nil
+}
+
+// RemoveDeployment ...
+func (d *DryRunKubernetes) RemoveDeployment(name, namespace string) error {
+	d.record(PlanDelete, "deployment", name, namespace)
+	return nil
+}
+
+// IncreaseDeploymentRef ...
+func (d *DryRunKubernetes) IncreaseDeploymentRef(name, namespace string) error {
+	d.record(PlanUpdate, "deployment", name, namespace, fmt.Sprintf("annotation %s: +1", util.KtRefCount))
+	return nil
+}
+
+// DecreaseDeploymentRef ...
+func (d *DryRunKubernetes) DecreaseDeploymentRef(name, namespace string) (bool, error) {
+	d.record(PlanUpdate, "deployment", name, namespace, fmt.Sprintf("annotation %s: -1", util.KtRefCount))
+	return false, nil
+}
+
+// ScaleTo ...
+func (d *DryRunKubernetes) ScaleTo(name, namespace string, replicas *int32) error {
+	var details []string
+	if origin, err := d.origin.GetDeployment(name, namespace); err == nil {
+		details = diffReplicas(origin.Spec.Replicas, replicas)
+	}
+	d.record(PlanUpdate, "deployment", name, namespace, details...)
+	return nil
+}
+
+// CreateService ...
+func (d *DryRunKubernetes) CreateService(metaAndSpec *SvcMetaAndSpec) (*coreV1.Service, error) {
+	svc := createService(metaAndSpec)
+	d.record(PlanCreate, "service", svc.Name, svc.Namespace, fmt.Sprintf("selector: %s", formatMap(svc.Spec.Selector)),
+		fmt.Sprintf("type: %s", svc.Spec.Type))
+	return svc, nil
+}
+
+// UpdateService ...
+func (d *DryRunKubernetes) UpdateService(svc *coreV1.Service) (*coreV1.Service, error) {
+	var details []string
+	if origin, err := d.origin.GetService(svc.Name, svc.Namespace); err == nil {
+		if !util.MapEquals(origin.Spec.Selector, svc.Spec.Selector) {
+			details = append(details, fmt.Sprintf("selector: %s -> %s", formatMap(origin.Spec.Selector), formatMap(svc.Spec.Selector)))
+		}
+		details = append(details, diffMap("annotation", origin.Annotations, svc.Annotations)...)
+	}
+	d.record(PlanUpdate, "service", svc.Name, svc.Namespace, details...)
+	return svc, nil
+}
+
+// RemoveService ...
+func (d *DryRunKubernetes) RemoveService(name, namespace string) error {
+	d.record(PlanDelete, "service", name, namespace)
+	return nil
+}
+
+// AttachServiceToSession ...
+func (d *DryRunKubernetes) AttachServiceToSession(name, namespace string) error {
+	d.record(PlanUpdate, "service", name, namespace, fmt.Sprintf("annotation %s: + %s", util.KtLease, SessionLeaseName()))
+	return nil
+}
+
+// WatchService ...
+func (d *DryRunKubernetes) WatchService(name, namespace string, fAdd, fDel, fMod func(*coreV1.Service)) {
+}
+
+// UpdateEndpoints ...
+func (d *DryRunKubernetes) UpdateEndpoints(endpoints *coreV1.Endpoints) (*coreV1.Endpoints, error) {
+	d.record(PlanUpdate, "endpoints", endpoints.Name, endpoints.Namespace,
+		fmt.Sprintf("addresses: %s", formatAddresses(endpoints)))
+	return endpoints, nil
+}
+
+// CreateEndpointSlice ...
+func (d *DryRunKubernetes) CreateEndpointSlice(slice *discoveryV1.EndpointSlice) (*discoveryV1.EndpointSlice, error) {
+	d.record(PlanCreate, "endpointslice", slice.Name, slice.Namespace,
+		fmt.Sprintf("service: %s", slice.Labels[discoveryV1.LabelServiceName]),
+		fmt.Sprintf("endpoints: %s", formatEndpoints(slice.Endpoints)))
+	return slice, nil
+}
+
+// UpdateEndpointSlice ...
+func (d *DryRunKubernetes) UpdateEndpointSlice(slice *discoveryV1.EndpointSlice) (*discoveryV1.EndpointSlice, error) {
+	d.record(PlanUpdate, "endpointslice", slice.Name, slice.Namespace,
+		fmt.Sprintf("endpoints: %s", formatEndpoints(slice.Endpoints)))
+	return slice, nil
+}
+
+// RemoveEndpointSlice ...
+func (d *DryRunKubernetes) RemoveEndpointSlice(name, namespace string) error {
+	d.record(PlanDelete, "endpointslice", name, namespace)
+	return nil
+}
+
+// PatchCustomResource ...
+func (d *DryRunKubernetes) PatchCustomResource(gvr schema.GroupVersionResource, name, namespace string, patch []byte) error {
+	d.record(PlanUpdate, gvr.Resource, name, namespace, fmt.Sprintf("patch: %s", string(patch)))
+	return nil
+}
+
+// CreateCustomResource ...
+func (d *DryRunKubernetes) CreateCustomResource(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	spec, _ := json.Marshal(obj.Object["spec"])
+	d.record(PlanCreate, gvr.Resource, obj.GetName(), obj.GetNamespace(), fmt.Sprintf("spec: %s", string(spec)))
+	return nil
+}
+
+// UpdateCustomResource ...
+func (d *DryRunKubernetes) UpdateCustomResource(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	spec, _ := json.Marshal(obj.Object["spec"])
+	d.record(PlanUpdate, gvr.Resource, obj.GetName(), obj.GetNamespace(), fmt.Sprintf("spec: %s", string(spec)))
+	return nil
+}
+
+// RemoveCustomResource ...
+func (d *DryRunKubernetes) RemoveCustomResource(gvr schema.GroupVersionResource, name, namespace string) error {
+	d.record(PlanDelete, gvr.Resource, name, namespace)
+	return nil
+}
+
+// CreateConfigMap ...
+func (d *DryRunKubernetes) CreateConfigMap(name, namespace string, labels, annotations, data map[string]string) (*coreV1.ConfigMap, error) {
+	d.record(PlanCreate, "configmap", name, namespace, fmt.Sprintf("data: %s", formatMap(data)))
+	return &coreV1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels,
+		Annotations: annotations}, Data: data}, nil
+}
+
+// UpdateConfigMap ...
+func (d *DryRunKubernetes) UpdateConfigMap(configMap *coreV1.ConfigMap) (*coreV1.ConfigMap, error) {
+	d.record(PlanUpdate, "configmap", configMap.Name, configMap.Namespace, fmt.Sprintf("data: %s", formatMap(configMap.Data)))
+	return configMap, nil
+}
+
+// RemoveConfigMap ...
+func (d *DryRunKubernetes) RemoveConfigMap(name, namespace string) error {
+	d.record(PlanDelete, "configmap", name, namespace)
+	return nil
+}
+
+// RemoveLease ...
+func (d *DryRunKubernetes) RemoveLease(name, namespace string) error {
+	d.record(PlanDelete, "lease", name, namespace)
+	return nil
+}
+
+// AcquireLease ...
+func (d *DryRunKubernetes) AcquireLease(name, namespace, role string, durationSec int32) (bool, error) {
+	if lease, err := d.origin.GetLease(name, namespace); err == nil && !IsLeaseExpired(lease) &&
+		lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != LeaseHolder() {
+		// report lock conflict just like real run
+		return false, nil
+	}
+	if role != util.RoleLock {
+		// lock lease only lives during the change, not worth showing
+		d.record(PlanCreate, "lease", name, namespace, fmt.Sprintf("role: %s", role))
+	}
+	return true, nil
+}
+
+// RenewLease ...
+func (d *DryRunKubernetes) RenewLease(name, namespace string) error {
+	return nil
+}
+
+// ReleaseLease ...
+func (d *DryRunKubernetes) ReleaseLease(name, namespace string) error {
+	return nil
+}
+
+// SetupSessionLease ...
+func (d *DryRunKubernetes) SetupSessionLease(namespace string) {
+	d.lock.Lock()
+	recorded := d.leaseSetup
+	d.leaseSetup = true
+	d.lock.Unlock()
+	if !recorded {
+		d.record(PlanCreate, "lease", SessionLeaseName(), namespace, fmt.Sprintf("role: %s", util.RoleSession))
+	}
+}
+
+// CreateEvent ...
+func (d *DryRunKubernetes) CreateEvent(kind, name, namespace, reason, message string) error {
+	return nil
+}
+
+// CreateKtSession ...
+func (d *DryRunKubernetes) CreateKtSession(session *KtSession) (*KtSession, error) {
+	d.record(PlanCreate, "ktsession", session.Name, session.Namespace)
+	return session, nil
+}
+
+// UpdateKtSession ...
+func (d *DryRunKubernetes) UpdateKtSession(session *KtSession) (*KtSession, error) {
+	d.record(PlanUpdate, "ktsession", session.Name, session.Namespace)
+	return session, nil
+}
+
+// UpdateKtSessionStatus ...
+func (d *DryRunKubernetes) UpdateKtSessionStatus(session *KtSession) (*KtSession, error) {
+	return session, nil
+}
+
+// RemoveKtSession ...
+func (d *DryRunKubernetes) RemoveKtSession(name, namespace string) error {
+	d.record(PlanDelete, "ktsession", name, namespace)
+	return nil
+}
+
+// RecordSessionChange ...
+func (d *DryRunKubernetes) RecordSessionChange(namespace, component, mode string, change func(spec *KtSessionSpec)) error {
+	d.SetupSessionLease(namespace)
+	spec := &KtSessionSpec{}
+	change(spec)
+	var details []string
+	for _, s := range spec.Services {
+		details = append(details, fmt.Sprintf("service %s selector: %s -> %s", s.Name,
+			formatMap(s.OriginalSelector), formatMap(s.Selector)))
+	}
+	for _, a := range spec.Deployments {
+		details = append(details, fmt.Sprintf("deployment %s replicas: %d -> %d", a.Name, a.OriginalReplicas, a.Replicas))
+	}
+	d.record(PlanUpdate, "ktsession", SessionLeaseName(), namespace, details...)
+	return nil
+}
+
+// WaitKtSessionApplied ...
+func (d *DryRunKubernetes) WaitKtSessionApplied(name, namespace string, timeoutSec int) error {
+	return nil
+}
+
+// WaitKtSessionRemoved ...
+func (d *DryRunKubernetes) WaitKtSessionRemoved(name, namespace string, timeoutSec int) error {
+	return nil
+}
+
+// CreateServiceAccountToken token request is a write to cluster, which is not sent in dry run mode
+func (d *DryRunKubernetes) CreateServiceAccountToken(name, namespace string, audiences []string, expirationSec *int64) (string, error) {
+	return "", fmt.Errorf("token of service account %s is not requested in dry run mode", name)
+}
+
+// GetPodsByLabel ...
+func (d *DryRunKubernetes) GetPodsByLabel(labels map[string]string, namespace string) (*coreV1.PodList, error) {
+	return d.origin.GetPodsByLabel(labels, namespace)
+}
+
+// GetDeployment ...
+func (d *DryRunKubernetes) GetDeployment(name string, namespace string) (*appV1.Deployment, error) {
+	return d.origin.GetDeployment(name, namespace)
+}
+
+// GetDeploymentsByLabel ...
+func (d *DryRunKubernetes) GetDeploymentsByLabel(labels map[string]string, namespace string) (*appV1.DeploymentList, error) {
+	return d.origin.GetDeploymentsByLabel(labels, namespace)
+}
+
+// GetAllDeploymentInNamespace ...
+func (d *DryRunKubernetes) GetAllDeploymentInNamespace(namespace string) (*appV1.DeploymentList, error) {
+	return d.origin.GetAllDeploymentInNamespace(namespace)
+}
+
+// GetService ...
+func (d *DryRunKubernetes) GetService(name, namespace string) (*coreV1.Service, error) {
+	return d.origin.GetService(name, namespace)
+}
+
+// GetServicesBySelector ...
+func (d *DryRunKubernetes) GetServicesBySelector(matchLabels map[string]string, namespace string) ([]coreV1.Service, error) {
+	return d.origin.GetServicesBySelector(matchLabels, namespace)
+}
+
+// GetAllServiceInNamespace ...
+func (d *DryRunKubernetes) GetAllServiceInNamespace(namespace string) (*coreV1.ServiceList, error) {
+	return d.origin.GetAllServiceInNamespace(namespace)
+}
+
+// GetServicesByLabel ...
+func (d *DryRunKubernetes) GetServicesByLabel(labels map[string]string, namespace string) (*coreV1.ServiceList, error) {
+	return d.origin.GetServicesByLabel(labels, namespace)
+}
+
+// GetEndpoints ...
+func (d *DryRunKubernetes) GetEndpoints(name, namespace string) (*coreV1.Endpoints, error) {
+	return d.origin.GetEndpoints(name, namespace)
+}
+
+// GetAllEndpointsInNamespace ...
+func (d *DryRunKubernetes) GetAllEndpointsInNamespace(namespace string) ([]coreV1.Endpoints, error) {
+	return d.origin.GetAllEndpointsInNamespace(namespace)
+}
+
+// GetEndpointSlicesOfService ...
+func (d *DryRunKubernetes) GetEndpointSlicesOfService(svcName, namespace string) ([]discoveryV1.EndpointSlice, error) {
+	return d.origin.GetEndpointSlicesOfService(svcName, namespace)
+}
+
+// GetAllEndpointSlicesInNamespace ...
+func (d *DryRunKubernetes) GetAllEndpointSlicesInNamespace(namespace string) ([]discoveryV1.EndpointSlice, error) {
+	return d.origin.GetAllEndpointSlicesInNamespace(namespace)
+}
+
+// GetConfigMap ...
+func (d *DryRunKubernetes) GetConfigMap(name, namespace string) (*coreV1.ConfigMap, error) {
+	return d.origin.GetConfigMap(name, namespace)
+}
+
+// GetConfigMapsByLabel ...
+func (d *DryRunKubernetes) GetConfigMapsByLabel(labels map[string]string, namespace string) (*coreV1.ConfigMapList, error) {
+	return d.origin.GetConfigMapsByLabel(labels, namespace)
+}
+
+// GetSecret ...
+func (d *DryRunKubernetes) GetSecret(name, namespace string) (*coreV1.Secret, error) {
+	return d.origin.GetSecret(name, namespace)
+}
+
+// GetLease ...
+func (d *DryRunKubernetes) GetLease(name, namespace string) (*coordV1.Lease, error) {
+	return d.origin.GetLease(name, namespace)
+}
+
+// GetLeasesByLabel ...
+func (d *DryRunKubernetes) GetLeasesByLabel(labels map[string]string, namespace string) (*coordV1.LeaseList, error) {
+	return d.origin.GetLeasesByLabel(labels, namespace)
+}
+
+// GetKtSession ...
+func (d *DryRunKubernetes) GetKtSession(name, namespace string) (*KtSession, error) {
+	return d.origin.GetKtSession(name, namespace)
+}
+
+// GetKtSessions ...
+func (d *DryRunKubernetes) GetKtSessions(namespace string) ([]KtSession, error) {
+	return d.origin.GetKtSessions(namespace)
+}
+
+// GetCustomResource ...
+func (d *DryRunKubernetes) GetCustomResource(gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error) {
+	return d.origin.GetCustomResource(gvr, name, namespace)
+}
+
+// ListCustomResources ...
+func (d *DryRunKubernetes) ListCustomResources(gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
+	return d.origin.ListCustomResources(gvr, namespace)
+}
+
+// GetAllIngressInNamespace ...
+func (d *DryRunKubernetes) GetAllIngressInNamespace(namespace string) (*extV1.IngressList, error) {
+	return d.origin.GetAllIngressInNamespace(namespace)
+}
+
+// GetKtResources ...
+func (d *DryRunKubernetes) GetKtResources(namespace string) ([]coreV1.Pod, []coreV1.ConfigMap, []appV1.Deployment, []coreV1.Service, error) {
+	return d.origin.GetKtResources(namespace)
+}
+
+// GetAllNamespaces ...
+func (d *DryRunKubernetes) GetAllNamespaces() (*coreV1.NamespaceList, error) {
+	return d.origin.GetAllNamespaces()
+}
+
+// ClusterCidr ...
+func (d *DryRunKubernetes) ClusterCidr(namespace string) ([]string, []string) {
+	return d.origin.ClusterCidr(namespace)
+}
+
+func diffMap(field string, before, after map[string]string) []string {
+	var details []string
+	for _, k := range sortedKeys(before, after) {
+		oldValue, oldExists := before[k]
+		newValue, newExists := after[k]
+		if !oldExists {
+			details = append(details, fmt.Sprintf("%s %s: <none> -> %s", field, k, newValue))
+		} else if !newExists {
+			details = append(details, fmt.Sprintf("%s %s: %s -> <none>", field, k, oldValue))
+		} else if oldValue != newValue {
+			details = append(details, fmt.Sprintf("%s %s: %s -> %s", field, k, oldValue, newValue))
+		}
+	}
+	return details
+}
+
+func diffReplicas(before, after *int32) []string {
+	if before == nil || after == nil || *before == *after {
+		return nil
+	}
+	return []string{fmt.Sprintf("replicas: %d -> %d", *before, *after)}
+}
+
+func formatMap(m map[string]string) string {
+	var pairs []string
+	for _, k := range sortedKeys(m) {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatEndpoints(endpoints []discoveryV1.Endpoint) string {
+	var addresses []string
+	for _, e := range endpoints {
+		if e.TargetRef != nil {
+			// address of planned pod is unknown yet
+			addresses = append(addresses, strings.ToLower(e.TargetRef.Kind)+"/"+e.TargetRef.Name)
+		} else {
+			addresses = append(addresses, e.Addresses...)
+		}
+	}
+	return "[" + strings.Join(addresses, ",") + "]"
+}
+
+func formatAddresses(endpoints *coreV1.Endpoints) string {
+	var addresses []string
+	for _, s := range endpoints.Subsets {
+		for _, a := range s.Addresses {
+			addresses = append(addresses, a.IP)
+		}
+	}
+	return "[" + strings.Join(addresses, ",") + "]"
+}
+
+func sortedKeys(maps ...map[string]string) []string {
+	keySet := map[string]bool{}
+	for _, m := range maps {
+		for k := range m {
+			keySet[k] = true
+		}
+	}
+	var keys []string
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
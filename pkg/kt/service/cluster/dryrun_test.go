@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	appV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func TestDryRunKubernetes(t *testing.T) {
+	replicas := int32(2)
+	k := &Kubernetes{Clientset: testclient.NewSimpleClientset(
+		&coreV1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "tomcat", Namespace: "default"},
+			Spec:       coreV1.ServiceSpec{Selector: map[string]string{"app": "tomcat"}},
+		},
+		&appV1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "tomcat", Namespace: "default"},
+			Spec:       appV1.DeploymentSpec{Replicas: &replicas},
+		},
+	)}
+	d := NewDryRunKubernetes(k)
+
+	svc, err := d.GetService("tomcat", "default")
+	require.Nil(t, err)
+	svc.Spec.Selector = map[string]string{util.KtRole: util.RoleExchangeShadow}
+	svc.Annotations = map[string]string{util.KtSelector: `{"app":"tomcat"}`}
+	_, err = d.UpdateService(svc)
+	require.Nil(t, err)
+	down := int32(0)
+	require.Nil(t, d.ScaleTo("tomcat", "default", &down))
+	_, err = d.CreateRouterPod("tomcat-kt-router", map[string]string{util.KtRole: util.RoleRouter}, nil, nil)
+	require.Nil(t, err)
+	pod, err := d.WaitPodReady("tomcat-kt-router", "default", 10)
+	require.Nil(t, err)
+	require.Equal(t, "tomcat-kt-router", pod.Name)
+
+	plan := d.Plan()
+	require.Len(t, plan, 3)
+	require.Equal(t, PlanItem{PlanUpdate, "service", "tomcat", "default", []string{
+		"selector: {app=tomcat} -> {kt-role=shadow-exchange}",
+		`annotation kt-selector: <none> -> {"app":"tomcat"}`,
+	}}, plan[0])
+	require.Equal(t, []string{"replicas: 2 -> 0"}, plan[1].Details)
+	require.Equal(t, PlanCreate, plan[2].Operation)
+
+	// nothing changed in cluster
+	svc, _ = k.GetService("tomcat", "default")
+	require.Equal(t, map[string]string{"app": "tomcat"}, svc.Spec.Selector)
+	app, _ := k.GetDeployment("tomcat", "default")
+	require.Equal(t, int32(2), *app.Spec.Replicas)
+	_, err = k.GetPod("tomcat-kt-router", "default")
+	require.NotNil(t, err)
+
+	// mutating calls without plan item never reach cluster
+	actions := len(k.Clientset.(*testclient.Clientset).Actions())
+	_, err = d.CreateServiceAccountToken("default", "default", nil, nil)
+	require.NotNil(t, err)
+	_, err = d.WaitPodTerminate("tomcat-kt-router", "default")
+	require.True(t, k8sErrors.IsNotFound(err))
+	require.Len(t, k.Clientset.(*testclient.Clientset).Actions(), actions)
+}
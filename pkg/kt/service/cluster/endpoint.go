@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"context"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labelApi "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// GetEndpoints get endpoints of service
+func (k *Kubernetes) GetEndpoints(name, namespace string) (*coreV1.Endpoints, error) {
+	return k.Clientset.CoreV1().Endpoints(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// GetAllEndpointsInNamespace get all endpoints in specified namespace
+func (k *Kubernetes) GetAllEndpointsInNamespace(namespace string) ([]coreV1.Endpoints, error) {
+	endpoints, err := k.Clientset.CoreV1().Endpoints(namespace).List(context.TODO(), metav1.ListOptions{
+		TimeoutSeconds: &apiTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return endpoints.Items, nil
+}
+
+// UpdateEndpoints ...
+func (k *Kubernetes) UpdateEndpoints(endpoints *coreV1.Endpoints) (*coreV1.Endpoints, error) {
+	return k.Clientset.CoreV1().Endpoints(endpoints.Namespace).Update(context.TODO(), endpoints, metav1.UpdateOptions{})
+}
+
+// GetEndpointSlicesOfService get all endpoint slices belonging to specified service
+func (k *Kubernetes) GetEndpointSlicesOfService(svcName, namespace string) ([]discoveryV1.EndpointSlice, error) {
+	slices, err := k.Clientset.DiscoveryV1().EndpointSlices(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector:  labelApi.SelectorFromSet(map[string]string{discoveryV1.LabelServiceName: svcName}).String(),
+		TimeoutSeconds: &apiTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Items, nil
+}
+
+// GetAllEndpointSlicesInNamespace get all endpoint slices in specified namespace
+func (k *Kubernetes) GetAllEndpointSlicesInNamespace(namespace string) ([]discoveryV1.EndpointSlice, error) {
+	slices, err := k.Clientset.DiscoveryV1().EndpointSlices(namespace).List(context.TODO(), metav1.ListOptions{
+		TimeoutSeconds: &apiTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Items, nil
+}
+
+// CreateEndpointSlice create endpoint slice managed by kt
+func (k *Kubernetes) CreateEndpointSlice(slice *discoveryV1.EndpointSlice) (*discoveryV1.EndpointSlice, error) {
+	k.SetupSessionLease(slice.Namespace)
+	recordCreate("endpointslice", slice.Name, slice.Namespace)
+	slice.Labels = util.MergeMap(slice.Labels, map[string]string{
+		util.ControlBy:             util.KubernetesToolkit,
+		discoveryV1.LabelManagedBy: util.KubernetesToolkit,
+	})
+	slice.Annotations = SessionTracking(slice.Annotations)
+	created, err := k.Clientset.DiscoveryV1().EndpointSlices(slice.Namespace).Create(context.TODO(), slice, metav1.CreateOptions{})
+	if err == nil {
+		setupResourceHeartBeat("endpointslice", slice.Name, slice.Namespace, k.patchEndpointSlice)
+	}
+	return created, err
+}
+
+func (k *Kubernetes) patchEndpointSlice(name, namespace string, patch []byte) error {
+	_, err := k.Clientset.DiscoveryV1().EndpointSlices(namespace).Patch(context.TODO(), name, types.JSONPatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// UpdateEndpointSlice ...
+func (k *Kubernetes) UpdateEndpointSlice(slice *discoveryV1.EndpointSlice) (*discoveryV1.EndpointSlice, error) {
+	return k.Clientset.DiscoveryV1().EndpointSlices(slice.Namespace).Update(context.TODO(), slice, metav1.UpdateOptions{})
+}
+
+// RemoveEndpointSlice remove endpoint slice
+func (k *Kubernetes) RemoveEndpointSlice(name, namespace string) error {
+	return k.Clientset.DiscoveryV1().EndpointSlices(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
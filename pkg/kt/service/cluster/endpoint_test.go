@@ -0,0 +1,35 @@
+package cluster
+
+import (
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func TestKubernetes_EndpointSlices(t *testing.T) {
+	k := &Kubernetes{Clientset: testclient.NewSimpleClientset(
+		&discoveryV1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{Name: "tomcat-abc", Namespace: "default",
+			Labels: map[string]string{discoveryV1.LabelServiceName: "tomcat"}}},
+		&discoveryV1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{Name: "nginx-abc", Namespace: "default",
+			Labels: map[string]string{discoveryV1.LabelServiceName: "nginx"}}},
+	)}
+
+	_, err := k.CreateEndpointSlice(&discoveryV1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{Name: "tomcat-kt",
+		Namespace: "default", Labels: map[string]string{discoveryV1.LabelServiceName: "tomcat"}}})
+	require.Nil(t, err)
+	slices, err := k.GetEndpointSlicesOfService("tomcat", "default")
+	require.Nil(t, err)
+	require.Len(t, slices, 2)
+	for _, s := range slices {
+		if s.Name == "tomcat-kt" {
+			require.Equal(t, util.KubernetesToolkit, s.Labels[discoveryV1.LabelManagedBy])
+			require.Equal(t, SessionLeaseName(), s.Annotations[util.KtLease])
+		}
+	}
+	require.Nil(t, k.RemoveEndpointSlice("tomcat-kt", "default"))
+	slices, _ = k.GetEndpointSlicesOfService("tomcat", "default")
+	require.Len(t, slices, 1)
+}
@@ -0,0 +1,35 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+)
+
+// CreateEvent record a normal event of specified object
+func (k *Kubernetes) CreateEvent(kind, name, namespace, reason, message string) error {
+	now := metav1.Now()
+	_, err := k.Clientset.CoreV1().Events(namespace).Create(context.TODO(), &coreV1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", name, time.Now().UnixNano()),
+			Namespace: namespace,
+			Labels:    map[string]string{util.ControlBy: util.KubernetesToolkit},
+		},
+		InvolvedObject: coreV1.ObjectReference{
+			Kind:      kind,
+			Name:      name,
+			Namespace: namespace,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           coreV1.EventTypeNormal,
+		Source:         coreV1.EventSource{Component: util.KubernetesToolkit},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}, metav1.CreateOptions{})
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/router"
 	"github.com/rs/zerolog/log"
 	coreV1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,12 +22,15 @@ func (k *Kubernetes) CreateRouterPod(name string, labels, annotations map[string
 		Labels:      labels,
 		Annotations: annotations,
 	}, opt.Get().Mesh.RouterImage, map[string]string{}, targetPorts, true}
+	k.SetupSessionLease(metaAndSpec.Meta.Namespace)
 	pod := createPod(metaAndSpec)
+	addRouterConfigVolume(pod, name)
+	recordCreate("pod", name, metaAndSpec.Meta.Namespace)
 	if _, err := k.Clientset.CoreV1().Pods(metaAndSpec.Meta.Namespace).
 		Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
 		return nil, err
 	}
-	SetupHeartBeat(metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace, k.UpdatePodHeartBeat)
+	setupResourceHeartBeat("pod", name, metaAndSpec.Meta.Namespace, k.patchPod)
 	log.Info().Msgf("Router pod %s created", name)
 	return k.WaitPodReady(name, opt.Get().Global.Namespace, opt.Get().Global.PodCreationTimeout)
 }
@@ -41,6 +45,9 @@ func (k *Kubernetes) CreateRectifierPod(name string) (*coreV1.Pod, error) {
 	}, opt.Get().Global.Image, map[string]string{}, map[string]int{}, true}
 	pod := createPod(metaAndSpec)
 	pod.Spec.Containers[0].Command = []string{"tail", "-f", "/dev/null"}
+	if opt.Get().Global.RestrictedShadow {
+		applyRestrictedSecurityContext(&pod.Spec)
+	}
 	if _, err := k.Clientset.CoreV1().Pods(metaAndSpec.Meta.Namespace).
 		Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
 		return nil, err
@@ -48,3 +55,21 @@ func (k *Kubernetes) CreateRectifierPod(name string) (*coreV1.Pod, error) {
 	log.Debug().Msgf("Rectify pod %s created", name)
 	return k.WaitPodReady(name, opt.Get().Global.Namespace, opt.Get().Global.PodCreationTimeout)
 }
+
+// addRouterConfigVolume mount configmap with the same name as router pod, which holds route configuration
+func addRouterConfigVolume(pod *coreV1.Pod, configMapName string) {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, coreV1.Volume{
+		Name: "kt-router-config",
+		VolumeSource: coreV1.VolumeSource{
+			ConfigMap: &coreV1.ConfigMapVolumeSource{
+				LocalObjectReference: coreV1.LocalObjectReference{
+					Name: configMapName,
+				},
+			},
+		},
+	})
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, coreV1.VolumeMount{
+		Name:      "kt-router-config",
+		MountPath: router.ConfigDir,
+	})
+}
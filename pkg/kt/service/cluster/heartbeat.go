@@ -5,7 +5,9 @@ import (
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
-	"net"
+	"k8s.io/client-go/rest"
+	"math"
+	"net/http"
 	"strconv"
 	"strings"
 	"sync"
@@ -31,6 +33,9 @@ func (h *HeartBeatStatus) Set(key string, value bool) {
 	return
 }
 
+// resourcesInHeartBeat resources whose heart beat annotation is being updated
+var resourcesInHeartBeat sync.Map
+
 // LastHeartBeatStatus record last heart beat status to avoid verbose log
 var LastHeartBeatStatus = &HeartBeatStatus{
 	status: map[string]bool{},
@@ -38,26 +43,13 @@ var LastHeartBeatStatus = &HeartBeatStatus{
 
 // SetupTimeDifference get time difference between cluster and local
 func SetupTimeDifference() error {
-	rectifierPodName := fmt.Sprintf("%s%s", util.RectifierPodPrefix, strings.ToLower(util.RandomString(5)))
-	_, err := Ins().CreateRectifierPod(rectifierPodName)
-	if err != nil {
-		return err
-	}
-	stdout, stderr, err := Ins().ExecInPod(util.DefaultContainer, rectifierPodName, opt.Get().Global.Namespace, "date", "+%s")
+	timeDifference, err := getTimeDifferenceFromApiServer(opt.Store.RestConfig)
 	if err != nil {
-		return err
-	}
-	go func() {
-		if err2 := Ins().RemovePod(rectifierPodName, opt.Get().Global.Namespace); err2 != nil {
-			log.Debug().Err(err).Msgf("Failed to remove pod %s", rectifierPodName)
+		log.Debug().Err(err).Msgf("Unable to get cluster time from api server, try rectifier pod")
+		if timeDifference, err = getTimeDifferenceFromRectifierPod(); err != nil {
+			return err
 		}
-	}()
-	remoteTime, err := strconv.ParseInt(stdout, 10, 0)
-	if err != nil {
-		log.Warn().Msgf("Invalid cluster time: '%s' %s", stdout, stderr)
-		return err
 	}
-	timeDifference := remoteTime - time.Now().Unix()
 	if timeDifference >= -1 && timeDifference <= 1 {
 		log.Debug().Msgf("No time difference")
 	} else {
@@ -67,6 +59,67 @@ func SetupTimeDifference() error {
 	return nil
 }
 
+// getTimeDifferenceFromApiServer calculate time difference via the Date header of api server response
+func getTimeDifferenceFromApiServer(config *rest.Config) (int64, error) {
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return 0, err
+	}
+	host := strings.TrimSuffix(config.Host, "/")
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	client := &http.Client{Transport: transport, Timeout: 10 * time.Second}
+	start := time.Now()
+	// any response status is fine, only the Date header is needed
+	resp, err := client.Get(host + "/version")
+	end := time.Now()
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return calculateTimeDifference(resp.Header.Get("Date"), start, end)
+}
+
+// calculateTimeDifference Date header is truncated to second, so compare its middle value with middle of request time
+func calculateTimeDifference(date string, start, end time.Time) (int64, error) {
+	if date == "" {
+		return 0, fmt.Errorf("no Date header in response")
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return 0, err
+	}
+	localTime := start.Add(end.Sub(start) / 2)
+	return int64(math.Round(serverTime.Add(500 * time.Millisecond).Sub(localTime).Seconds())), nil
+}
+
+// getTimeDifferenceFromRectifierPod calculate time difference via executing date command in a temporary pod
+func getTimeDifferenceFromRectifierPod() (int64, error) {
+	rectifierPodName := fmt.Sprintf("%s%s", util.RectifierPodPrefix, strings.ToLower(util.RandomString(5)))
+	// rectifier pod should be removed even if it failed to become ready
+	defer func() {
+		go func() {
+			if err2 := Ins().RemovePod(rectifierPodName, opt.Get().Global.Namespace); err2 != nil {
+				log.Debug().Err(err2).Msgf("Failed to remove pod %s", rectifierPodName)
+			}
+		}()
+	}()
+	if _, err := Ins().CreateRectifierPod(rectifierPodName); err != nil {
+		return 0, err
+	}
+	stdout, stderr, err := Ins().ExecInPod(util.DefaultContainer, rectifierPodName, opt.Get().Global.Namespace, "date", "+%s")
+	if err != nil {
+		return 0, err
+	}
+	remoteTime, err := strconv.ParseInt(stdout, 10, 0)
+	if err != nil {
+		log.Warn().Msgf("Invalid cluster time: '%s' %s", stdout, stderr)
+		return 0, err
+	}
+	return remoteTime - time.Now().Unix(), nil
+}
+
 // SetupHeartBeat setup heartbeat watcher
 func SetupHeartBeat(name, namespace string, updater func(string, string)) {
 	ticker := time.NewTicker(time.Minute*util.ResourceHeartBeatIntervalMinus - util.RandomSeconds(0, 10))
@@ -77,30 +130,31 @@ func SetupHeartBeat(name, namespace string, updater func(string, string)) {
 	}()
 }
 
-// SetupPortForwardHeartBeat setup heartbeat watcher for port forward
-func SetupPortForwardHeartBeat(port int) *time.Ticker {
-	ticker := time.NewTicker(util.PortForwardHeartBeatIntervalSec*time.Second - util.RandomSeconds(0, 5))
-	go func() {
-	TickLoop:
-		for {
-			select {
-			case <-ticker.C:
-				if conn, err := net.Dial("tcp", fmt.Sprintf(":%d", port)); err != nil {
-					log.Warn().Err(err).Msgf("Heartbeat port forward %d ticked failed", port)
-				} else {
-					log.Debug().Msgf("Heartbeat port forward %d ticked at %s", port, util.FormattedTime())
-					_ = conn.Close()
-				}
-			case <-time.After(2 * util.PortForwardHeartBeatIntervalSec * time.Second):
-				log.Debug().Msgf("Port forward heartbeat %d stopped", port)
-				break TickLoop
+// setupResourceHeartBeat keep updating heart beat annotation of resource, only needed when session lease is unavailable
+func setupResourceHeartBeat(kind, name, namespace string, patcher func(name, namespace string, patch []byte) error) {
+	if IsSessionLeaseCreated() {
+		return
+	}
+	key := kind + "_" + name
+	if _, exists := resourcesInHeartBeat.LoadOrStore(namespace+"/"+key, true); exists {
+		return
+	}
+	SetupHeartBeat(name, namespace, func(name, namespace string) {
+		if err := patcher(name, namespace, []byte(resourceHeartbeatPatch())); err != nil {
+			if healthy, exists := LastHeartBeatStatus.Get(key); healthy || !exists {
+				log.Warn().Err(err).Msgf("Failed to update heart beat of %s %s", kind, name)
+			} else {
+				log.Debug().Err(err).Msgf("The %s %s heart beat interrupted", kind, name)
 			}
+			LastHeartBeatStatus.Set(key, false)
+		} else {
+			log.Debug().Msgf("Heartbeat %s %s ticked at %s", kind, name, util.FormattedTime())
+			LastHeartBeatStatus.Set(key, true)
 		}
-	}()
-	return ticker
+	})
 }
 
 func resourceHeartbeatPatch() string {
-	return fmt.Sprintf("[ { \"op\" : \"replace\" , \"path\" : \"/metadata/annotations/%s\" , \"value\" : \"%s\" } ]",
+	return fmt.Sprintf("[ { \"op\" : \"add\" , \"path\" : \"/metadata/annotations/%s\" , \"value\" : \"%s\" } ]",
 		util.KtLastHeartBeat, util.GetTimestamp())
 }
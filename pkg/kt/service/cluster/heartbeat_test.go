@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_calculateTimeDifference(t *testing.T) {
+	start := time.Date(2022, 6, 1, 10, 0, 0, 200000000, time.UTC)
+	end := start.Add(400 * time.Millisecond)
+	tests := []struct {
+		name    string
+		date    string
+		want    int64
+		wantErr bool
+	}{
+		{name: "noDifference", date: "Wed, 01 Jun 2022 10:00:00 GMT", want: 0},
+		{name: "clusterAhead", date: "Wed, 01 Jun 2022 10:01:00 GMT", want: 60},
+		{name: "clusterBehind", date: "Wed, 01 Jun 2022 09:59:50 GMT", want: -10},
+		{name: "noDate", date: "", wantErr: true},
+		{name: "invalidDate", date: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calculateTimeDifference(tt.date, start, end)
+			if tt.wantErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_getTimeDifferenceFromApiServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		// unauthorized response still carries the Date header
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	diff, err := getTimeDifferenceFromApiServer(&rest.Config{Host: server.URL})
+	require.Nil(t, err)
+	require.InDelta(t, -3600, diff, 1)
+}
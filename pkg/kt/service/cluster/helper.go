@@ -2,7 +2,9 @@ package cluster
 
 import (
 	"fmt"
+	"github.com/alibaba/kt-connect/pkg/common"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/journal"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	appV1 "k8s.io/api/apps/v1"
 	coreV1 "k8s.io/api/core/v1"
@@ -23,7 +25,7 @@ func getKubernetesClient(kubeConfig string) (clientset *kubernetes.Clientset, er
 
 func createService(metaAndSpec *SvcMetaAndSpec) *coreV1.Service {
 	var servicePorts []coreV1.ServicePort
-	metaAndSpec.Meta.Annotations = util.MapPut(metaAndSpec.Meta.Annotations, util.KtLastHeartBeat, util.GetTimestamp())
+	metaAndSpec.Meta.Annotations = SessionTracking(metaAndSpec.Meta.Annotations)
 	metaAndSpec.Meta.Labels = util.MergeMap(metaAndSpec.Meta.Labels, map[string]string{util.ControlBy: util.KubernetesToolkit})
 
 	for srcPort, targetPort := range metaAndSpec.Ports {
@@ -55,7 +57,7 @@ func createService(metaAndSpec *SvcMetaAndSpec) *coreV1.Service {
 
 func createDeployment(metaAndSpec *PodMetaAndSpec) *appV1.Deployment {
 	metaAndSpec.Meta.Annotations = util.MapPut(metaAndSpec.Meta.Annotations, util.KtRefCount, "1")
-	metaAndSpec.Meta.Annotations = util.MapPut(metaAndSpec.Meta.Annotations, util.KtLastHeartBeat, util.GetTimestamp())
+	metaAndSpec.Meta.Annotations = SessionTracking(metaAndSpec.Meta.Annotations)
 
 	var originLabels = make(map[string]string, 0)
 	for k, v := range metaAndSpec.Meta.Labels {
@@ -86,7 +88,7 @@ func createDeployment(metaAndSpec *PodMetaAndSpec) *appV1.Deployment {
 
 func createPod(metaAndSpec *PodMetaAndSpec) *coreV1.Pod {
 	metaAndSpec.Meta.Annotations = util.MapPut(metaAndSpec.Meta.Annotations, util.KtRefCount, "1")
-	metaAndSpec.Meta.Annotations = util.MapPut(metaAndSpec.Meta.Annotations, util.KtLastHeartBeat, util.GetTimestamp())
+	metaAndSpec.Meta.Annotations = SessionTracking(metaAndSpec.Meta.Annotations)
 	metaAndSpec.Meta.Labels = util.MergeMap(metaAndSpec.Meta.Labels, map[string]string{util.ControlBy: util.KubernetesToolkit})
 
 	pod := &coreV1.Pod{
@@ -157,3 +159,46 @@ func createContainer(image string, args []string, envs map[string]string, ports
 	}
 	return container
 }
+
+// applyRestrictedSecurityContext make pod compliant with the 'restricted' pod security standard
+func applyRestrictedSecurityContext(podSpec *coreV1.PodSpec) {
+	uid := int64(common.NonRootUid)
+	runAsNonRoot := true
+	allowPrivilegeEscalation := false
+	readOnlyRootFilesystem := true
+	podSpec.SecurityContext = &coreV1.PodSecurityContext{
+		RunAsNonRoot: &runAsNonRoot,
+		RunAsUser:    &uid,
+		RunAsGroup:   &uid,
+		FSGroup:      &uid,
+		SeccompProfile: &coreV1.SeccompProfile{
+			Type: coreV1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].SecurityContext = &coreV1.SecurityContext{
+			RunAsNonRoot:             &runAsNonRoot,
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+			Capabilities: &coreV1.Capabilities{
+				Drop: []coreV1.Capability{"ALL"},
+			},
+		}
+		// command executed in shadow pod (e.g. server side of sshuttle) may still need a writable temporary directory
+		podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, coreV1.VolumeMount{
+			Name:      "tmp",
+			MountPath: "/tmp",
+		})
+	}
+	podSpec.Volumes = append(podSpec.Volumes, coreV1.Volume{
+		Name: "tmp",
+		VolumeSource: coreV1.VolumeSource{
+			EmptyDir: &coreV1.EmptyDirVolumeSource{},
+		},
+	})
+}
+
+// recordCreate record resource creation in journal, so that it could be removed if ktctl crashed
+func recordCreate(kind, name, namespace string) {
+	journal.Record(journal.Entry{Action: journal.ActionCreate, Kind: kind, Name: name, Namespace: namespace})
+}
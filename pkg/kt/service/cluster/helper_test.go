@@ -1,6 +1,8 @@
 package cluster
 
 import (
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"reflect"
 	"testing"
@@ -38,3 +40,22 @@ func Test_getKubernetesClient(t *testing.T) {
 		})
 	}
 }
+
+func Test_applyRestrictedSecurityContext(t *testing.T) {
+	podSpec := coreV1.PodSpec{
+		Containers: []coreV1.Container{
+			{Name: "standalone"},
+		},
+	}
+	applyRestrictedSecurityContext(&podSpec)
+	require.True(t, *podSpec.SecurityContext.RunAsNonRoot)
+	require.Equal(t, int64(1000), *podSpec.SecurityContext.RunAsUser)
+	require.Equal(t, coreV1.SeccompProfileTypeRuntimeDefault, podSpec.SecurityContext.SeccompProfile.Type)
+	sc := podSpec.Containers[0].SecurityContext
+	require.False(t, *sc.AllowPrivilegeEscalation)
+	require.True(t, *sc.ReadOnlyRootFilesystem)
+	require.Equal(t, []coreV1.Capability{"ALL"}, sc.Capabilities.Drop)
+	require.Empty(t, sc.Capabilities.Add)
+	require.Equal(t, "/tmp", podSpec.Containers[0].VolumeMounts[0].MountPath)
+	require.NotNil(t, podSpec.Volumes[0].EmptyDir)
+}
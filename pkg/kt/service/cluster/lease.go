@@ -0,0 +1,247 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	coordV1 "k8s.io/api/coordination/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labelApi "k8s.io/apimachinery/pkg/labels"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var sessionLeaseName = util.SessionLeasePrefix + strings.ToLower(util.RandomString(10))
+var sessionLeaseOnce sync.Once
+var sessionLeaseCreated bool
+
+// SessionLeaseName name of the lease represents current ktctl process
+func SessionLeaseName() string {
+	return sessionLeaseName
+}
+
+// IsSessionLeaseCreated check whether lease of current process has been created
+func IsSessionLeaseCreated() bool {
+	return sessionLeaseCreated
+}
+
+// LeaseHolder identity of current ktctl process
+func LeaseHolder() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s@%s/%d", util.GetLocalUserName(), hostname, os.Getpid())
+}
+
+// LockLeaseName name of the lease used for locking specified service
+func LockLeaseName(serviceName string) string {
+	return serviceName + util.LockLeaseSuffix
+}
+
+// IsLeaseExpired check whether lease has not been renewed within its duration
+func IsLeaseExpired(lease *coordV1.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return util.GetTime() > lease.Spec.RenewTime.Unix()+int64(*lease.Spec.LeaseDurationSeconds)
+}
+
+// GetLease get lease
+func (k *Kubernetes) GetLease(name, namespace string) (*coordV1.Lease, error) {
+	return k.Clientset.CoordinationV1().Leases(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// GetLeasesByLabel get leases by label
+func (k *Kubernetes) GetLeasesByLabel(labels map[string]string, namespace string) (*coordV1.LeaseList, error) {
+	return k.Clientset.CoordinationV1().Leases(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector:  labelApi.SelectorFromSet(labels).String(),
+		TimeoutSeconds: &apiTimeout,
+	})
+}
+
+// RemoveLease remove lease
+func (k *Kubernetes) RemoveLease(name, namespace string) error {
+	return k.Clientset.CoordinationV1().Leases(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
+// AcquireLease create lease or take over an expired one, return false if the lease is held by others
+func (k *Kubernetes) AcquireLease(name, namespace, role string, durationSec int32) (bool, error) {
+	lease, err := k.GetLease(name, namespace)
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return false, err
+		}
+		_, err = k.Clientset.CoordinationV1().Leases(namespace).Create(context.TODO(),
+			newLease(name, namespace, role, durationSec), metav1.CreateOptions{})
+		if k8sErrors.IsAlreadyExists(err) {
+			// created by others at the same moment
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != LeaseHolder() && !IsLeaseExpired(lease) {
+		return false, nil
+	}
+	lease.Spec = newLease(name, namespace, role, durationSec).Spec
+	// update with resource version, so only one of concurrent takeover could success
+	if _, err = k.Clientset.CoordinationV1().Leases(namespace).Update(context.TODO(), lease, metav1.UpdateOptions{}); err != nil {
+		if k8sErrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// RenewLease update renew time of the lease
+func (k *Kubernetes) RenewLease(name, namespace string) error {
+	lease, err := k.GetLease(name, namespace)
+	if err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != LeaseHolder() {
+		return fmt.Errorf("lease %s is not held by current process", name)
+	}
+	now := metav1.NewMicroTime(time.Unix(util.GetTime(), 0))
+	lease.Spec.RenewTime = &now
+	_, err = k.Clientset.CoordinationV1().Leases(namespace).Update(context.TODO(), lease, metav1.UpdateOptions{})
+	return err
+}
+
+// ReleaseLease remove the lease if it's held by current process
+func (k *Kubernetes) ReleaseLease(name, namespace string) error {
+	lease, err := k.GetLease(name, namespace)
+	if err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != LeaseHolder() {
+		return fmt.Errorf("lease %s is not held by current process", name)
+	}
+	return k.RemoveLease(name, namespace)
+}
+
+// SetupSessionLease create lease of current process at first call, and keep renewing it
+func (k *Kubernetes) SetupSessionLease(namespace string) {
+	sessionLeaseOnce.Do(func() {
+		if _, err := k.AcquireLease(sessionLeaseName, namespace, util.RoleSession, util.SessionLeaseDurationSec); err != nil {
+			log.Warn().Err(err).Msgf("Failed to create session lease %s", sessionLeaseName)
+		} else {
+			log.Debug().Msgf("Session lease %s created", sessionLeaseName)
+			sessionLeaseCreated = true
+			SetupHeartBeat(sessionLeaseName, namespace, k.renewLeaseHeartBeat)
+		}
+	})
+}
+
+// AttachToSession annotation value of resource which is shared with current session
+func AttachToSession(leases string) string {
+	for _, l := range strings.Split(leases, ",") {
+		if l == sessionLeaseName {
+			return leases
+		}
+	}
+	if leases == "" {
+		return sessionLeaseName
+	}
+	return leases + "," + sessionLeaseName
+}
+
+// SessionTracking annotations tracking liveness of resource created by current session, which is the session lease,
+// or heart beat timestamp if the lease is unavailable (e.g. no permission to leases), SetupSessionLease must be called first
+func SessionTracking(annotations map[string]string) map[string]string {
+	if IsSessionLeaseCreated() {
+		return util.MapPut(annotations, util.KtLease, SessionLeaseName())
+	}
+	return util.MapPut(annotations, util.KtLastHeartBeat, util.GetTimestamp())
+}
+
+// AttachSessionTracking annotations of shared resource which should be kept alive with current session as well
+func AttachSessionTracking(annotations map[string]string) map[string]string {
+	if IsSessionLeaseCreated() {
+		return util.MapPut(annotations, util.KtLease, AttachToSession(annotations[util.KtLease]))
+	}
+	return util.MapPut(annotations, util.KtLastHeartBeat, util.GetTimestamp())
+}
+
+// GetKtLeases get all leases created by kt, indexed by lease name
+func GetKtLeases(namespace string) (map[string]coordV1.Lease, error) {
+	leases, err := Ins().GetLeasesByLabel(map[string]string{util.ControlBy: util.KubernetesToolkit}, namespace)
+	if err != nil {
+		return nil, err
+	}
+	leaseMap := make(map[string]coordV1.Lease)
+	for _, l := range leases.Items {
+		leaseMap[l.Name] = l
+	}
+	return leaseMap, nil
+}
+
+// CheckSessionLeases check leases recorded in resource annotation,
+// return whether the resource is tracked by lease, and whether any of the leases is still alive
+func CheckSessionLeases(annotations map[string]string, leases map[string]coordV1.Lease) (bool, bool) {
+	names, exists := annotations[util.KtLease]
+	if !exists {
+		return false, false
+	}
+	for _, name := range strings.Split(names, ",") {
+		if lease, ok := leases[name]; ok && !IsLeaseExpired(&lease) {
+			return true, true
+		}
+	}
+	return true, false
+}
+
+// LastRenewTime latest renew time of leases recorded in resource annotation, return -1 if none of them exists
+func LastRenewTime(annotations map[string]string, leases map[string]coordV1.Lease) int64 {
+	var last int64 = -1
+	for _, name := range strings.Split(annotations[util.KtLease], ",") {
+		if lease, ok := leases[name]; ok && lease.Spec.RenewTime != nil && lease.Spec.RenewTime.Unix() > last {
+			last = lease.Spec.RenewTime.Unix()
+		}
+	}
+	return last
+}
+
+func (k *Kubernetes) renewLeaseHeartBeat(name, namespace string) {
+	key := "lease_" + name
+	err := k.RenewLease(name, namespace)
+	if k8sErrors.IsNotFound(err) {
+		// lease removed by others (e.g. clean command), recreate it
+		_, err = k.AcquireLease(name, namespace, util.RoleSession, util.SessionLeaseDurationSec)
+	}
+	if err != nil {
+		if healthy, exists := LastHeartBeatStatus.Get(key); healthy || !exists {
+			log.Warn().Err(err).Msgf("Failed to renew lease %s", name)
+		} else {
+			log.Debug().Err(err).Msgf("Lease %s renew interrupted", name)
+		}
+		LastHeartBeatStatus.Set(key, false)
+	} else {
+		log.Debug().Msgf("Heartbeat lease %s ticked at %s", name, util.FormattedTime())
+		LastHeartBeatStatus.Set(key, true)
+	}
+}
+
+func newLease(name, namespace, role string, durationSec int32) *coordV1.Lease {
+	holder := LeaseHolder()
+	now := metav1.NewMicroTime(time.Unix(util.GetTime(), 0))
+	return &coordV1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				util.ControlBy: util.KubernetesToolkit,
+				util.KtRole:    role,
+			},
+			Annotations: map[string]string{util.KtUser: util.GetLocalUserName()},
+		},
+		Spec: coordV1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &durationSec,
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	coordV1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func leaseOf(name, holder string, renewBefore time.Duration, durationSec int32) *coordV1.Lease {
+	renewTime := metav1.NewMicroTime(time.Now().Add(-renewBefore))
+	return &coordV1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: coordV1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &durationSec,
+			RenewTime:            &renewTime,
+		},
+	}
+}
+
+func TestKubernetes_AcquireLease(t *testing.T) {
+	k := &Kubernetes{
+		Clientset: testclient.NewSimpleClientset(
+			leaseOf("held", "others", 0, 60),
+			leaseOf("expired", "others", 2*time.Minute, 60),
+		),
+	}
+	tests := []struct {
+		name  string
+		lease string
+		want  bool
+	}{
+		{name: "shouldCreateNewLease", lease: "new", want: true},
+		{name: "shouldReacquireOwnLease", lease: "new", want: true},
+		{name: "shouldNotAcquireLeaseHeldByOthers", lease: "held", want: false},
+		{name: "shouldTakeOverExpiredLease", lease: "expired", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := k.AcquireLease(tt.lease, "default", util.RoleLock, 60)
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+	lease, err := k.GetLease("expired", "default")
+	require.Nil(t, err)
+	require.Equal(t, LeaseHolder(), *lease.Spec.HolderIdentity)
+	require.NotNil(t, k.RenewLease("held", "default"))
+	require.NotNil(t, k.ReleaseLease("held", "default"))
+	require.Nil(t, k.ReleaseLease("expired", "default"))
+}
+
+func TestCheckSessionLeases(t *testing.T) {
+	leases := map[string]coordV1.Lease{
+		"alive": *leaseOf("alive", "a", 0, 60),
+		"dead":  *leaseOf("dead", "b", 2*time.Minute, 60),
+	}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantTracked bool
+		wantAlive   bool
+	}{
+		{name: "noLease", annotations: map[string]string{}, wantTracked: false, wantAlive: false},
+		{name: "aliveLease", annotations: map[string]string{util.KtLease: "alive"}, wantTracked: true, wantAlive: true},
+		{name: "expiredLease", annotations: map[string]string{util.KtLease: "dead"}, wantTracked: true, wantAlive: false},
+		{name: "missingLease", annotations: map[string]string{util.KtLease: "gone"}, wantTracked: true, wantAlive: false},
+		{name: "anyLeaseAlive", annotations: map[string]string{util.KtLease: "dead,alive"}, wantTracked: true, wantAlive: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracked, alive := CheckSessionLeases(tt.annotations, leases)
+			require.Equal(t, tt.wantTracked, tracked)
+			require.Equal(t, tt.wantAlive, alive)
+		})
+	}
+}
+
+func TestAttachToSession(t *testing.T) {
+	require.Equal(t, SessionLeaseName(), AttachToSession(""))
+	require.Equal(t, "other,"+SessionLeaseName(), AttachToSession("other"))
+	require.Equal(t, "other,"+SessionLeaseName(), AttachToSession("other,"+SessionLeaseName()))
+}
+
+func TestSessionTracking(t *testing.T) {
+	sessionLeaseCreated = false
+	annotations := SessionTracking(nil)
+	require.NotContains(t, annotations, util.KtLease)
+	require.Contains(t, annotations, util.KtLastHeartBeat)
+	sessionLeaseCreated = true
+	defer func() { sessionLeaseCreated = false }()
+	require.Equal(t, SessionLeaseName(), SessionTracking(nil)[util.KtLease])
+	require.Equal(t, "other,"+SessionLeaseName(), AttachSessionTracking(map[string]string{util.KtLease: "other"})[util.KtLease])
+}
@@ -72,22 +72,6 @@ func (k *Kubernetes) WaitPodTerminate(name, namespace string) (*coreV1.Pod, erro
 	return k.waitPodTerminate(name, namespace, 0)
 }
 
-func (k *Kubernetes) UpdatePodHeartBeat(name, namespace string) {
-	key := "pod_" + name
-	if _, err := k.Clientset.CoreV1().Pods(namespace).
-		Patch(context.TODO(), name, types.JSONPatchType, []byte(resourceHeartbeatPatch()), metav1.PatchOptions{}); err != nil {
-		if healthy, exists := LastHeartBeatStatus.Get(key); healthy || !exists {
-			log.Warn().Err(err).Msgf("Failed to update heart beat of pod %s", name)
-		} else {
-			log.Debug().Err(err).Msgf("Pod %s heart beat interrupted", name)
-		}
-		LastHeartBeatStatus.Set(key, false)
-	} else {
-		log.Debug().Msgf("Heartbeat pod %s ticked at %s", name, util.FormattedTime())
-		LastHeartBeatStatus.Set(key, true)
-	}
-}
-
 // WatchPod ...
 func (k *Kubernetes) WatchPod(name, namespace string, fAdd, fDel, fMod func(*coreV1.Pod)) {
 	k.watchResource(name, namespace, string(coreV1.ResourcePods), &coreV1.Pod{},
@@ -145,8 +129,18 @@ func (k *Kubernetes) IncreasePodRef(name string, namespace string) error {
 		return err
 	}
 
+	k.SetupSessionLease(namespace)
 	pod.Annotations[util.KtRefCount] = strconv.Itoa(count + 1)
-	_, err = k.UpdatePod(pod)
+	pod.Annotations = AttachSessionTracking(pod.Annotations)
+	if _, err = k.UpdatePod(pod); err != nil {
+		return err
+	}
+	setupResourceHeartBeat("pod", name, namespace, k.patchPod)
+	return nil
+}
+
+func (k *Kubernetes) patchPod(name, namespace string, patch []byte) error {
+	_, err := k.Clientset.CoreV1().Pods(namespace).Patch(context.TODO(), name, types.JSONPatchType, patch, metav1.PatchOptions{})
 	return err
 }
 
@@ -0,0 +1,28 @@
+package cluster
+
+import (
+	"context"
+	authV1 "k8s.io/api/authentication/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetSecret get secret
+func (k *Kubernetes) GetSecret(name, namespace string) (*coreV1.Secret, error) {
+	return k.Clientset.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// CreateServiceAccountToken request a bound token of service account, as projected into pod by kubelet
+func (k *Kubernetes) CreateServiceAccountToken(name, namespace string, audiences []string, expirationSec *int64) (string, error) {
+	tokenRequest, err := k.Clientset.CoreV1().ServiceAccounts(namespace).CreateToken(context.TODO(), name,
+		&authV1.TokenRequest{
+			Spec: authV1.TokenRequestSpec{
+				Audiences:         audiences,
+				ExpirationSeconds: expirationSec,
+			},
+		}, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+	return tokenRequest.Status.Token, nil
+}
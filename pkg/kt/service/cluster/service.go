@@ -55,9 +55,14 @@ func (k *Kubernetes) GetAllServiceInNamespace(namespace string) (*coreV1.Service
 
 // CreateService create kubernetes service
 func (k *Kubernetes) CreateService(metaAndSpec *SvcMetaAndSpec) (*coreV1.Service, error) {
-	SetupHeartBeat(metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace, k.UpdateServiceHeartBeat)
-	return k.Clientset.CoreV1().Services(metaAndSpec.Meta.Namespace).
+	k.SetupSessionLease(metaAndSpec.Meta.Namespace)
+	recordCreate("service", metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace)
+	svc, err := k.Clientset.CoreV1().Services(metaAndSpec.Meta.Namespace).
 		Create(context.TODO(), createService(metaAndSpec), metav1.CreateOptions{})
+	if err == nil {
+		setupResourceHeartBeat("service", metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace, k.patchService)
+	}
+	return svc, err
 }
 
 // UpdateService ...
@@ -73,20 +78,24 @@ func (k *Kubernetes) RemoveService(name, namespace string) (err error) {
 	})
 }
 
-func (k *Kubernetes) UpdateServiceHeartBeat(name, namespace string) {
-	key := "service_" + name
-	if _, err := k.Clientset.CoreV1().Services(namespace).
-		Patch(context.TODO(), name, types.JSONPatchType, []byte(resourceHeartbeatPatch()), metav1.PatchOptions{}); err != nil {
-		if healthy, exists := LastHeartBeatStatus.Get(key); healthy || !exists {
-			log.Warn().Err(err).Msgf("Failed to update heart beat of service %s", name)
-		} else {
-			log.Debug().Err(err).Msgf("Service %s heart beat interrupted", name)
-		}
-		LastHeartBeatStatus.Set(key, false)
-	} else {
-		log.Debug().Msgf("Heartbeat service %s ticked at %s", name, util.FormattedTime())
-		LastHeartBeatStatus.Set(key, true)
+// AttachServiceToSession keep the shared service alive with current session
+func (k *Kubernetes) AttachServiceToSession(name, namespace string) error {
+	k.SetupSessionLease(namespace)
+	svc, err := k.GetService(name, namespace)
+	if err != nil {
+		return err
 	}
+	svc.Annotations = AttachSessionTracking(svc.Annotations)
+	if _, err = k.UpdateService(svc); err != nil {
+		return err
+	}
+	setupResourceHeartBeat("service", name, namespace, k.patchService)
+	return nil
+}
+
+func (k *Kubernetes) patchService(name, namespace string, patch []byte) error {
+	_, err := k.Clientset.CoreV1().Services(namespace).Patch(context.TODO(), name, types.JSONPatchType, patch, metav1.PatchOptions{})
+	return err
 }
 
 // WatchService ...
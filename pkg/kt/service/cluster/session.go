@@ -0,0 +1,242 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"time"
+)
+
+// KtSessionGVR group version resource of KtSession
+var KtSessionGVR = schema.GroupVersionResource{
+	Group:    util.KtSessionGroup,
+	Version:  util.KtSessionVersion,
+	Resource: util.KtSessionResource,
+}
+
+// KtSession record of cluster changes made by one ktctl process
+type KtSession struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              KtSessionSpec   `json:"spec"`
+	Status            KtSessionStatus `json:"status,omitempty"`
+}
+
+// KtSessionSpec desired changes of the session
+type KtSessionSpec struct {
+	// Component exchange, mesh or preview
+	Component string `json:"component"`
+	// Mode mode of the component, e.g. selector, scale or auto
+	Mode string `json:"mode,omitempty"`
+	// User local user name of ktctl
+	User string `json:"user,omitempty"`
+	// Lease name of session lease, the session expires together with it
+	Lease string `json:"lease"`
+	// Services services whose selector should be changed
+	Services []ServiceChange `json:"services,omitempty"`
+	// Deployments deployments whose replicas should be changed
+	Deployments []DeploymentChange `json:"deployments,omitempty"`
+}
+
+// ServiceChange selector change of a service
+type ServiceChange struct {
+	Name             string            `json:"name"`
+	Selector         map[string]string `json:"selector"`
+	OriginalSelector map[string]string `json:"originalSelector"`
+}
+
+// DeploymentChange replicas change of a deployment
+type DeploymentChange struct {
+	Name             string `json:"name"`
+	Replicas         int32  `json:"replicas"`
+	OriginalReplicas int32  `json:"originalReplicas"`
+}
+
+// KtSessionStatus reconcile result of the session
+type KtSessionStatus struct {
+	Phase              string `json:"phase,omitempty"`
+	Message            string `json:"message,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+}
+
+// IsApplied check whether the latest spec has been applied by controller
+func (s *KtSession) IsApplied() bool {
+	return s.Status.Phase == util.SessionPhaseApplied && s.Status.ObservedGeneration >= s.Generation
+}
+
+// GetKtSession get KtSession
+func (k *Kubernetes) GetKtSession(name, namespace string) (*KtSession, error) {
+	obj, err := k.DynamicClient.Resource(KtSessionGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromUnstructured(obj)
+}
+
+// GetKtSessions get all KtSessions in namespace, empty namespace for all namespaces
+func (k *Kubernetes) GetKtSessions(namespace string) ([]KtSession, error) {
+	list, err := k.DynamicClient.Resource(KtSessionGVR).Namespace(namespace).List(context.TODO(), metav1.ListOptions{
+		TimeoutSeconds: &apiTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var sessions []KtSession
+	for i := range list.Items {
+		session, err2 := fromUnstructured(&list.Items[i])
+		if err2 != nil {
+			return nil, err2
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, nil
+}
+
+// CreateKtSession create KtSession
+func (k *Kubernetes) CreateKtSession(session *KtSession) (*KtSession, error) {
+	obj, err := toUnstructured(session)
+	if err != nil {
+		return nil, err
+	}
+	obj, err = k.DynamicClient.Resource(KtSessionGVR).Namespace(session.Namespace).Create(context.TODO(), obj, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromUnstructured(obj)
+}
+
+// UpdateKtSession update metadata and spec of KtSession
+func (k *Kubernetes) UpdateKtSession(session *KtSession) (*KtSession, error) {
+	obj, err := toUnstructured(session)
+	if err != nil {
+		return nil, err
+	}
+	obj, err = k.DynamicClient.Resource(KtSessionGVR).Namespace(session.Namespace).Update(context.TODO(), obj, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromUnstructured(obj)
+}
+
+// UpdateKtSessionStatus update status of KtSession
+func (k *Kubernetes) UpdateKtSessionStatus(session *KtSession) (*KtSession, error) {
+	obj, err := toUnstructured(session)
+	if err != nil {
+		return nil, err
+	}
+	obj, err = k.DynamicClient.Resource(KtSessionGVR).Namespace(session.Namespace).UpdateStatus(context.TODO(), obj, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromUnstructured(obj)
+}
+
+// RemoveKtSession remove KtSession
+func (k *Kubernetes) RemoveKtSession(name, namespace string) error {
+	return k.DynamicClient.Resource(KtSessionGVR).Namespace(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
+// RecordSessionChange create or update KtSession of current process, and wait for controller applying it
+func (k *Kubernetes) RecordSessionChange(namespace, component, mode string, change func(spec *KtSessionSpec)) error {
+	k.SetupSessionLease(namespace)
+	name := SessionLeaseName()
+	session, err := k.GetKtSession(name, namespace)
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return err
+		}
+		session = newKtSession(name, namespace, component, mode)
+		change(&session.Spec)
+		if session, err = k.CreateKtSession(session); err != nil {
+			return err
+		}
+		log.Info().Msgf("KtSession %s created", name)
+	} else {
+		change(&session.Spec)
+		if session, err = k.UpdateKtSession(session); err != nil {
+			return err
+		}
+		log.Info().Msgf("KtSession %s updated", name)
+	}
+	return k.WaitKtSessionApplied(name, namespace, util.SessionApplyTimeoutSec)
+}
+
+// WaitKtSessionApplied wait for controller applying the latest spec of KtSession
+func (k *Kubernetes) WaitKtSessionApplied(name, namespace string, timeoutSec int) error {
+	for i := 0; i < timeoutSec; i++ {
+		session, err := k.GetKtSession(name, namespace)
+		if err != nil {
+			return err
+		}
+		if session.IsApplied() {
+			return nil
+		} else if session.Status.Phase == util.SessionPhaseFailed && session.Status.ObservedGeneration >= session.Generation {
+			return fmt.Errorf("failed to apply KtSession %s: %s", name, session.Status.Message)
+		}
+		log.Debug().Msgf("Waiting for KtSession %s applied ...", name)
+		time.Sleep(1 * time.Second)
+	}
+	return fmt.Errorf("KtSession %s not applied after %d seconds, please check whether kt controller is running", name, timeoutSec)
+}
+
+// WaitKtSessionRemoved wait for controller recovering cluster and removing finalizer of KtSession
+func (k *Kubernetes) WaitKtSessionRemoved(name, namespace string, timeoutSec int) error {
+	for i := 0; i < timeoutSec; i++ {
+		if _, err := k.GetKtSession(name, namespace); k8sErrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		log.Debug().Msgf("Waiting for KtSession %s removed ...", name)
+		time.Sleep(1 * time.Second)
+	}
+	return fmt.Errorf("KtSession %s not removed after %d seconds", name, timeoutSec)
+}
+
+func newKtSession(name, namespace, component, mode string) *KtSession {
+	return &KtSession{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: KtSessionGVR.GroupVersion().String(),
+			Kind:       util.KtSessionKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				util.ControlBy: util.KubernetesToolkit,
+			},
+			Annotations: map[string]string{util.KtUser: util.GetLocalUserName()},
+		},
+		Spec: KtSessionSpec{
+			Component: component,
+			Mode:      mode,
+			User:      util.GetLocalUserName(),
+			Lease:     name,
+		},
+	}
+}
+
+func toUnstructured(session *KtSession) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(session)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetAPIVersion(KtSessionGVR.GroupVersion().String())
+	obj.SetKind(util.KtSessionKind)
+	return obj, nil
+}
+
+func fromUnstructured(obj *unstructured.Unstructured) (*KtSession, error) {
+	session := &KtSession{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
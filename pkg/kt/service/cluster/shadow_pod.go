@@ -3,6 +3,7 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"github.com/alibaba/kt-connect/pkg/common"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
@@ -10,9 +11,35 @@ import (
 	coreV1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"strconv"
 	"strings"
 )
 
+// ShadowSshPort ssh port listened by shadow pod
+func ShadowSshPort() int {
+	if opt.Get().Global.RestrictedShadow {
+		return common.NonRootSshPort
+	}
+	return common.StandardSshPort
+}
+
+// ShadowTunnelPort port of shadow pod which ktctl setup tunnel with
+func ShadowTunnelPort() int {
+	if opt.Get().Global.Transport == util.TransportMux {
+		return common.StandardMuxPort
+	}
+	return ShadowSshPort()
+}
+
+// ShadowDnsPort dns port listened by shadow pod
+func ShadowDnsPort() int {
+	if opt.Get().Global.RestrictedShadow {
+		return common.NonRootDnsPort
+	}
+	return common.StandardDnsPort
+}
+
 // GetOrCreateShadow create shadow pod or deployment
 func (k *Kubernetes) GetOrCreateShadow(name string, labels, annotations, envs map[string]string, exposePorts string, portNameDict map[int]string) (
 	string, string, string, error) {
@@ -27,6 +54,10 @@ func (k *Kubernetes) GetOrCreateShadow(name string, labels, annotations, envs ma
 		annotations[key] = val
 	}
 	annotations[util.KtUser] = util.GetLocalUserName()
+	if opt.Get().Global.RestrictedShadow {
+		envs = util.MapPut(envs, common.EnvVarDnsPort, strconv.Itoa(common.NonRootDnsPort))
+		envs = util.MapPut(envs, common.EnvVarSshPort, strconv.Itoa(common.NonRootSshPort))
+	}
 	resourceMeta := ResourceMeta{
 		Name:        name,
 		Namespace:   opt.Get().Global.Namespace,
@@ -39,6 +70,7 @@ func (k *Kubernetes) GetOrCreateShadow(name string, labels, annotations, envs ma
 	}
 
 	ports := map[string]int{}
+	var remotePorts []string
 	if exposePorts != "" {
 		portPairs := strings.Split(exposePorts, ",")
 		for _, exposePort := range portPairs {
@@ -52,9 +84,14 @@ func (k *Kubernetes) GetOrCreateShadow(name string, labels, annotations, envs ma
 					name = n
 				}
 				ports[name] = port
+				remotePorts = append(remotePorts, strconv.Itoa(port))
 			}
 		}
 	}
+	if len(remotePorts) > 0 {
+		// shadow pod checks these ports via reverse tunnel for readiness
+		envs = util.MapPut(envs, common.EnvVarExposePorts, strings.Join(remotePorts, ","))
+	}
 
 	if opt.Store.Component == util.ComponentConnect && opt.Get().Connect.ShareShadow {
 		pod, generator, err2 := k.tryGetExistingShadows(&resourceMeta, &sshKeyMeta)
@@ -129,33 +166,49 @@ func filterRunningPods(pods []coreV1.Pod) []coreV1.Pod {
 
 // createShadowDeployment create shadow deployment
 func (k *Kubernetes) createShadowDeployment(metaAndSpec *PodMetaAndSpec, sshcm string) error {
+	k.SetupSessionLease(metaAndSpec.Meta.Namespace)
 	deployment := createDeployment(metaAndSpec)
 	k.appendSshVolume(&deployment.Spec.Template.Spec, sshcm)
+	appendReadinessProbe(&deployment.Spec.Template.Spec, metaAndSpec.Envs)
+	if opt.Get().Global.RestrictedShadow {
+		applyRestrictedSecurityContext(&deployment.Spec.Template.Spec)
+	}
+	recordCreate("deployment", metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace)
 	if _, err := k.Clientset.AppsV1().Deployments(metaAndSpec.Meta.Namespace).
 		Create(context.TODO(), deployment, metav1.CreateOptions{}); err != nil {
 		return err
 	}
-	SetupHeartBeat(metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace, k.UpdateDeploymentHeartBeat)
+	setupResourceHeartBeat("deployment", metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace, k.patchDeployment)
 	return nil
 }
 
 // createShadowPod create shadow pod
 func (k *Kubernetes) createShadowPod(metaAndSpec *PodMetaAndSpec, sshcm string) error {
+	k.SetupSessionLease(metaAndSpec.Meta.Namespace)
 	pod := createPod(metaAndSpec)
 	k.appendSshVolume(&pod.Spec, sshcm)
+	appendReadinessProbe(&pod.Spec, metaAndSpec.Envs)
+	if opt.Get().Global.RestrictedShadow {
+		applyRestrictedSecurityContext(&pod.Spec)
+	}
+	recordCreate("pod", metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace)
 	if _, err := k.Clientset.CoreV1().Pods(metaAndSpec.Meta.Namespace).
 		Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
 		return err
 	}
-	SetupHeartBeat(metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace, k.UpdatePodHeartBeat)
+	setupResourceHeartBeat("pod", metaAndSpec.Meta.Name, metaAndSpec.Meta.Namespace, k.patchPod)
 	return nil
 }
 
 func (k *Kubernetes) appendSshVolume(podSpec *coreV1.PodSpec, sshcm string) {
+	homeDir := "/root"
+	if opt.Get().Global.RestrictedShadow {
+		homeDir = fmt.Sprintf("/home/%s", common.NonRootUser)
+	}
 	podSpec.Containers[0].VolumeMounts = []coreV1.VolumeMount{
 		{
 			Name:      "ssh-public-key",
-			MountPath: fmt.Sprintf("/root/%s", util.SshAuthKey),
+			MountPath: fmt.Sprintf("%s/%s", homeDir, util.SshAuthKey),
 		},
 	}
 	podSpec.Volumes = []coreV1.Volume{
@@ -163,6 +216,25 @@ func (k *Kubernetes) appendSshVolume(podSpec *coreV1.PodSpec, sshcm string) {
 	}
 }
 
+// appendReadinessProbe let shadow pod become not ready when local endpoints of exposed ports are unreachable
+func appendReadinessProbe(podSpec *coreV1.PodSpec, envs map[string]string) {
+	if envs[common.EnvVarExposePorts] == "" {
+		return
+	}
+	podSpec.Containers[0].ReadinessProbe = &coreV1.Probe{
+		Handler: coreV1.Handler{
+			HTTPGet: &coreV1.HTTPGetAction{
+				Path: common.ReadinessPath,
+				Port: intstr.FromInt(common.ReadinessPort),
+			},
+		},
+		// ports are checked concurrently and each check takes at most 1 second, well within the timeout
+		PeriodSeconds:    3,
+		TimeoutSeconds:   5,
+		FailureThreshold: 2,
+	}
+}
+
 func (k *Kubernetes) tryGetExistingShadows(resourceMeta *ResourceMeta, sshKeyMeta *SSHkeyMeta) (*coreV1.Pod, *util.SSHGenerator, error) {
 	var app *appV1.Deployment
 	var pod *coreV1.Pod
@@ -3,8 +3,13 @@ package cluster
 import (
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
 	appV1 "k8s.io/api/apps/v1"
+	coordV1 "k8s.io/api/coordination/v1"
 	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
 	extV1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -17,7 +22,6 @@ type KubernetesInterface interface {
 	GetOrCreateShadow(name string, labels, annotations, envs map[string]string, portsToExpose string, portNameDict map[int]string) (string, string, string, error)
 	CreateRouterPod(name string, labels, annotations map[string]string, ports map[int]int) (*coreV1.Pod, error)
 	CreateRectifierPod(name string) (*coreV1.Pod, error)
-	UpdatePodHeartBeat(name, namespace string)
 	WaitPodReady(name, namespace string, timeoutSec int) (*coreV1.Pod, error)
 	WaitPodTerminate(name, namespace string) (*coreV1.Pod, error)
 	WatchPod(name, namespace string, fAdd, fDel, fMod func(*coreV1.Pod))
@@ -43,13 +47,53 @@ type KubernetesInterface interface {
 	CreateService(metaAndSpec *SvcMetaAndSpec) (*coreV1.Service, error)
 	UpdateService(svc *coreV1.Service) (*coreV1.Service, error)
 	RemoveService(name, namespace string) (err error)
-	UpdateServiceHeartBeat(name, namespace string)
+	AttachServiceToSession(name, namespace string) error
 	WatchService(name, namespace string, fAdd, fDel, fMod func(*coreV1.Service))
 
+	GetEndpoints(name, namespace string) (*coreV1.Endpoints, error)
+	GetAllEndpointsInNamespace(namespace string) ([]coreV1.Endpoints, error)
+	UpdateEndpoints(endpoints *coreV1.Endpoints) (*coreV1.Endpoints, error)
+	GetEndpointSlicesOfService(svcName, namespace string) ([]discoveryV1.EndpointSlice, error)
+	GetAllEndpointSlicesInNamespace(namespace string) ([]discoveryV1.EndpointSlice, error)
+	CreateEndpointSlice(slice *discoveryV1.EndpointSlice) (*discoveryV1.EndpointSlice, error)
+	UpdateEndpointSlice(slice *discoveryV1.EndpointSlice) (*discoveryV1.EndpointSlice, error)
+	RemoveEndpointSlice(name, namespace string) error
+
 	GetConfigMap(name, namespace string) (*coreV1.ConfigMap, error)
 	GetConfigMapsByLabel(labels map[string]string, namespace string) (*coreV1.ConfigMapList, error)
+	CreateConfigMap(name, namespace string, labels, annotations, data map[string]string) (*coreV1.ConfigMap, error)
+	UpdateConfigMap(configMap *coreV1.ConfigMap) (*coreV1.ConfigMap, error)
 	RemoveConfigMap(name, namespace string) (err error)
-	UpdateConfigMapHeartBeat(name, namespace string)
+
+	GetSecret(name, namespace string) (*coreV1.Secret, error)
+	CreateServiceAccountToken(name, namespace string, audiences []string, expirationSec *int64) (string, error)
+
+	GetLease(name, namespace string) (*coordV1.Lease, error)
+	GetLeasesByLabel(labels map[string]string, namespace string) (*coordV1.LeaseList, error)
+	RemoveLease(name, namespace string) error
+	AcquireLease(name, namespace, role string, durationSec int32) (bool, error)
+	RenewLease(name, namespace string) error
+	ReleaseLease(name, namespace string) error
+	SetupSessionLease(namespace string)
+
+	CreateEvent(kind, name, namespace, reason, message string) error
+
+	GetKtSession(name, namespace string) (*KtSession, error)
+	GetKtSessions(namespace string) ([]KtSession, error)
+	CreateKtSession(session *KtSession) (*KtSession, error)
+	UpdateKtSession(session *KtSession) (*KtSession, error)
+	UpdateKtSessionStatus(session *KtSession) (*KtSession, error)
+	RemoveKtSession(name, namespace string) error
+	RecordSessionChange(namespace, component, mode string, change func(spec *KtSessionSpec)) error
+	WaitKtSessionApplied(name, namespace string, timeoutSec int) error
+	WaitKtSessionRemoved(name, namespace string, timeoutSec int) error
+
+	GetCustomResource(gvr schema.GroupVersionResource, name, namespace string) (*unstructured.Unstructured, error)
+	PatchCustomResource(gvr schema.GroupVersionResource, name, namespace string, patch []byte) error
+	ListCustomResources(gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error)
+	CreateCustomResource(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error
+	UpdateCustomResource(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error
+	RemoveCustomResource(gvr schema.GroupVersionResource, name, namespace string) error
 
 	GetAllIngressInNamespace(namespace string) (*extV1.IngressList, error)
 
@@ -60,7 +104,8 @@ type KubernetesInterface interface {
 
 // Kubernetes implements KubernetesInterface
 type Kubernetes struct {
-	Clientset kubernetes.Interface
+	Clientset     kubernetes.Interface
+	DynamicClient dynamic.Interface
 }
 
 // Cli the singleton type
@@ -68,9 +113,13 @@ var instance *Kubernetes
 
 // Ins get singleton instance
 func Ins() KubernetesInterface {
+	if dryRunInstance != nil {
+		return dryRunInstance
+	}
 	if instance == nil {
 		instance = &Kubernetes{
-			Clientset: opt.Store.Clientset,
+			Clientset:     opt.Store.Clientset,
+			DynamicClient: opt.Store.DynamicClient,
 		}
 	}
 	return instance
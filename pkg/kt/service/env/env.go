@@ -0,0 +1,471 @@
+package env
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// serviceAccountMountPath where kubelet mounts service account token by default
+	serviceAccountMountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// rootCaConfigMap configmap holding cluster ca, published to every namespace
+	rootCaConfigMap = "kube-root-ca.crt"
+	// localPodIp ip address of pod when running at local
+	localPodIp = "127.0.0.1"
+)
+
+var fieldRefPattern = regexp.MustCompile(`^metadata\.(labels|annotations)\['(.+)'\]$`)
+
+// Environment environment variables and mounted files of a container
+type Environment struct {
+	// Envs resolved environment variables, in the order of definition
+	Envs []coreV1.EnvVar
+	// Files content of mounted files, indexed by absolute path in container
+	Files map[string][]byte
+}
+
+// Resolve fetch environment variables and mounted files of container from pod spec
+func Resolve(meta *metav1.ObjectMeta, spec *coreV1.PodSpec, containerName string) (*Environment, error) {
+	container, err := getContainer(spec, containerName)
+	if err != nil {
+		return nil, err
+	}
+	env := &Environment{Files: map[string][]byte{}}
+	r := &resolver{meta: meta, spec: spec, container: container, values: map[string]string{}}
+	if err = r.resolveEnvFrom(env); err != nil {
+		return nil, err
+	}
+	if err = r.resolveEnv(env); err != nil {
+		return nil, err
+	}
+	if err = r.resolveVolumes(env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Export environment variables in <key>=<value> format
+func (e *Environment) Export() []string {
+	var envs []string
+	for _, v := range e.Envs {
+		envs = append(envs, fmt.Sprintf("%s=%s", v.Name, v.Value))
+	}
+	return envs
+}
+
+// Mirror write mounted files to local directory, with container path as relative path
+func (e *Environment) Mirror(root string) error {
+	for _, p := range e.sortedPaths() {
+		localPath := filepath.Join(root, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(localPath, e.Files[p], 0600); err != nil {
+			return err
+		}
+		_ = util.FixFileOwner(localPath)
+	}
+	return nil
+}
+
+func (e *Environment) sortedPaths() []string {
+	var paths []string
+	for p := range e.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+type resolver struct {
+	meta      *metav1.ObjectMeta
+	spec      *coreV1.PodSpec
+	container *coreV1.Container
+	// values resolved variables, for $(VAR) reference expanding
+	values map[string]string
+}
+
+func (r *resolver) setEnv(env *Environment, name, value string) {
+	if _, exists := r.values[name]; exists {
+		for i := range env.Envs {
+			if env.Envs[i].Name == name {
+				env.Envs[i].Value = value
+			}
+		}
+	} else {
+		env.Envs = append(env.Envs, coreV1.EnvVar{Name: name, Value: value})
+	}
+	r.values[name] = value
+}
+
+func (r *resolver) resolveEnvFrom(env *Environment) error {
+	for _, source := range r.container.EnvFrom {
+		var data map[string]string
+		if source.ConfigMapRef != nil {
+			cm, err := cluster.Ins().GetConfigMap(source.ConfigMapRef.Name, r.meta.Namespace)
+			if err != nil {
+				if k8sErrors.IsNotFound(err) && isOptional(source.ConfigMapRef.Optional) {
+					continue
+				}
+				return fmt.Errorf("failed to read configmap %s: %s", source.ConfigMapRef.Name, err)
+			}
+			data = cm.Data
+		} else if source.SecretRef != nil {
+			secret, err := cluster.Ins().GetSecret(source.SecretRef.Name, r.meta.Namespace)
+			if err != nil {
+				if k8sErrors.IsNotFound(err) && isOptional(source.SecretRef.Optional) {
+					continue
+				}
+				return fmt.Errorf("failed to read secret %s: %s", source.SecretRef.Name, err)
+			}
+			data = toStringMap(secret.Data)
+		}
+		for _, key := range sortedKeys(data) {
+			r.setEnv(env, source.Prefix+key, data[key])
+		}
+	}
+	return nil
+}
+
+func (r *resolver) resolveEnv(env *Environment) error {
+	for _, v := range r.container.Env {
+		if v.ValueFrom == nil {
+			r.setEnv(env, v.Name, expand(v.Value, r.values))
+			continue
+		}
+		value, found, err := r.resolveValueFrom(v.ValueFrom)
+		if err != nil {
+			return fmt.Errorf("failed to resolve env %s: %s", v.Name, err)
+		} else if found {
+			r.setEnv(env, v.Name, value)
+		}
+	}
+	return nil
+}
+
+func (r *resolver) resolveValueFrom(source *coreV1.EnvVarSource) (string, bool, error) {
+	if source.ConfigMapKeyRef != nil {
+		cm, err := cluster.Ins().GetConfigMap(source.ConfigMapKeyRef.Name, r.meta.Namespace)
+		if err != nil {
+			return "", false, ignoreOptional(err, source.ConfigMapKeyRef.Optional)
+		}
+		value, exists := cm.Data[source.ConfigMapKeyRef.Key]
+		if !exists && !isOptional(source.ConfigMapKeyRef.Optional) {
+			return "", false, fmt.Errorf("key %s not found in configmap %s", source.ConfigMapKeyRef.Key, cm.Name)
+		}
+		return value, exists, nil
+	} else if source.SecretKeyRef != nil {
+		secret, err := cluster.Ins().GetSecret(source.SecretKeyRef.Name, r.meta.Namespace)
+		if err != nil {
+			return "", false, ignoreOptional(err, source.SecretKeyRef.Optional)
+		}
+		value, exists := secret.Data[source.SecretKeyRef.Key]
+		if !exists && !isOptional(source.SecretKeyRef.Optional) {
+			return "", false, fmt.Errorf("key %s not found in secret %s", source.SecretKeyRef.Key, secret.Name)
+		}
+		return string(value), exists, nil
+	} else if source.FieldRef != nil {
+		return r.resolveFieldRef(source.FieldRef.FieldPath)
+	} else if source.ResourceFieldRef != nil {
+		return resolveResourceFieldRef(r.container, source.ResourceFieldRef)
+	}
+	return "", false, nil
+}
+
+func (r *resolver) resolveFieldRef(fieldPath string) (string, bool, error) {
+	switch fieldPath {
+	case "metadata.name":
+		return r.meta.Name, true, nil
+	case "metadata.namespace":
+		return r.meta.Namespace, true, nil
+	case "metadata.uid":
+		return string(r.meta.UID), true, nil
+	case "spec.nodeName":
+		return r.spec.NodeName, true, nil
+	case "spec.serviceAccountName":
+		return r.spec.ServiceAccountName, true, nil
+	case "status.podIP", "status.podIPs", "status.hostIP", "status.hostIPs":
+		// process is running at local
+		return localPodIp, true, nil
+	}
+	if matches := fieldRefPattern.FindStringSubmatch(fieldPath); matches != nil {
+		if matches[1] == "labels" {
+			value, exists := r.meta.Labels[matches[2]]
+			return value, exists, nil
+		}
+		value, exists := r.meta.Annotations[matches[2]]
+		return value, exists, nil
+	}
+	return "", false, fmt.Errorf("unsupported field path %s", fieldPath)
+}
+
+func (r *resolver) resolveVolumes(env *Environment) error {
+	for _, mount := range r.container.VolumeMounts {
+		volume := r.getVolume(mount.Name)
+		if volume == nil {
+			return fmt.Errorf("volume %s mounted by container %s not found", mount.Name, r.container.Name)
+		}
+		files, err := r.resolveVolume(volume)
+		if err != nil {
+			return fmt.Errorf("failed to resolve volume %s: %s", mount.Name, err)
+		}
+		for p, content := range files {
+			if mount.SubPath == "" {
+				env.Files[path.Join(mount.MountPath, p)] = content
+			} else if p == mount.SubPath {
+				env.Files[mount.MountPath] = content
+			}
+		}
+	}
+	if r.needDefaultServiceAccountToken() {
+		for p, content := range r.defaultServiceAccountFiles() {
+			env.Files[path.Join(serviceAccountMountPath, p)] = content
+		}
+	}
+	return nil
+}
+
+func (r *resolver) resolveVolume(volume *coreV1.Volume) (map[string][]byte, error) {
+	if volume.ConfigMap != nil {
+		return r.configMapFiles(volume.ConfigMap.Name, volume.ConfigMap.Items, volume.ConfigMap.Optional)
+	} else if volume.Secret != nil {
+		return r.secretFiles(volume.Secret.SecretName, volume.Secret.Items, volume.Secret.Optional)
+	} else if volume.DownwardAPI != nil {
+		return r.downwardApiFiles(volume.DownwardAPI.Items)
+	} else if volume.Projected != nil {
+		files := map[string][]byte{}
+		for _, source := range volume.Projected.Sources {
+			var sourceFiles map[string][]byte
+			var err error
+			if source.ConfigMap != nil {
+				sourceFiles, err = r.configMapFiles(source.ConfigMap.Name, source.ConfigMap.Items, source.ConfigMap.Optional)
+			} else if source.Secret != nil {
+				sourceFiles, err = r.secretFiles(source.Secret.Name, source.Secret.Items, source.Secret.Optional)
+			} else if source.DownwardAPI != nil {
+				sourceFiles, err = r.downwardApiFiles(source.DownwardAPI.Items)
+			} else if source.ServiceAccountToken != nil {
+				sourceFiles = r.serviceAccountTokenFile(source.ServiceAccountToken)
+			}
+			if err != nil {
+				return nil, err
+			}
+			for p, content := range sourceFiles {
+				files[p] = content
+			}
+		}
+		return files, nil
+	}
+	log.Debug().Msgf("Volume %s is neither configmap, secret nor projected, not mirrored", volume.Name)
+	return map[string][]byte{}, nil
+}
+
+func (r *resolver) configMapFiles(name string, items []coreV1.KeyToPath, optional *bool) (map[string][]byte, error) {
+	cm, err := cluster.Ins().GetConfigMap(name, r.meta.Namespace)
+	if err != nil {
+		return map[string][]byte{}, ignoreOptional(err, optional)
+	}
+	data := map[string][]byte{}
+	for k, v := range cm.Data {
+		data[k] = []byte(v)
+	}
+	for k, v := range cm.BinaryData {
+		data[k] = v
+	}
+	return pickItems(data, items), nil
+}
+
+func (r *resolver) secretFiles(name string, items []coreV1.KeyToPath, optional *bool) (map[string][]byte, error) {
+	secret, err := cluster.Ins().GetSecret(name, r.meta.Namespace)
+	if err != nil {
+		return map[string][]byte{}, ignoreOptional(err, optional)
+	}
+	return pickItems(secret.Data, items), nil
+}
+
+func (r *resolver) downwardApiFiles(items []coreV1.DownwardAPIVolumeFile) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for _, item := range items {
+		var value string
+		var err error
+		if item.FieldRef != nil {
+			value, _, err = r.resolveFieldRef(item.FieldRef.FieldPath)
+		} else if item.ResourceFieldRef != nil {
+			value, _, err = resolveResourceFieldRef(r.container, item.ResourceFieldRef)
+		}
+		if err != nil {
+			return nil, err
+		}
+		files[item.Path] = []byte(value)
+	}
+	return files, nil
+}
+
+// serviceAccountTokenFile request token of service account, skip if no permission
+func (r *resolver) serviceAccountTokenFile(source *coreV1.ServiceAccountTokenProjection) map[string][]byte {
+	var audiences []string
+	if source.Audience != "" {
+		audiences = []string{source.Audience}
+	}
+	token, err := cluster.Ins().CreateServiceAccountToken(r.serviceAccountName(), r.meta.Namespace, audiences,
+		source.ExpirationSeconds)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to request token of service account %s, skipped", r.serviceAccountName())
+		return map[string][]byte{}
+	}
+	return map[string][]byte{source.Path: []byte(token)}
+}
+
+// defaultServiceAccountFiles files of the service account volume injected by admission controller
+func (r *resolver) defaultServiceAccountFiles() map[string][]byte {
+	files := r.serviceAccountTokenFile(&coreV1.ServiceAccountTokenProjection{Path: "token"})
+	if cm, err2 := cluster.Ins().GetConfigMap(rootCaConfigMap, r.meta.Namespace); err2 == nil {
+		files["ca.crt"] = []byte(cm.Data["ca.crt"])
+	}
+	files["namespace"] = []byte(r.meta.Namespace)
+	return files
+}
+
+// needDefaultServiceAccountToken pod template doesn't contain service account volume injected by admission controller
+func (r *resolver) needDefaultServiceAccountToken() bool {
+	if r.spec.AutomountServiceAccountToken != nil && !*r.spec.AutomountServiceAccountToken {
+		return false
+	}
+	for _, mount := range r.container.VolumeMounts {
+		if mount.MountPath == serviceAccountMountPath {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *resolver) serviceAccountName() string {
+	if r.spec.ServiceAccountName != "" {
+		return r.spec.ServiceAccountName
+	}
+	return "default"
+}
+
+func (r *resolver) getVolume(name string) *coreV1.Volume {
+	for i := range r.spec.Volumes {
+		if r.spec.Volumes[i].Name == name {
+			return &r.spec.Volumes[i]
+		}
+	}
+	return nil
+}
+
+func getContainer(spec *coreV1.PodSpec, containerName string) (*coreV1.Container, error) {
+	if len(spec.Containers) == 0 {
+		return nil, fmt.Errorf("no container found in pod spec")
+	}
+	if containerName == "" {
+		if len(spec.Containers) > 1 {
+			log.Info().Msgf("Using environment of first container '%s'", spec.Containers[0].Name)
+		}
+		return &spec.Containers[0], nil
+	}
+	for i := range spec.Containers {
+		if spec.Containers[i].Name == containerName {
+			return &spec.Containers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("container '%s' not found", containerName)
+}
+
+func resolveResourceFieldRef(container *coreV1.Container, ref *coreV1.ResourceFieldSelector) (string, bool, error) {
+	parts := strings.SplitN(ref.Resource, ".", 2)
+	if len(parts) != 2 {
+		return "", false, fmt.Errorf("invalid resource %s", ref.Resource)
+	}
+	resources := container.Resources.Requests
+	if parts[0] == "limits" {
+		resources = container.Resources.Limits
+	}
+	quantity, exists := resources[coreV1.ResourceName(parts[1])]
+	if !exists {
+		log.Debug().Msgf("Resource %s of container %s not specified", ref.Resource, container.Name)
+		return "", false, nil
+	}
+	divisor := ref.Divisor.MilliValue()
+	if divisor == 0 {
+		divisor = 1000
+	}
+	// round up as kubelet does
+	return fmt.Sprintf("%d", (quantity.MilliValue()+divisor-1)/divisor), true, nil
+}
+
+func pickItems(data map[string][]byte, items []coreV1.KeyToPath) map[string][]byte {
+	if len(items) == 0 {
+		return data
+	}
+	files := map[string][]byte{}
+	for _, item := range items {
+		if content, exists := data[item.Key]; exists {
+			files[item.Path] = content
+		}
+	}
+	return files
+}
+
+// expand replace $(VAR) references with defined variables, and $$ with $
+func expand(value string, values map[string]string) string {
+	var buf strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 >= len(value) {
+			buf.WriteByte(value[i])
+		} else if value[i+1] == '$' {
+			buf.WriteByte('$')
+			i++
+		} else if end := strings.IndexByte(value[i+1:], ')'); value[i+1] == '(' && end > 0 {
+			name := value[i+2 : i+1+end]
+			if v, exists := values[name]; exists {
+				buf.WriteString(v)
+			} else {
+				buf.WriteString(value[i : i+2+end])
+			}
+			i += 1 + end
+		} else {
+			buf.WriteByte(value[i])
+		}
+	}
+	return buf.String()
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
+
+func ignoreOptional(err error, optional *bool) error {
+	if k8sErrors.IsNotFound(err) && isOptional(optional) {
+		return nil
+	}
+	return err
+}
+
+func toStringMap(data map[string][]byte) map[string]string {
+	m := map[string]string{}
+	for k, v := range data {
+		m[k] = string(v)
+	}
+	return m
+}
+
+func sortedKeys(data map[string]string) []string {
+	var keys []string
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
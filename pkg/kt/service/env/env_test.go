@@ -0,0 +1,96 @@
+package env
+
+import (
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/stretchr/testify/require"
+	authV1 "k8s.io/api/authentication/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8sTesting "k8s.io/client-go/testing"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	clientset := testclient.NewSimpleClientset(
+		&coreV1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "default"},
+			Data: map[string]string{"LOG_LEVEL": "info", "app.yaml": "port: 8080"}},
+		&coreV1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app-secret", Namespace: "default"},
+			Data: map[string][]byte{"password": []byte("123456")}},
+		&coreV1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: rootCaConfigMap, Namespace: "default"},
+			Data: map[string]string{"ca.crt": "cert"}},
+	)
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, &authV1.TokenRequest{Status: authV1.TokenRequestStatus{Token: "token-of-app"}}, nil
+	})
+	opt.Store.Clientset = clientset
+
+	optional := true
+	meta := &metav1.ObjectMeta{Name: "tomcat-abc", Namespace: "default", Labels: map[string]string{"app": "tomcat"}}
+	spec := &coreV1.PodSpec{
+		ServiceAccountName: "app",
+		Containers: []coreV1.Container{{
+			Name: "tomcat",
+			EnvFrom: []coreV1.EnvFromSource{
+				{ConfigMapRef: &coreV1.ConfigMapEnvSource{LocalObjectReference: coreV1.LocalObjectReference{Name: "app-config"}}},
+				{Prefix: "OPT_", SecretRef: &coreV1.SecretEnvSource{LocalObjectReference: coreV1.LocalObjectReference{Name: "none"},
+					Optional: &optional}},
+			},
+			Env: []coreV1.EnvVar{
+				{Name: "LOG_LEVEL", Value: "debug"},
+				{Name: "DB_PASSWORD", ValueFrom: &coreV1.EnvVarSource{SecretKeyRef: &coreV1.SecretKeySelector{
+					LocalObjectReference: coreV1.LocalObjectReference{Name: "app-secret"}, Key: "password"}}},
+				{Name: "POD_IP", ValueFrom: &coreV1.EnvVarSource{FieldRef: &coreV1.ObjectFieldSelector{FieldPath: "status.podIP"}}},
+				{Name: "APP", ValueFrom: &coreV1.EnvVarSource{FieldRef: &coreV1.ObjectFieldSelector{
+					FieldPath: "metadata.labels['app']"}}},
+				{Name: "MEMORY", ValueFrom: &coreV1.EnvVarSource{ResourceFieldRef: &coreV1.ResourceFieldSelector{
+					Resource: "limits.memory", Divisor: resource.MustParse("1Mi")}}},
+				{Name: "URL", Value: "http://$(POD_IP):8080/$(UNKNOWN)?a=$$(POD_IP)"},
+			},
+			Resources: coreV1.ResourceRequirements{Limits: coreV1.ResourceList{
+				coreV1.ResourceMemory: resource.MustParse("512Mi")}},
+			VolumeMounts: []coreV1.VolumeMount{
+				{Name: "config", MountPath: "/etc/app"},
+				{Name: "secret", MountPath: "/etc/secret/db.pwd", SubPath: "password"},
+			},
+		}},
+		Volumes: []coreV1.Volume{
+			{Name: "config", VolumeSource: coreV1.VolumeSource{ConfigMap: &coreV1.ConfigMapVolumeSource{
+				LocalObjectReference: coreV1.LocalObjectReference{Name: "app-config"},
+				Items:                []coreV1.KeyToPath{{Key: "app.yaml", Path: "conf/app.yaml"}}}}},
+			{Name: "secret", VolumeSource: coreV1.VolumeSource{Secret: &coreV1.SecretVolumeSource{SecretName: "app-secret"}}},
+		},
+	}
+
+	env, err := Resolve(meta, spec, "")
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		"LOG_LEVEL=debug",
+		"app.yaml=port: 8080",
+		"DB_PASSWORD=123456",
+		"POD_IP=127.0.0.1",
+		"APP=tomcat",
+		"MEMORY=512",
+		"URL=http://127.0.0.1:8080/$(UNKNOWN)?a=$(POD_IP)",
+	}, env.Export())
+	require.Equal(t, map[string][]byte{
+		"/etc/app/conf/app.yaml":               []byte("port: 8080"),
+		"/etc/secret/db.pwd":                   []byte("123456"),
+		serviceAccountMountPath + "/token":     []byte("token-of-app"),
+		serviceAccountMountPath + "/ca.crt":    []byte("cert"),
+		serviceAccountMountPath + "/namespace": []byte("default"),
+	}, env.Files)
+
+	root := t.TempDir()
+	require.Nil(t, env.Mirror(root))
+	content, err := os.ReadFile(filepath.Join(root, "etc", "app", "conf", "app.yaml"))
+	require.Nil(t, err)
+	require.Equal(t, "port: 8080", string(content))
+
+	_, err = Resolve(meta, spec, "nginx")
+	require.NotNil(t, err)
+}
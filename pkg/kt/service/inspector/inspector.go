@@ -0,0 +1,350 @@
+package inspector
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/service/recorder"
+	"github.com/rs/zerolog/log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+//go:embed index.html
+var indexPage []byte
+
+// maxRecords earlier requests are dropped when exceeded
+const maxRecords = 500
+
+// TokenHeader header carrying access token of inspector api, token is also accepted via query parameter
+const TokenHeader = "X-Kt-Token"
+
+// Inspector keep recent requests passing through reverse tunnels, and serve them via web ui
+type Inspector struct {
+	records []*record
+	nextId  int
+	lock    sync.RWMutex
+	client  *http.Client
+	// token per-run secret required by api, so that other web pages could not read or resend traffic
+	token string
+	// loopbackOnly reject requests not addressed to loopback host, which protects from dns rebinding
+	loopbackOnly bool
+}
+
+type record struct {
+	Id int
+	*recorder.Entry
+}
+
+// Summary brief of a request shown in list
+type Summary struct {
+	Id       int       `json:"id"`
+	Time     time.Time `json:"time"`
+	Endpoint string    `json:"endpoint"`
+	Method   string    `json:"method"`
+	Uri      string    `json:"uri"`
+	Host     string    `json:"host"`
+	Status   int       `json:"status"`
+	Duration int64     `json:"duration"`
+}
+
+// Message request or response with readable body
+type Message struct {
+	Method string      `json:"method,omitempty"`
+	Uri    string      `json:"uri,omitempty"`
+	Host   string      `json:"host,omitempty"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+	Size   int         `json:"size"`
+	Binary bool        `json:"binary"`
+}
+
+// Detail full content of a request and its response
+type Detail struct {
+	Summary
+	Request  Message  `json:"request"`
+	Response *Message `json:"response,omitempty"`
+}
+
+// ResendRequest request edited in web ui to send again
+type ResendRequest struct {
+	Endpoint string      `json:"endpoint"`
+	Method   string      `json:"method"`
+	Uri      string      `json:"uri"`
+	Host     string      `json:"host"`
+	Header   http.Header `json:"header"`
+	Body     string      `json:"body"`
+}
+
+// ResendResult response of resent request
+type ResendResult struct {
+	Duration int64   `json:"duration"`
+	Response Message `json:"response"`
+}
+
+// Start serve inspector web ui on specified address, and feed it with traffic of reverse tunnels
+func Start(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid inspect address '%s': %s", address, err)
+	}
+	if host == "" {
+		// traffic may contain credentials, do not expose it to network unless explicitly specified
+		host = "127.0.0.1"
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return fmt.Errorf("failed to listen inspect address %s: %s", address, err)
+	}
+	ins := New()
+	ins.loopbackOnly = isLoopbackHost(host)
+	recorder.AddSink(ins)
+	go func() {
+		if err2 := http.Serve(listener, ins.Handler()); err2 != nil {
+			log.Debug().Err(err2).Msgf("Request inspector stopped")
+		}
+	}()
+	log.Info().Msgf("Request inspector available at http://%s/?token=%s", listener.Addr(), ins.token)
+	return nil
+}
+
+// New create an inspector
+func New() *Inspector {
+	return &Inspector{
+		token: newToken(),
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Put keep the entry, implements recorder.Sink
+func (i *Inspector) Put(entry *recorder.Entry) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.nextId++
+	i.records = append(i.records, &record{Id: i.nextId, Entry: entry})
+	if len(i.records) > maxRecords {
+		i.records = i.records[len(i.records)-maxRecords:]
+	}
+}
+
+// Handler http handler of web ui and its api
+func (i *Inspector) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(indexPage)
+	})
+	mux.HandleFunc("/api/requests", i.handleList)
+	mux.HandleFunc("/api/requests/", i.handleDetail)
+	mux.HandleFunc("/api/resend", i.handleResend)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := i.checkAccess(r); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// checkAccess only loopback host and same origin are allowed, and api requires the per-run token
+func (i *Inspector) checkAccess(r *http.Request) error {
+	if i.loopbackOnly && !isLoopbackHost(hostOf(r.Host)) {
+		return fmt.Errorf("host '%s' is not allowed", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			return fmt.Errorf("origin '%s' is not allowed", origin)
+		}
+	}
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		return nil
+	}
+	token := r.Header.Get(TokenHeader)
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(i.token)) != 1 {
+		return fmt.Errorf("invalid token, please open the url printed by ktctl")
+	}
+	return nil
+}
+
+// handleList list requests newest first, filtered by method, status prefix and keyword of host or uri
+func (i *Inspector) handleList(w http.ResponseWriter, r *http.Request) {
+	method := strings.ToUpper(r.URL.Query().Get("method"))
+	status := r.URL.Query().Get("status")
+	keyword := r.URL.Query().Get("q")
+	summaries := make([]Summary, 0)
+	i.lock.RLock()
+	for idx := len(i.records) - 1; idx >= 0; idx-- {
+		s := toSummary(i.records[idx])
+		if method != "" && s.Method != method {
+			continue
+		}
+		if status != "" && !strings.HasPrefix(strconv.Itoa(s.Status), status) {
+			continue
+		}
+		if keyword != "" && !strings.Contains(s.Host+s.Uri, keyword) {
+			continue
+		}
+		summaries = append(summaries, s)
+	}
+	i.lock.RUnlock()
+	writeJson(w, summaries)
+}
+
+func (i *Inspector) handleDetail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/requests/"))
+	if err != nil {
+		http.Error(w, "invalid request id", http.StatusBadRequest)
+		return
+	}
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	for _, rec := range i.records {
+		if rec.Id == id {
+			detail := Detail{
+				Summary: toSummary(rec),
+				Request: toMessage(rec.Request.Header, rec.Request.Body),
+			}
+			detail.Request.Method = rec.Request.Method
+			detail.Request.Uri = rec.Request.Uri
+			detail.Request.Host = rec.Request.Host
+			if rec.Response != nil {
+				resp := toMessage(rec.Response.Header, rec.Response.Body)
+				resp.Status = rec.Response.Status
+				detail.Response = &resp
+			}
+			writeJson(w, detail)
+			return
+		}
+	}
+	http.Error(w, "request not found", http.StatusNotFound)
+}
+
+func (i *Inspector) handleResend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// simple cross-site form post could not carry json content type
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	var req ResendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid resend request: %s", err), http.StatusBadRequest)
+		return
+	}
+	if !i.isKnownEndpoint(req.Endpoint) {
+		// only allow sending to local services behind the tunnel
+		http.Error(w, fmt.Sprintf("unknown endpoint '%s'", req.Endpoint), http.StatusBadRequest)
+		return
+	}
+	start := time.Now()
+	resp, err := recorder.Send(i.client, &recorder.Request{
+		Method: req.Method,
+		Uri:    req.Uri,
+		Host:   req.Host,
+		Header: req.Header,
+		Body:   []byte(req.Body),
+	}, req.Endpoint)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to resend request: %s", err), http.StatusBadGateway)
+		return
+	}
+	result := ResendResult{
+		Duration: time.Since(start).Milliseconds(),
+		Response: toMessage(resp.Header, resp.Body),
+	}
+	result.Response.Status = resp.Status
+	writeJson(w, result)
+}
+
+func (i *Inspector) isKnownEndpoint(endpoint string) bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	for _, rec := range i.records {
+		if rec.Endpoint == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+func toSummary(rec *record) Summary {
+	s := Summary{
+		Id:       rec.Id,
+		Time:     rec.Time,
+		Endpoint: rec.Endpoint,
+		Method:   rec.Request.Method,
+		Uri:      rec.Request.Uri,
+		Host:     rec.Request.Host,
+		Duration: rec.Duration,
+	}
+	if rec.Response != nil {
+		s.Status = rec.Response.Status
+	}
+	return s
+}
+
+func toMessage(header http.Header, body []byte) Message {
+	m := Message{Header: header, Size: len(body)}
+	if utf8.Valid(body) {
+		m.Body = string(body)
+	} else {
+		m.Binary = true
+	}
+	return m
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Warn().Err(err).Msgf("Failed to generate inspector token")
+	}
+	return hex.EncodeToString(b)
+}
+
+func hostOf(hostPort string) string {
+	if host, _, err := net.SplitHostPort(hostPort); err == nil {
+		return strings.Trim(host, "[]")
+	}
+	return strings.Trim(hostPort, "[]")
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJson(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Debug().Err(err).Msgf("Failed to write inspector response")
+	}
+}
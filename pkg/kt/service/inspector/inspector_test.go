@@ -0,0 +1,161 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/service/recorder"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListAndDetail(t *testing.T) {
+	ins := New()
+	for i := 0; i < maxRecords+2; i++ {
+		ins.Put(&recorder.Entry{
+			Endpoint: "127.0.0.1:8080",
+			Time:     time.Now(),
+			Request:  recorder.Request{Method: http.MethodGet, Uri: fmt.Sprintf("/item/%d", i), Host: "tomcat"},
+			Response: &recorder.Response{Status: 200 + i%2*300},
+		})
+	}
+	ins.Put(&recorder.Entry{
+		Endpoint: "127.0.0.1:8080",
+		Request:  recorder.Request{Method: http.MethodPost, Uri: "/upload", Host: "tomcat", Body: []byte{0xff, 0xfe}},
+	})
+	server := httptest.NewServer(ins.Handler())
+	defer server.Close()
+
+	var summaries []Summary
+	getJson(t, server.URL+"/api/requests?token="+ins.token, &summaries)
+	require.Equal(t, maxRecords, len(summaries))
+	require.Equal(t, "/upload", summaries[0].Uri)
+	require.Equal(t, "/item/3", summaries[len(summaries)-1].Uri)
+
+	getJson(t, server.URL+"/api/requests?method=get&status=5&q=/item/1&token="+ins.token, &summaries)
+	for _, s := range summaries {
+		require.Equal(t, 500, s.Status)
+		require.True(t, strings.HasPrefix(s.Uri, "/item/1"))
+	}
+	require.Equal(t, "/item/199", summaries[0].Uri)
+
+	var detail Detail
+	getJson(t, fmt.Sprintf("%s/api/requests/%d?token=%s", server.URL, maxRecords+3, ins.token), &detail)
+	require.Equal(t, "/upload", detail.Request.Uri)
+	require.True(t, detail.Request.Binary)
+	require.Equal(t, 2, detail.Request.Size)
+	require.Nil(t, detail.Response)
+
+	resp, err := http.Get(server.URL + "/api/requests/1?token=" + ins.token)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestResend(t *testing.T) {
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(fmt.Sprintf("%s %s %s %s", r.Method, r.Host, r.Header.Get("X-Trace"), body)))
+	}))
+	defer local.Close()
+	endpoint := local.Listener.Addr().String()
+
+	ins := New()
+	ins.Put(&recorder.Entry{Endpoint: endpoint, Request: recorder.Request{Method: http.MethodGet, Uri: "/"}})
+	server := httptest.NewServer(ins.Handler())
+	defer server.Close()
+
+	req := ResendRequest{
+		Endpoint: endpoint,
+		Method:   http.MethodPut,
+		Uri:      "/edited",
+		Host:     "tomcat",
+		Header:   http.Header{"X-Trace": []string{"abc"}},
+		Body:     "hello",
+	}
+	data, _ := json.Marshal(req)
+	resp, err := postResend(server.URL, ins.token, "application/json", string(data))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var result ResendResult
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Equal(t, http.StatusCreated, result.Response.Status)
+	require.Equal(t, "PUT tomcat abc hello", result.Response.Body)
+
+	req.Endpoint = "10.0.0.1:80"
+	data, _ = json.Marshal(req)
+	resp, err = postResend(server.URL, ins.token, "application/json", string(data))
+	require.Nil(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestAccessCheck(t *testing.T) {
+	ins := New()
+	ins.loopbackOnly = true
+	ins.Put(&recorder.Entry{Endpoint: "127.0.0.1:8080", Request: recorder.Request{Method: http.MethodGet, Uri: "/"}})
+	handler := ins.Handler()
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		host        string
+		origin      string
+		contentType string
+		token       string
+		want        int
+	}{
+		{name: "indexPage", method: http.MethodGet, path: "/", host: "localhost:4040", want: http.StatusOK},
+		{name: "apiWithToken", method: http.MethodGet, path: "/api/requests", host: "127.0.0.1:4040",
+			token: ins.token, want: http.StatusOK},
+		{name: "apiWithoutToken", method: http.MethodGet, path: "/api/requests", host: "127.0.0.1:4040",
+			want: http.StatusForbidden},
+		{name: "dnsRebinding", method: http.MethodGet, path: "/api/requests", host: "evil.com:4040",
+			token: ins.token, want: http.StatusForbidden},
+		{name: "crossOrigin", method: http.MethodGet, path: "/api/requests", host: "127.0.0.1:4040",
+			origin: "http://evil.com", token: ins.token, want: http.StatusForbidden},
+		{name: "sameOrigin", method: http.MethodGet, path: "/api/requests", host: "[::1]:4040",
+			origin: "http://[::1]:4040", token: ins.token, want: http.StatusOK},
+		{name: "resendFormPost", method: http.MethodPost, path: "/api/resend", host: "127.0.0.1:4040",
+			contentType: "text/plain", token: ins.token, want: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}"))
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.token != "" {
+				req.Header.Set(TokenHeader, tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func postResend(serverUrl, token, contentType, body string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, serverUrl+"/api/resend", strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(TokenHeader, token)
+	return http.DefaultClient.Do(req)
+}
+
+func getJson(t *testing.T, url string, target any) {
+	resp, err := http.Get(url)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(target))
+}
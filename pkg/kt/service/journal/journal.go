@@ -0,0 +1,186 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ActionBegin first entry of every journal, records the cluster and session of the process
+	ActionBegin = "begin"
+	// ActionCreate a kubernetes resource created
+	ActionCreate = "create"
+	// ActionSelector selector of a service changed
+	ActionSelector = "selector"
+	// ActionScale replicas of a deployment changed
+	ActionScale = "scale"
+	// ActionEndpoints endpoints of a service taken over
+	ActionEndpoints = "endpoints"
+	// ActionWorkload custom workload (e.g. argo rollout) suspended
+	ActionWorkload = "workload"
+	// ActionIstio route or subset added to istio virtual service or destination rule
+	ActionIstio = "istio"
+	// ActionHosts records added to local hosts file
+	ActionHosts = "hosts"
+	// ActionNameServer local dns configuration changed
+	ActionNameServer = "nameserver"
+	// ActionRoute local route table changed
+	ActionRoute = "route"
+
+	journalSuffix   = ".journal"
+	replayingSuffix = ".replaying"
+)
+
+// Entry a mutating step which should be undone if ktctl exits without cleanup
+type Entry struct {
+	Action    string            `json:"action"`
+	Time      int64             `json:"time"`
+	Cluster   string            `json:"cluster,omitempty"`
+	Holder    string            `json:"holder,omitempty"`
+	Lease     string            `json:"lease,omitempty"`
+	Kind      string            `json:"kind,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	Replicas  int32             `json:"replicas,omitempty"`
+	Patch     string            `json:"patch,omitempty"`
+	Origin    string            `json:"origin,omitempty"`
+}
+
+var journalFile *os.File
+var journalLock sync.Mutex
+
+// Open create journal of current process, all following recorded entries are persisted to it
+func Open(component string, begin Entry) error {
+	journalLock.Lock()
+	defer journalLock.Unlock()
+	path := filepath.Join(util.KtJournalDir, fmt.Sprintf("%s-%d%s", component, os.Getpid(), journalSuffix))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	journalFile = f
+	begin.Action = ActionBegin
+	return write(begin)
+}
+
+// Record persist entry to journal before the mutating step happens, do nothing if journal not opened
+func Record(entry Entry) {
+	journalLock.Lock()
+	defer journalLock.Unlock()
+	if journalFile == nil {
+		return
+	}
+	if err := write(entry); err != nil {
+		log.Warn().Err(err).Msgf("Failed to record %s journal", entry.Action)
+	}
+}
+
+// Close remove journal of current process, should be called after all changes undone
+func Close() {
+	journalLock.Lock()
+	defer journalLock.Unlock()
+	if journalFile == nil {
+		return
+	}
+	path := journalFile.Name()
+	_ = journalFile.Close()
+	journalFile = nil
+	if err := os.Remove(path); err != nil {
+		log.Debug().Err(err).Msgf("Failed to remove journal %s", path)
+	}
+}
+
+// ListIncomplete list journals left by processes no longer running
+func ListIncomplete() []string {
+	files, _ := os.ReadDir(util.KtJournalDir)
+	var journals []string
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), journalSuffix) {
+			continue
+		}
+		if pid := parsePid(f.Name()); pid > 0 && pid != os.Getpid() && !util.IsProcessExist(pid) {
+			journals = append(journals, filepath.Join(util.KtJournalDir, f.Name()))
+		}
+	}
+	return journals
+}
+
+// Claim take over a journal to avoid it being replayed by multiple processes, return its entries
+func Claim(path string) (string, []Entry, error) {
+	claimed := path + replayingSuffix
+	if err := os.Rename(path, claimed); err != nil {
+		return "", nil, err
+	}
+	entries, err := Load(claimed)
+	if err != nil {
+		Release(claimed)
+		return "", nil, err
+	}
+	return claimed, entries, nil
+}
+
+// Release give back a claimed journal which cannot be replayed now
+func Release(claimed string) {
+	if err := os.Rename(claimed, strings.TrimSuffix(claimed, replayingSuffix)); err != nil {
+		log.Debug().Err(err).Msgf("Failed to release journal %s", claimed)
+	}
+}
+
+// Finish remove a claimed journal after replayed
+func Finish(claimed string) {
+	if err := os.Remove(claimed); err != nil {
+		log.Debug().Err(err).Msgf("Failed to remove journal %s", claimed)
+	}
+}
+
+// Load read entries from journal, incomplete line (e.g. written during power loss) is ignored
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			log.Debug().Msgf("Skipping broken journal line: %s", scanner.Text())
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func write(entry Entry) error {
+	entry.Time = time.Now().Unix()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err = journalFile.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	// must reach disk before the mutating step happens
+	return journalFile.Sync()
+}
+
+func parsePid(fileName string) int {
+	name := strings.TrimSuffix(fileName, journalSuffix)
+	pid, err := strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+	if err != nil {
+		return -1
+	}
+	return pid
+}
@@ -0,0 +1,59 @@
+package journal
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	util.KtJournalDir = t.TempDir()
+	require.Nil(t, Open("exchange", Entry{Cluster: "https://cluster", Lease: "kt-session-abc"}))
+	Record(Entry{Action: ActionCreate, Kind: "pod", Name: "shadow", Namespace: "default"})
+	Record(Entry{Action: ActionSelector, Kind: "service", Name: "tomcat", Namespace: "default",
+		Selector: map[string]string{"app": "tomcat"}})
+
+	path := filepath.Join(util.KtJournalDir, fmt.Sprintf("exchange-%d.journal", os.Getpid()))
+	entries, err := Load(path)
+	require.Nil(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, ActionBegin, entries[0].Action)
+	require.Equal(t, "kt-session-abc", entries[0].Lease)
+	require.Equal(t, "shadow", entries[1].Name)
+	require.Equal(t, map[string]string{"app": "tomcat"}, entries[2].Selector)
+
+	// journal of current process should never be replayed
+	require.Empty(t, ListIncomplete())
+	Close()
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	Record(Entry{Action: ActionScale})
+}
+
+func TestClaim(t *testing.T) {
+	util.KtJournalDir = t.TempDir()
+	path := filepath.Join(util.KtJournalDir, "mesh-999999.journal")
+	// last line is truncated by power loss
+	content := `{"action":"begin","cluster":"https://cluster"}
+{"action":"scale","name":"tomcat","replicas":2}
+{"action":"sel`
+	require.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(util.KtJournalDir, "invalid.journal"), []byte(""), 0644))
+	require.Equal(t, []string{path}, ListIncomplete())
+
+	claimed, entries, err := Claim(path)
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, int32(2), entries[1].Replicas)
+	_, _, err = Claim(path)
+	require.NotNil(t, err)
+
+	Release(claimed)
+	claimed, _, err = Claim(path)
+	require.Nil(t, err)
+	Finish(claimed)
+	require.Empty(t, ListIncomplete())
+}
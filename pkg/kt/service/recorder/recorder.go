@@ -0,0 +1,338 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxBodySize body exceeding this size is truncated in record
+const maxBodySize = 1 << 20
+
+// maxPending requests waiting for response in one connection
+const maxPending = 64
+
+// maxSpoolSize data not parsed yet exceeding this size stops recording of the connection
+const maxSpoolSize = 4 * maxBodySize
+
+// Entry a http request arrived at local endpoint and its response
+type Entry struct {
+	Endpoint string    `json:"endpoint,omitempty"`
+	Time     time.Time `json:"time"`
+	Duration int64     `json:"duration"`
+	Request  Request   `json:"request"`
+	Response *Response `json:"response,omitempty"`
+}
+
+// Request recorded http request
+type Request struct {
+	Method string      `json:"method"`
+	Uri    string      `json:"uri"`
+	Host   string      `json:"host"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// Response recorded http response
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// Sink receive entries of recorded traffic
+type Sink interface {
+	Put(entry *Entry)
+}
+
+// Recorder parse http traffic passing through wrapped connections, and send to sinks
+type Recorder struct {
+	sinks []Sink
+	lock  sync.Mutex
+}
+
+var instance *Recorder
+var instanceLock sync.RWMutex
+
+// AddSink start recording traffic of reverse tunnels to the sink
+func AddSink(sink Sink) {
+	instanceLock.Lock()
+	defer instanceLock.Unlock()
+	if instance == nil {
+		instance = New()
+	}
+	instance.AddSink(sink)
+}
+
+// Start record traffic of reverse tunnels to specified file
+func Start(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open record file %s: %s", path, err)
+	}
+	AddSink(NewFileSink(file))
+	log.Info().Msgf("Recording traffic to %s", path)
+	return nil
+}
+
+// Stop close all sinks
+func Stop() {
+	instanceLock.Lock()
+	defer instanceLock.Unlock()
+	if instance != nil {
+		instance.lock.Lock()
+		for _, sink := range instance.sinks {
+			if closer, ok := sink.(io.Closer); ok {
+				_ = closer.Close()
+			}
+		}
+		instance.lock.Unlock()
+		instance = nil
+	}
+}
+
+// Wrap record traffic of connection to local endpoint if recording started
+func Wrap(conn net.Conn) net.Conn {
+	instanceLock.RLock()
+	r := instance
+	instanceLock.RUnlock()
+	if r == nil {
+		return conn
+	}
+	return r.Wrap(conn)
+}
+
+// New create recorder sending entries to specified sinks
+func New(sinks ...Sink) *Recorder {
+	return &Recorder{sinks: sinks}
+}
+
+// AddSink let recorder send entries to one more sink
+func (r *Recorder) AddSink(sink Sink) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sinks = append(r.sinks, sink)
+}
+
+// Wrap return a connection which copies data written to it as requests and data read from it as responses
+func (r *Recorder) Wrap(conn net.Conn) net.Conn {
+	reqSpool := newSpool()
+	respSpool := newSpool()
+	pending := make(chan *Entry, maxPending)
+	go r.parseRequests(reqSpool, pending, conn.RemoteAddr().String())
+	go r.parseResponses(respSpool, pending)
+	return &recordConn{Conn: conn, reqSpool: reqSpool, respSpool: respSpool}
+}
+
+func (r *Recorder) parseRequests(reader *spool, pending chan *Entry, endpoint string) {
+	// following data is discarded once parsing stopped
+	defer reader.Close()
+	defer close(pending)
+	br := bufio.NewReader(reader)
+	for {
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			if err != io.EOF {
+				log.Debug().Err(err).Msgf("Stop recording non-http request")
+			}
+			return
+		}
+		entry := &Entry{
+			Endpoint: endpoint,
+			Time:     time.Now(),
+			Request: Request{
+				Method: req.Method,
+				Uri:    req.RequestURI,
+				Host:   req.Host,
+				Header: req.Header,
+				Body:   readBody(req.Body),
+			},
+		}
+		select {
+		case pending <- entry:
+		default:
+			log.Debug().Msgf("Too many pending requests, skip recording %s %s", req.Method, req.RequestURI)
+		}
+	}
+}
+
+func (r *Recorder) parseResponses(reader *spool, pending chan *Entry) {
+	defer func() {
+		reader.Close()
+		// requests without response are still recorded
+		for entry := range pending {
+			r.write(entry)
+		}
+	}()
+	br := bufio.NewReader(reader)
+	for entry := range pending {
+		resp, err := readResponse(br, entry.Request.Method)
+		if err != nil {
+			if err != io.EOF {
+				log.Debug().Err(err).Msgf("Stop recording non-http response")
+			}
+			r.write(entry)
+			return
+		}
+		entry.Duration = time.Since(entry.Time).Milliseconds()
+		entry.Response = &Response{
+			Status: resp.StatusCode,
+			Header: resp.Header,
+			Body:   readBody(resp.Body),
+		}
+		r.write(entry)
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			return
+		}
+	}
+}
+
+func (r *Recorder) write(entry *Entry) {
+	r.lock.Lock()
+	sinks := r.sinks
+	r.lock.Unlock()
+	for _, sink := range sinks {
+		sink.Put(entry)
+	}
+}
+
+// fileSink write entries to file, one entry per line
+type fileSink struct {
+	writer io.WriteCloser
+	lock   sync.Mutex
+}
+
+// NewFileSink create sink writing entries to specified writer in json lines format
+func NewFileSink(writer io.WriteCloser) Sink {
+	return &fileSink{writer: writer}
+}
+
+func (s *fileSink) Put(entry *Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to marshal record entry")
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err = s.writer.Write(append(data, '\n')); err != nil {
+		log.Debug().Err(err).Msgf("Failed to write record entry")
+	}
+}
+
+func (s *fileSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.writer.Close()
+}
+
+func readResponse(br *bufio.Reader, method string) (*http.Response, error) {
+	for {
+		resp, err := http.ReadResponse(br, &http.Request{Method: method})
+		if err != nil {
+			return nil, err
+		}
+		// skip informational responses, e.g. 100 Continue
+		if resp.StatusCode >= 200 || resp.StatusCode == http.StatusSwitchingProtocols {
+			return resp, nil
+		}
+	}
+}
+
+func readBody(body io.ReadCloser) []byte {
+	if body == nil {
+		return nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(body, maxBodySize))
+	_, _ = io.Copy(io.Discard, body)
+	_ = body.Close()
+	return data
+}
+
+// recordConn copy data passing through to spools, which are parsed in background
+type recordConn struct {
+	net.Conn
+	reqSpool  *spool
+	respSpool *spool
+}
+
+func (c *recordConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.reqSpool.Write(p[:n])
+	return n, err
+}
+
+func (c *recordConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.respSpool.Write(p[:n])
+	return n, err
+}
+
+func (c *recordConn) Close() error {
+	c.reqSpool.Close()
+	c.respSpool.Close()
+	return c.Conn.Close()
+}
+
+// spool buffer between connection and parser, writing to it never blocks the connection
+type spool struct {
+	buf    bytes.Buffer
+	closed bool
+	lock   sync.Mutex
+	cond   *sync.Cond
+}
+
+func newSpool() *spool {
+	s := &spool{}
+	s.cond = sync.NewCond(&s.lock)
+	return s
+}
+
+// Write append data to buffer, data is dropped after closed or too much data left unparsed
+func (s *spool) Write(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+	if s.buf.Len()+len(p) > maxSpoolSize {
+		log.Debug().Msgf("Parsing falls behind, stop recording")
+		s.closed = true
+		s.buf.Reset()
+	} else {
+		s.buf.Write(p)
+	}
+	s.cond.Broadcast()
+}
+
+// Read block until data available, return EOF after closed and all data consumed
+func (s *spool) Read(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for s.buf.Len() == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if s.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return s.buf.Read(p)
+}
+
+// Close stop accepting data
+func (s *spool) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	s.cond.Broadcast()
+}
@@ -0,0 +1,166 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Close() error {
+	return nil
+}
+
+func (b *syncBuffer) lines() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return strings.Split(strings.TrimSpace(b.buf.String()), "\n")
+}
+
+func TestWrap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Echo", r.Header.Get("X-Trace"))
+		_, _ = w.Write([]byte(fmt.Sprintf("%s %s", r.URL.Path, body)))
+	}))
+	defer server.Close()
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.Nil(t, err)
+
+	out := &syncBuffer{}
+	conn = New(NewFileSink(out)).Wrap(conn)
+	br := bufio.NewReader(conn)
+	for _, path := range []string{"/a", "/b"} {
+		req, _ := http.NewRequest(http.MethodPost, "http://tomcat"+path, strings.NewReader("hello"))
+		req.Header.Set("X-Trace", path)
+		require.Nil(t, req.Write(conn))
+		resp, err2 := http.ReadResponse(br, req)
+		require.Nil(t, err2)
+		body, _ := io.ReadAll(resp.Body)
+		require.Equal(t, path+" hello", string(body))
+	}
+	_ = conn.Close()
+
+	require.Eventually(t, func() bool { return len(out.lines()) == 2 }, time.Second, 10*time.Millisecond)
+	var entry Entry
+	require.Nil(t, json.Unmarshal([]byte(out.lines()[1]), &entry))
+	require.Equal(t, server.Listener.Addr().String(), entry.Endpoint)
+	require.Equal(t, http.MethodPost, entry.Request.Method)
+	require.Equal(t, "/b", entry.Request.Uri)
+	require.Equal(t, "tomcat", entry.Request.Host)
+	require.Equal(t, "hello", string(entry.Request.Body))
+	require.Equal(t, http.StatusOK, entry.Response.Status)
+	require.Equal(t, "/b", entry.Response.Header.Get("X-Echo"))
+	require.Equal(t, "/b hello", string(entry.Response.Body))
+}
+
+func TestWrapNonHttp(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		_, _ = io.Copy(server, server)
+	}()
+	out := &syncBuffer{}
+	conn := New(NewFileSink(out)).Wrap(client)
+	for i := 0; i < 100; i++ {
+		_, err := conn.Write([]byte("\x00\x01binary"))
+		require.Nil(t, err)
+		_, err = io.ReadFull(conn, make([]byte, 8))
+		require.Nil(t, err)
+	}
+	_ = conn.Close()
+	require.Equal(t, []string{""}, out.lines())
+}
+
+func TestLoadAndReplay(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	entries := []Entry{
+		{Time: now, Request: Request{Method: http.MethodPut, Uri: "/orders/1?v=2", Host: "order-svc",
+			Header: http.Header{"Authorization": {"token"}, "X-Trace": {"abc"}, "Connection": {"close"}},
+			Body:   []byte("{}")}, Response: &Response{Status: http.StatusOK}},
+		{Time: now.Add(200 * time.Millisecond), Request: Request{Method: http.MethodGet, Uri: "/health"}},
+	}
+	file := filepath.Join(t.TempDir(), "record.jsonl")
+	// entries are saved in the order of response finished
+	var lines []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		data, _ := json.Marshal(entries[i])
+		lines = append(lines, string(data))
+	}
+	require.Nil(t, os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n\n"), 0644))
+
+	loaded, err := Load(file)
+	require.Nil(t, err)
+	require.Len(t, loaded, 2)
+	SortByTime(loaded)
+	require.Equal(t, "/orders/1?v=2", loaded[0].Request.Uri)
+	require.Equal(t, 200*time.Millisecond, Delay(&loaded[0], &loaded[1], 0))
+	require.Equal(t, 150*time.Millisecond, Delay(&loaded[0], &loaded[1], 50*time.Millisecond))
+	require.Equal(t, time.Duration(0), Delay(&loaded[0], &loaded[1], time.Second))
+	require.Equal(t, time.Duration(0), Delay(nil, &loaded[0], 0))
+
+	status, err := Replay(http.DefaultClient, &loaded[0], server.Listener.Addr().String(), []string{"authorization"})
+	require.Nil(t, err)
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, http.MethodPut, received.Method)
+	require.Equal(t, "/orders/1?v=2", received.RequestURI)
+	require.Equal(t, "order-svc", received.Host)
+	require.Equal(t, "abc", received.Header.Get("X-Trace"))
+	require.Equal(t, "", received.Header.Get("Authorization"))
+	require.Equal(t, "{}", string(receivedBody))
+}
+
+func TestStartAndStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "record.jsonl")
+	require.Nil(t, Start(path))
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, server := net.Pipe()
+			_ = Wrap(client).Close()
+			_ = server.Close()
+		}()
+	}
+	Stop()
+	wg.Wait()
+
+	client, server := net.Pipe()
+	defer server.Close()
+	require.Equal(t, client, Wrap(client))
+}
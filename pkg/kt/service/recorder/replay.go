@@ -0,0 +1,114 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// hopHeaders headers only meaningful for a single connection, never replayed
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// Load read recorded entries from file
+func Load(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	// body of each entry could be up to 1MB, which is base64 encoded
+	scanner.Buffer(make([]byte, 64*1024), 4*maxBodySize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid record at line %d: %s", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Replay send recorded request to target address, and return status code of response
+func Replay(client *http.Client, entry *Entry, to string, dropHeaders []string) (int, error) {
+	req := entry.Request
+	req.Header = req.Header.Clone()
+	for _, h := range dropHeaders {
+		req.Header.Del(strings.TrimSpace(h))
+	}
+	// keep the original virtual host unless explicitly dropped
+	if containsHeader(dropHeaders, "Host") {
+		req.Host = ""
+	}
+	resp, err := Send(client, &req, to)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Status, nil
+}
+
+// Send issue the request to target address, response body exceeding max size is truncated
+func Send(client *http.Client, request *Request, to string) (*Response, error) {
+	req, err := http.NewRequest(request.Method, "http://"+to+request.Uri, bytes.NewReader(request.Body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range request.Header {
+		req.Header[k] = v
+	}
+	for _, h := range hopHeaders {
+		req.Header.Del(h)
+	}
+	if request.Host != "" {
+		req.Host = request.Host
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return &Response{
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   readBody(resp.Body),
+	}, nil
+}
+
+// SortByTime order entries by start time of request, entries are saved when response finished,
+// hence in the order of completion rather than arrival
+func SortByTime(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+}
+
+// Delay time to wait before replaying the entry, to keep the original pace of requests,
+// offset to start time of the first request is used, so that time spent on replaying doesn't accumulate
+func Delay(first, current *Entry, elapsed time.Duration) time.Duration {
+	if first == nil {
+		return 0
+	}
+	if delay := current.Time.Sub(first.Time) - elapsed; delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+func containsHeader(headers []string, header string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(strings.TrimSpace(h), header) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package sshchannel
+
+import (
+	"context"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/common/mux"
+	"github.com/alibaba/kt-connect/pkg/kt/service/recorder"
+	"github.com/rs/zerolog/log"
+	"github.com/wzshiming/socks5"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// MuxCli channel multiplexing all forward and reverse streams to shadow pod over one connection
+type MuxCli struct {
+	// tunnel address -> session
+	sessions map[string]*muxSession
+	lock     sync.Mutex
+}
+
+type muxSession struct {
+	session *mux.Session
+	// remote listening address -> local endpoint
+	forwards map[string]string
+	lock     sync.Mutex
+}
+
+// StartSocks5Proxy start socks5 proxy
+func (c *MuxCli) StartSocks5Proxy(privateKey, sshAddress, socks5Address string) error {
+	svc := &socks5.Server{
+		Logger: SocksLogger{},
+		ProxyDial: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			ms, err := c.getSession(privateKey, sshAddress)
+			if err != nil {
+				return nil, err
+			}
+			return ms.session.Open((&mux.Request{Kind: mux.RequestDial, Address: address}).Encode())
+		},
+	}
+	return svc.ListenAndServe("tcp", socks5Address)
+}
+
+// ForwardRemoteToLocal forward remote request to local
+func (c *MuxCli) ForwardRemoteToLocal(privateKey, sshAddress, remoteEndpoint, localEndpoint string) error {
+	ms, err := c.getSession(privateKey, sshAddress)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to create mux tunnel")
+		return err
+	}
+	ms.lock.Lock()
+	ms.forwards[remoteEndpoint] = localEndpoint
+	ms.lock.Unlock()
+	defer func() {
+		ms.lock.Lock()
+		delete(ms.forwards, remoteEndpoint)
+		ms.lock.Unlock()
+	}()
+
+	stream, err := ms.session.Open((&mux.Request{Kind: mux.RequestListen, Address: remoteEndpoint}).Encode())
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to listen remote endpoint")
+		return err
+	}
+	defer stream.Close()
+
+	log.Info().Msgf("Reverse tunnel %s -> %s established", remoteEndpoint, localEndpoint)
+	// listening lasts until the stream closed by shadow pod or tunnel broken
+	if _, err = io.Copy(io.Discard, stream); err == nil {
+		err = io.EOF
+	}
+	return err
+}
+
+// RunScript not supported by mux tunnel
+func (c *MuxCli) RunScript(_, _, _ string) (string, error) {
+	return "", fmt.Errorf("running script is not supported by mux transport")
+}
+
+// getSession reuse the session to same address, reconnect if previous one already closed
+func (c *MuxCli) getSession(privateKey, address string) (*muxSession, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if ms, exists := c.sessions[address]; exists && !ms.session.IsClosed() {
+		return ms, nil
+	}
+	keyData, err := os.ReadFile(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(keyData)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("tcp", address, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if err = mux.ClientHandshake(conn, signer); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	ms := &muxSession{
+		session:  mux.Client(conn, mux.DefaultConfig()),
+		forwards: map[string]string{},
+	}
+	go ms.handleForwarded()
+	c.sessions[address] = ms
+	log.Debug().Msgf("Mux tunnel to %s established", address)
+	return ms, nil
+}
+
+// handleForwarded handle connections accepted by remote listening address
+func (ms *muxSession) handleForwarded() {
+	for {
+		stream, err := ms.session.Accept()
+		if err != nil {
+			log.Debug().Err(err).Msgf("Mux tunnel closed")
+			return
+		}
+		go func() {
+			req, err2 := mux.ParseRequest(stream.Request())
+			if err2 != nil || req.Kind != mux.RequestForwarded {
+				_ = stream.Refuse("unexpected request")
+				return
+			}
+			ms.lock.Lock()
+			localEndpoint, exists := ms.forwards[req.Address]
+			ms.lock.Unlock()
+			if !exists {
+				_ = stream.Refuse(fmt.Sprintf("address %s not forwarded", req.Address))
+				return
+			}
+			local, err2 := net.Dial("tcp", localEndpoint)
+			if err2 != nil {
+				log.Error().Err(err2).Msgf("Failed to dial local endpoint %s", localEndpoint)
+				_ = stream.Refuse(err2.Error())
+				return
+			}
+			if err2 = stream.Confirm(); err2 != nil {
+				_ = local.Close()
+				return
+			}
+			mux.Pipe(stream, recorder.Wrap(local))
+		}()
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/alibaba/kt-connect/pkg/common"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/recorder"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"io"
 	"net"
@@ -103,7 +106,14 @@ func (c *Cli) ForwardRemoteToLocal(privateKey, sshAddress, remoteEndpoint, local
 }
 
 func getSshTunnelAddress(privateKey string, sshAddress string) string {
-	return fmt.Sprintf("ssh://root@%s?identity_file=%s", sshAddress, privateKey)
+	return fmt.Sprintf("ssh://%s@%s?identity_file=%s", getSshUser(), sshAddress, privateKey)
+}
+
+func getSshUser() string {
+	if opt.Get().Global.RestrictedShadow {
+		return common.NonRootUser
+	}
+	return "root"
 }
 
 func disconnectRemotePort(privateKey, sshAddress, remoteEndpoint string, c *Cli) {
@@ -143,7 +153,7 @@ func handleRequest(listener net.Listener, localEndpoint string) error {
 	}
 
 	// Handle request in individual coroutine, current coroutine continue to accept more requests
-	go handleClient(client, local)
+	go handleClient(client, recorder.Wrap(local))
 	return nil
 }
 
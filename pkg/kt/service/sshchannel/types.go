@@ -1,5 +1,10 @@
 package sshchannel
 
+import (
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+)
+
 // Channel network channel
 type Channel interface {
 	StartSocks5Proxy(privateKey, sshAddress, socks5Address string) error
@@ -9,12 +14,16 @@ type Channel interface {
 
 // Cli the singleton type
 type Cli struct {}
-var instance *Cli
+var instance Channel
 
 // Ins get singleton instance
 func Ins() Channel {
 	if instance == nil {
-		instance = &Cli{}
+		if opt.Get().Global.Transport == util.TransportMux {
+			instance = &MuxCli{sessions: map[string]*muxSession{}}
+		} else {
+			instance = &Cli{}
+		}
 	}
 	return instance
 }
@@ -36,7 +36,11 @@ func (s *Cli) Connect(req *SSHVPNRequest) *exec.Cmd {
 	}
 
 	subCommand := fmt.Sprintf("ssh -oStrictHostKeyChecking=no -oUserKnownHostsFile=/dev/null -i %s", req.RemoteSSHPKPath)
-	remoteAddr := fmt.Sprintf("root@%s:%d", common.Localhost, req.LocalSshPort)
+	sshUser := "root"
+	if opt.Get().Global.RestrictedShadow {
+		sshUser = common.NonRootUser
+	}
+	remoteAddr := fmt.Sprintf("%s@%s:%d", sshUser, common.Localhost, req.LocalSshPort)
 	args = append(args, "--ssh-cmd", subCommand, "--remote", remoteAddr, "--exclude", common.Localhost)
 	if opt.Get().Connect.ExcludeIps != "" {
 		for _, ip := range req.ExcludeCIDR {
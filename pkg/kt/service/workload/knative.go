@@ -0,0 +1,68 @@
+package workload
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KnativeServiceGVR group version resource of knative service
+var KnativeServiceGVR = schema.GroupVersionResource{Group: "serving.knative.dev", Version: "v1", Resource: "services"}
+
+// KnativeService plugin of knative service
+type KnativeService struct{}
+
+// GVR ...
+func (k *KnativeService) GVR() schema.GroupVersionResource {
+	return KnativeServiceGVR
+}
+
+// GetService use the private service of latest ready revision, which selects revision pods,
+// notice its selector and endpoints are reconciled by knative, so it must not be modified
+func (k *KnativeService) GetService(obj *unstructured.Unstructured) (*coreV1.Service, error) {
+	revision, err := latestReadyRevision(obj)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.Ins().GetService(revision+"-private", obj.GetNamespace())
+}
+
+// GetPodLabels ...
+func (k *KnativeService) GetPodLabels(obj *unstructured.Unstructured) (map[string]string, error) {
+	revision, err := latestReadyRevision(obj)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"serving.knative.dev/revision": revision}, nil
+}
+
+// Suspend pin all traffic to current revision, so that new revision rolled out during exchange won't bypass it
+func (k *KnativeService) Suspend(obj *unstructured.Unstructured, mode string) ([]byte, []byte, error) {
+	switch mode {
+	case util.ExchangeModeScale:
+		// revision pods are scaled by knative autoscaler according to traffic
+		return nil, nil, fmt.Errorf("knative service cannot be exchanged in %s mode, please use %s mode",
+			util.ExchangeModeScale, util.ExchangeModeEphemeral)
+	case util.ExchangeModeSelector, util.ExchangeModeEndpoints:
+		// selector and endpoints of revision services are owned by serverless service reconciler of knative
+		return nil, nil, fmt.Errorf("knative service cannot be exchanged in %s mode, because its revision service "+
+			"is reconciled by knative, please use %s mode", mode, util.ExchangeModeEphemeral)
+	}
+	revision, err := latestReadyRevision(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	traffic, _, _ := unstructured.NestedSlice(obj.Object, "spec", "traffic")
+	return specPatch("traffic", []map[string]any{{"revisionName": revision, "percent": 100}}, traffic)
+}
+
+func latestReadyRevision(obj *unstructured.Unstructured) (string, error) {
+	revision, _, _ := unstructured.NestedString(obj.Object, "status", "latestReadyRevisionName")
+	if revision == "" {
+		return "", fmt.Errorf("knative service '%s' has no ready revision", obj.GetName())
+	}
+	return revision, nil
+}
@@ -0,0 +1,101 @@
+package workload
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RolloutGVR group version resource of argo rollout
+var RolloutGVR = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+
+// RolloutPodTemplateHash label added by argo rollouts to pods and selector of services it manages
+const RolloutPodTemplateHash = "rollouts-pod-template-hash"
+
+// Rollout plugin of argo rollout
+type Rollout struct{}
+
+// GVR ...
+func (r *Rollout) GVR() schema.GroupVersionResource {
+	return RolloutGVR
+}
+
+// GetService use active service of blue-green strategy or stable service of canary strategy,
+// otherwise the service selecting pods of rollout
+func (r *Rollout) GetService(obj *unstructured.Unstructured) (*coreV1.Service, error) {
+	for _, path := range [][]string{
+		{"spec", "strategy", "blueGreen", "activeService"},
+		{"spec", "strategy", "canary", "stableService"},
+	} {
+		if svcName, _, _ := unstructured.NestedString(obj.Object, path...); svcName != "" {
+			return cluster.Ins().GetService(svcName, obj.GetNamespace())
+		}
+	}
+	labels, err := selectorOf(obj)
+	if err != nil {
+		return nil, err
+	}
+	svcList, err := cluster.Ins().GetServicesBySelector(labels, obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	} else if len(svcList) == 0 {
+		return nil, fmt.Errorf("failed to find service for rollout '%s', with labels '%v'", obj.GetName(), labels)
+	}
+	return &svcList[0], nil
+}
+
+// GetPodLabels labels of stable pods, argo rollouts adds pod template hash of stable replica set
+// to selector of active or stable service, pods without it won't receive any traffic
+func (r *Rollout) GetPodLabels(obj *unstructured.Unstructured) (map[string]string, error) {
+	labels, err := selectorOf(obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range [][]string{
+		{"status", "stableRS"},
+		{"status", "blueGreen", "activeSelector"},
+	} {
+		if hash, _, _ := unstructured.NestedString(obj.Object, path...); hash != "" {
+			labels[RolloutPodTemplateHash] = hash
+			break
+		}
+	}
+	return labels, nil
+}
+
+func selectorOf(obj *unstructured.Unstructured) (map[string]string, error) {
+	labels, _, err := unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels")
+	if err != nil {
+		return nil, err
+	} else if len(labels) == 0 {
+		return nil, fmt.Errorf("rollout '%s' has no selector", obj.GetName())
+	}
+	return labels, nil
+}
+
+// Suspend scale rollout to zero in scale mode, otherwise pause it to keep service selector unchanged
+func (r *Rollout) Suspend(obj *unstructured.Unstructured, mode string) ([]byte, []byte, error) {
+	if mode == util.ExchangeModeScale {
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			// replicas of rollout defaults to 1
+			replicas = 1
+		}
+		return specPatch("replicas", 0, replicas)
+	}
+	paused, _, _ := unstructured.NestedBool(obj.Object, "spec", "paused")
+	return specPatch("paused", true, paused)
+}
+
+func specPatch(field string, value, origin any) ([]byte, []byte, error) {
+	suspend, err := json.Marshal(map[string]any{"spec": map[string]any{field: value}})
+	if err != nil {
+		return nil, nil, err
+	}
+	restore, err := json.Marshal(map[string]any{"spec": map[string]any{field: origin}})
+	return suspend, restore, err
+}
@@ -0,0 +1,41 @@
+package workload
+
+import (
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Plugin support of workload type which is managed by its own controller,
+// changing its deployment or service directly would be undone
+type Plugin interface {
+	// GVR group version resource of the workload
+	GVR() schema.GroupVersionResource
+	// GetService get the service which routes requests to the workload
+	GetService(obj *unstructured.Unstructured) (*coreV1.Service, error)
+	// GetPodLabels get labels which select pods of the workload
+	GetPodLabels(obj *unstructured.Unstructured) (map[string]string, error)
+	// Suspend generate merge patch to keep controller of the workload from undoing exchange in specified mode,
+	// and the merge patch to restore it
+	Suspend(obj *unstructured.Unstructured, mode string) ([]byte, []byte, error)
+}
+
+var plugins = map[string]Plugin{}
+
+// Register make plugin available for specified resource types
+func Register(plugin Plugin, resourceTypes ...string) {
+	for _, t := range resourceTypes {
+		plugins[t] = plugin
+	}
+}
+
+// Get get plugin of resource type
+func Get(resourceType string) (Plugin, bool) {
+	plugin, exists := plugins[resourceType]
+	return plugin, exists
+}
+
+func init() {
+	Register(&Rollout{}, "rollout", "rollouts", "ro")
+	Register(&KnativeService{}, "ksvc", "kservice")
+}
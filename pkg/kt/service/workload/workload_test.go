@@ -0,0 +1,94 @@
+package workload
+
+import (
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func newRollout(strategy map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Rollout",
+		"metadata":   map[string]any{"name": "tomcat", "namespace": "default"},
+		"spec": map[string]any{
+			"replicas": int64(3),
+			"selector": map[string]any{"matchLabels": map[string]any{"app": "tomcat"}},
+			"strategy": strategy,
+		},
+	}}
+}
+
+func TestRollout(t *testing.T) {
+	opt.Store.Clientset = testclient.NewSimpleClientset(
+		&coreV1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "tomcat", Namespace: "default"},
+			Spec:       coreV1.ServiceSpec{Selector: map[string]string{"app": "tomcat"}},
+		},
+		&coreV1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "tomcat-active", Namespace: "default"},
+			Spec:       coreV1.ServiceSpec{Selector: map[string]string{"app": "tomcat"}},
+		},
+	)
+	plugin, exists := Get("rollout")
+	require.True(t, exists)
+
+	svc, err := plugin.GetService(newRollout(map[string]any{"canary": map[string]any{}}))
+	require.Nil(t, err)
+	require.Equal(t, "tomcat", svc.Name)
+	svc, err = plugin.GetService(newRollout(map[string]any{
+		"blueGreen": map[string]any{"activeService": "tomcat-active"},
+	}))
+	require.Nil(t, err)
+	require.Equal(t, "tomcat-active", svc.Name)
+
+	labels, err := plugin.GetPodLabels(newRollout(nil))
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"app": "tomcat"}, labels)
+	rollout := newRollout(nil)
+	rollout.Object["status"] = map[string]any{"stableRS": "6f8b9c7d5"}
+	labels, err = plugin.GetPodLabels(rollout)
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"app": "tomcat", RolloutPodTemplateHash: "6f8b9c7d5"}, labels)
+	svc, err = plugin.GetService(rollout)
+	require.Nil(t, err)
+	require.Equal(t, "tomcat", svc.Name)
+
+	suspend, restore, err := plugin.Suspend(newRollout(nil), util.ExchangeModeScale)
+	require.Nil(t, err)
+	require.Equal(t, `{"spec":{"replicas":0}}`, string(suspend))
+	require.Equal(t, `{"spec":{"replicas":3}}`, string(restore))
+	suspend, restore, err = plugin.Suspend(newRollout(nil), util.ExchangeModeSelector)
+	require.Nil(t, err)
+	require.Equal(t, `{"spec":{"paused":true}}`, string(suspend))
+	require.Equal(t, `{"spec":{"paused":false}}`, string(restore))
+}
+
+func TestKnativeService(t *testing.T) {
+	plugin, exists := Get("ksvc")
+	require.True(t, exists)
+	ksvc := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "tomcat", "namespace": "default"},
+		"status":   map[string]any{"latestReadyRevisionName": "tomcat-00002"},
+	}}
+
+	labels, err := plugin.GetPodLabels(ksvc)
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"serving.knative.dev/revision": "tomcat-00002"}, labels)
+	_, _, err = plugin.Suspend(ksvc, util.ExchangeModeScale)
+	require.NotNil(t, err)
+	// revision service is reconciled by knative, changing its selector or endpoints would be reverted
+	_, _, err = plugin.Suspend(ksvc, util.ExchangeModeSelector)
+	require.NotNil(t, err)
+	_, _, err = plugin.Suspend(ksvc, util.ExchangeModeEndpoints)
+	require.NotNil(t, err)
+	suspend, restore, err := plugin.Suspend(ksvc, util.ExchangeModeEphemeral)
+	require.Nil(t, err)
+	require.Equal(t, `{"spec":{"traffic":[{"percent":100,"revisionName":"tomcat-00002"}]}}`, string(suspend))
+	require.Equal(t, `{"spec":{"traffic":null}}`, string(restore))
+}
@@ -2,7 +2,7 @@ package transmission
 
 import (
 	"fmt"
-	"github.com/alibaba/kt-connect/pkg/common"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/service/sshchannel"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
@@ -10,22 +10,23 @@ import (
 	"time"
 )
 
-// ForwardPodToLocal mapping pod port to local port
-func ForwardPodToLocal(exposePorts, podName, privateKey string) (int, error) {
+// ForwardPodToLocal mapping pod port to local port, the returned channel receives local port when port forward is dead
+func ForwardPodToLocal(exposePorts, podName, privateKey string) (int, chan int, error) {
 	log.Info().Msgf("Forwarding pod %s to local via port %s", podName, exposePorts)
 	localSshPort := util.GetRandomTcpPort()
 
-	// port forward pod 22 -> local <random port>
-	if _, err := SetupPortForwardToLocal(podName, common.StandardSshPort, localSshPort); err != nil {
-		return -1, err
+	// port forward pod tunnel port -> local <random port>
+	gone, err := SetupPortForwardToLocal(podName, cluster.ShadowTunnelPort(), localSshPort)
+	if err != nil {
+		return -1, nil, err
 	}
 
-	err := ForwardRemotePortsViaSshTunnel(exposePorts, localSshPort, privateKey)
+	err = ForwardRemotePortsViaSshTunnel(exposePorts, localSshPort, privateKey)
 	if err != nil {
-		return -1, err
+		return -1, nil, err
 	}
 
-	return localSshPort, nil
+	return localSshPort, gone, nil
 }
 
 // ForwardRemotePortsViaSshTunnel forward multiple remote ports to local
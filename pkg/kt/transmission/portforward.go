@@ -3,7 +3,6 @@ package transmission
 import (
 	"fmt"
 	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
-	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
 	"github.com/alibaba/kt-connect/pkg/kt/util"
 	"github.com/rs/zerolog/log"
 	"k8s.io/client-go/tools/portforward"
@@ -12,54 +11,41 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"sync"
 	"time"
 )
 
-// SetupPortForwardToLocal mapping local port to shadow pod ssh port
+// SetupPortForwardToLocal mapping local port to pod port, the returned channel receives local port when forward is dead
 func SetupPortForwardToLocal(podName string, remotePort, localPort int) (chan int, error) {
-	gone := make(chan int)
-	return gone, setupPortForwardToLocal(podName, remotePort, localPort, gone, true)
+	s := newPortForwardSupervisor(podName, remotePort, localPort)
+	supervisors.Store(localPort, s)
+	done, stop, err := s.connect()
+	if err != nil {
+		s.setState(StateDead, err)
+		return s.gone, err
+	}
+	s.setState(StateHealthy, nil)
+	log.Info().Msgf("Port forward local:%d -> pod %s:%d established", localPort, podName, remotePort)
+	go s.run(done, stop)
+	return s.gone, nil
 }
 
-func setupPortForwardToLocal(podName string, remotePort, localPort int, gone chan int, isInitConnect bool) error {
-	ready := make(chan struct{})
-	var ticker *time.Ticker
-	go func() {
-		stop := make(chan struct{})
-		fw, err := createPortForwarder(podName, remotePort, localPort, stop, ready)
-		if err != nil {
-			log.Warn().Err(err).Msgf("Invalid port forward parameter")
-			return
-		}
-		// will hang here
-		err = fw.ForwardPorts()
-		if err != nil {
-			if isInitConnect {
-				log.Error().Err(err).Msgf("Failed to setup port forward local:%d -> pod %s:%d", localPort, podName, remotePort)
-			} else {
-				log.Debug().Err(err).Msgf("Port forward local:%d -> pod %s:%d interrupted", localPort, podName, remotePort)
-			}
-		}
-		if ticker != nil {
-			ticker.Stop()
-		}
-		time.Sleep(time.Duration(opt.Get().Global.PortForwardTimeout) * time.Second)
-		log.Debug().Msgf("Port forward reconnecting ...")
-		_ = setupPortForwardToLocal(podName, remotePort, localPort, gone, false)
-	}()
-
-	select {
-	case <-ready:
-		ticker = cluster.SetupPortForwardHeartBeat(localPort)
-		log.Info().Msgf("Port forward local:%d -> pod %s:%d established", localPort, podName, remotePort)
-		return nil
-	case <-time.After(time.Duration(opt.Get().Global.PortForwardTimeout) * time.Second):
-		return fmt.Errorf("connect to port-forward failed")
-	}
+// forwarder the port forward handler
+type forwarder interface {
+	ForwardPorts() error
 }
 
-// createPortForwarder fetch a port forward handler
-func createPortForwarder(podName string, remotePort, localPort int, stop, ready chan struct{}) (*portforward.PortForwarder, error) {
+const (
+	portForwardTransportWebsocket = "websocket"
+	portForwardTransportSpdy      = "spdy"
+)
+
+// portForwardTransport the transport decided by first port forward
+var portForwardTransport string
+var portForwardTransportLock sync.Mutex
+
+// createPortForwarder fetch a port forward handler, try websocket first and fall back to spdy
+func createPortForwarder(podName string, remotePort, localPort int, stop, ready chan struct{}) (forwarder, error) {
 	apiPath := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", opt.Get().Global.Namespace, podName)
 	log.Debug().Msgf("Request port forward pod:%d -> local:%d via %s", remotePort, localPort, opt.Store.RestConfig.Host)
 	apiUrl, err := parseReqHost(opt.Store.RestConfig.Host, apiPath)
@@ -67,6 +53,29 @@ func createPortForwarder(podName string, remotePort, localPort int, stop, ready
 		return nil, err
 	}
 
+	portForwardTransportLock.Lock()
+	defer portForwardTransportLock.Unlock()
+	if portForwardTransport != portForwardTransportSpdy {
+		timeout := time.Duration(opt.Get().Global.PortForwardTimeout) * time.Second
+		wsf, err2 := newWebsocketForwarder(opt.Store.RestConfig, apiUrl, remotePort, localPort, stop, ready, timeout)
+		if err2 == nil {
+			err2 = wsf.Probe()
+		}
+		if err2 == nil {
+			if portForwardTransport == "" {
+				log.Info().Msgf("Using websocket port forward transport")
+				portForwardTransport = portForwardTransportWebsocket
+			}
+			return wsf, nil
+		}
+		if portForwardTransport == "" {
+			log.Info().Msgf("Websocket port forward unavailable (%s), fall back to spdy transport", err2)
+			portForwardTransport = portForwardTransportSpdy
+		} else {
+			log.Debug().Err(err2).Msgf("Websocket port forward failed, try spdy transport")
+		}
+	}
+
 	transport, upgrader, err := spdy.RoundTripperFor(opt.Store.RestConfig)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,308 @@
+package transmission
+
+import (
+	"fmt"
+	opt "github.com/alibaba/kt-connect/pkg/kt/command/options"
+	"github.com/alibaba/kt-connect/pkg/kt/service/cluster"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/rs/zerolog/log"
+	coreV1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ForwardState state of a port forward
+type ForwardState string
+
+const (
+	// StateConnecting port forward is being established
+	StateConnecting ForwardState = "connecting"
+	// StateHealthy port forward established and heartbeat works
+	StateHealthy ForwardState = "healthy"
+	// StateDegraded port forward established but heartbeat failed
+	StateDegraded ForwardState = "degraded"
+	// StateDead port forward given up after too many failures
+	StateDead ForwardState = "dead"
+)
+
+const (
+	backoffInitial = time.Second
+	backoffMax     = time.Minute
+	// maxReconnectFailures consecutive reconnect failures before marking forward as dead
+	maxReconnectFailures = 10
+	// maxHeartbeatFailures consecutive heartbeat failures before forcing reconnect
+	maxHeartbeatFailures = 3
+)
+
+// PortForwardStatus status of a port forward
+type PortForwardStatus struct {
+	PodName    string
+	RemotePort int
+	LocalPort  int
+	State      ForwardState
+	Failures   int
+	LastError  string
+	Since      time.Time
+}
+
+// supervisors supervisors of port forwards in current process, keyed by local port
+var supervisors sync.Map
+
+// GetPortForwardStatus status of port forward listening on specified local port
+func GetPortForwardStatus(localPort int) (PortForwardStatus, bool) {
+	if s, exists := supervisors.Load(localPort); exists {
+		return s.(*portForwardSupervisor).getStatus(), true
+	}
+	return PortForwardStatus{}, false
+}
+
+// GetPortForwardStatuses status of all port forwards in current process, ordered by local port
+func GetPortForwardStatuses() []PortForwardStatus {
+	var statuses []PortForwardStatus
+	supervisors.Range(func(_, s any) bool {
+		statuses = append(statuses, s.(*portForwardSupervisor).getStatus())
+		return true
+	})
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].LocalPort < statuses[j].LocalPort
+	})
+	return statuses
+}
+
+// portForwardSupervisor keep port forward alive and track its state
+type portForwardSupervisor struct {
+	status PortForwardStatus
+	labels map[string]string
+	// owner uid of the controller which created the pod, only its pods could replace the previous one
+	owner types.UID
+	gone  chan int
+	lock  sync.RWMutex
+}
+
+func newPortForwardSupervisor(podName string, remotePort, localPort int) *portForwardSupervisor {
+	s := &portForwardSupervisor{
+		status: PortForwardStatus{
+			PodName:    podName,
+			RemotePort: remotePort,
+			LocalPort:  localPort,
+			State:      StateConnecting,
+			Since:      time.Now(),
+		},
+		gone: make(chan int, 1),
+	}
+	if pod, err := cluster.Ins().GetPod(podName, opt.Get().Global.Namespace); err == nil {
+		s.labels = pod.Labels
+		if owner := metav1.GetControllerOf(pod); owner != nil {
+			s.owner = owner.UID
+		}
+	} else {
+		log.Debug().Err(err).Msgf("Failed to fetch labels and owner of pod %s", podName)
+	}
+	return s
+}
+
+func (s *portForwardSupervisor) getStatus() PortForwardStatus {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.status
+}
+
+func (s *portForwardSupervisor) setState(state ForwardState, err error) {
+	s.lock.Lock()
+	previous := s.status.State
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+	if previous == state {
+		s.lock.Unlock()
+		return
+	}
+	s.status.State = state
+	s.status.Since = time.Now()
+	st := s.status
+	s.lock.Unlock()
+
+	msg := fmt.Sprintf("Port forward local:%d -> pod %s:%d %s -> %s", st.LocalPort, st.PodName, st.RemotePort, previous, state)
+	switch state {
+	case StateDegraded:
+		log.Warn().Msg(msg)
+	case StateDead:
+		log.Error().Msgf("%s, last error: %s", msg, st.LastError)
+	default:
+		log.Debug().Msg(msg)
+	}
+}
+
+// connect setup port forward once, return a channel which closed when the port forward interrupted
+func (s *portForwardSupervisor) connect() (chan error, chan struct{}, error) {
+	st := s.getStatus()
+	stop := make(chan struct{})
+	ready := make(chan struct{})
+	fw, err := createPortForwarder(st.PodName, st.RemotePort, st.LocalPort, stop, ready)
+	if err != nil {
+		return nil, nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		// will hang here
+		done <- fw.ForwardPorts()
+	}()
+	select {
+	case <-ready:
+		return done, stop, nil
+	case err = <-done:
+		if err == nil {
+			err = fmt.Errorf("port forward closed")
+		}
+		return nil, nil, err
+	case <-time.After(time.Duration(opt.Get().Global.PortForwardTimeout) * time.Second):
+		close(stop)
+		return nil, nil, fmt.Errorf("connect to port-forward failed")
+	}
+}
+
+// watch check port forward health until it interrupted
+func (s *portForwardSupervisor) watch(done chan error, stop chan struct{}) error {
+	ticker := time.NewTicker(util.PortForwardHeartBeatIntervalSec*time.Second - util.RandomSeconds(0, 5))
+	defer ticker.Stop()
+	heartbeatFailures := 0
+	for {
+		select {
+		case err := <-done:
+			if err == nil {
+				err = fmt.Errorf("port forward closed")
+			}
+			return err
+		case <-ticker.C:
+			conn, err := net.Dial("tcp", fmt.Sprintf(":%d", s.getStatus().LocalPort))
+			if err != nil {
+				heartbeatFailures++
+				s.setState(StateDegraded, err)
+				if heartbeatFailures >= maxHeartbeatFailures {
+					close(stop)
+					return fmt.Errorf("heartbeat failed %d times", heartbeatFailures)
+				}
+				continue
+			}
+			_ = conn.Close()
+			heartbeatFailures = 0
+			s.setState(StateHealthy, nil)
+		}
+	}
+}
+
+// run keep reconnecting port forward with exponential backoff
+func (s *portForwardSupervisor) run(done chan error, stop chan struct{}) {
+	failures := 0
+	for {
+		err := s.watch(done, stop)
+		log.Debug().Err(err).Msgf("Port forward local:%d interrupted", s.getStatus().LocalPort)
+		for {
+			s.setState(StateConnecting, err)
+			s.lock.Lock()
+			s.status.Failures = failures
+			s.lock.Unlock()
+			if failures >= maxReconnectFailures {
+				s.setState(StateDead, err)
+				s.gone <- s.getStatus().LocalPort
+				return
+			}
+			time.Sleep(backoffDuration(failures))
+			failures++
+			if err = s.resolvePod(); err != nil {
+				s.setState(StateDead, err)
+				s.gone <- s.getStatus().LocalPort
+				return
+			}
+			if done, stop, err = s.connect(); err == nil {
+				failures = 0
+				s.lock.Lock()
+				s.status.Failures = 0
+				s.lock.Unlock()
+				s.setState(StateHealthy, nil)
+				log.Info().Msgf("Port forward local:%d -> pod %s:%d re-established",
+					s.getStatus().LocalPort, s.getStatus().PodName, s.getStatus().RemotePort)
+				break
+			}
+			log.Debug().Err(err).Msgf("Port forward reconnect failed (%d times)", failures)
+		}
+	}
+}
+
+// resolvePod find replacement pod created by the same owner when previous pod no longer exists,
+// pods of other owners (e.g. shadow pod of another developer) must never be used
+func (s *portForwardSupervisor) resolvePod() error {
+	st := s.getStatus()
+	pod, err := cluster.Ins().GetPod(st.PodName, opt.Get().Global.Namespace)
+	if err == nil && pod.DeletionTimestamp == nil {
+		return nil
+	}
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		// api server may be temporarily unreachable, keep retrying the same pod
+		log.Debug().Err(err).Msgf("Failed to check pod %s", st.PodName)
+		return nil
+	}
+	if s.owner == "" || len(s.labels) == 0 {
+		return fmt.Errorf("pod %s is gone and it has no owner to find replacement", st.PodName)
+	}
+	pods, err := cluster.Ins().GetPodsByLabel(s.labels, opt.Get().Global.Namespace)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to list pods for replacement of %s", st.PodName)
+		return nil
+	}
+	name := pickReplacementPod(pods.Items, st.PodName, s.owner)
+	if name == "" {
+		if isPodPending(pods.Items, s.owner) {
+			log.Debug().Msgf("Replacement of pod %s is not running yet", st.PodName)
+			return nil
+		}
+		return fmt.Errorf("pod %s is gone and no replacement pod found", st.PodName)
+	}
+	log.Info().Msgf("Pod %s replaced by %s, port forward local:%d redirected", st.PodName, name, st.LocalPort)
+	s.lock.Lock()
+	s.status.PodName = name
+	s.lock.Unlock()
+	return nil
+}
+
+// pickReplacementPod return name of a running pod owned by specified owner other than the previous one
+func pickReplacementPod(pods []coreV1.Pod, previous string, owner types.UID) string {
+	for _, p := range pods {
+		if p.Name != previous && isOwnedBy(p, owner) && p.DeletionTimestamp == nil && p.Status.Phase == coreV1.PodRunning {
+			return p.Name
+		}
+	}
+	return ""
+}
+
+// isPodPending check whether any pod of specified owner is still starting
+func isPodPending(pods []coreV1.Pod, owner types.UID) bool {
+	for _, p := range pods {
+		if isOwnedBy(p, owner) && p.DeletionTimestamp == nil && p.Status.Phase == coreV1.PodPending {
+			return true
+		}
+	}
+	return false
+}
+
+func isOwnedBy(pod coreV1.Pod, owner types.UID) bool {
+	ref := metav1.GetControllerOf(&pod)
+	return ref != nil && ref.UID == owner
+}
+
+// backoffDuration exponential backoff with upper limit
+func backoffDuration(failures int) time.Duration {
+	duration := backoffInitial
+	for i := 0; i < failures && duration < backoffMax; i++ {
+		duration *= 2
+	}
+	if duration > backoffMax {
+		duration = backoffMax
+	}
+	return duration
+}
@@ -0,0 +1,74 @@
+package transmission
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"testing"
+	"time"
+)
+
+func Test_backoffDuration(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: time.Second},
+		{failures: 1, want: 2 * time.Second},
+		{failures: 5, want: 32 * time.Second},
+		{failures: 6, want: time.Minute},
+		{failures: 100, want: time.Minute},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, backoffDuration(tt.failures))
+	}
+}
+
+func Test_pickReplacementPod(t *testing.T) {
+	now := metav1.Now()
+	isController := true
+	ownedBy := func(uid types.UID) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", UID: uid, Controller: &isController}}
+	}
+	pods := []coreV1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "old", OwnerReferences: ownedBy("mine")}, Status: coreV1.PodStatus{Phase: coreV1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "others", OwnerReferences: ownedBy("others")}, Status: coreV1.PodStatus{Phase: coreV1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "orphan"}, Status: coreV1.PodStatus{Phase: coreV1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "terminating", OwnerReferences: ownedBy("mine"), DeletionTimestamp: &now}, Status: coreV1.PodStatus{Phase: coreV1.PodRunning}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pending", OwnerReferences: ownedBy("mine")}, Status: coreV1.PodStatus{Phase: coreV1.PodPending}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "new", OwnerReferences: ownedBy("mine")}, Status: coreV1.PodStatus{Phase: coreV1.PodRunning}},
+	}
+	require.Equal(t, "new", pickReplacementPod(pods, "old", "mine"))
+	require.Equal(t, "", pickReplacementPod(pods[:5], "old", "mine"))
+	require.Equal(t, "", pickReplacementPod(pods, "old", "absent"))
+	require.True(t, isPodPending(pods, "mine"))
+	require.False(t, isPodPending(pods, "others"))
+}
+
+func TestPortForwardSupervisor_setState(t *testing.T) {
+	s := &portForwardSupervisor{status: PortForwardStatus{LocalPort: 1, State: StateConnecting}}
+	s.setState(StateHealthy, nil)
+	require.Equal(t, StateHealthy, s.getStatus().State)
+	s.setState(StateDegraded, errors.New("dial failed"))
+	status := s.getStatus()
+	require.Equal(t, StateDegraded, status.State)
+	require.Equal(t, "dial failed", status.LastError)
+}
+
+func TestGetPortForwardStatuses(t *testing.T) {
+	supervisors.Store(2002, &portForwardSupervisor{status: PortForwardStatus{LocalPort: 2002, State: StateDead}})
+	supervisors.Store(2001, &portForwardSupervisor{status: PortForwardStatus{LocalPort: 2001, State: StateHealthy}})
+	defer supervisors.Delete(2001)
+	defer supervisors.Delete(2002)
+	status, exists := GetPortForwardStatus(2002)
+	require.True(t, exists)
+	require.Equal(t, StateDead, status.State)
+	_, exists = GetPortForwardStatus(2003)
+	require.False(t, exists)
+	statuses := GetPortForwardStatuses()
+	require.Len(t, statuses, 2)
+	require.Equal(t, 2001, statuses[0].LocalPort)
+	require.Equal(t, 2002, statuses[1].LocalPort)
+}
@@ -0,0 +1,258 @@
+package transmission
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/kt/util"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"io"
+	"k8s.io/client-go/rest"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// portForwardProtocolV4 websocket sub-protocol of kubelet port forward
+	portForwardProtocolV4 = "v4.channel.k8s.io"
+	dataChannel           = 0
+	errorChannel          = 1
+)
+
+// websocketForwarder port forward via websocket, each local connection uses an independent websocket
+type websocketForwarder struct {
+	url        string
+	header     http.Header
+	dialer     *websocket.Dialer
+	remotePort int
+	localPort  int
+	stop       chan struct{}
+	ready      chan struct{}
+}
+
+func newWebsocketForwarder(config *rest.Config, apiUrl *url.URL, remotePort, localPort int, stop, ready chan struct{},
+	timeout time.Duration) (*websocketForwarder, error) {
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+	header, err := getAuthHeader(config, apiUrl)
+	if err != nil {
+		return nil, err
+	}
+	wsUrl := *apiUrl
+	if wsUrl.Scheme == "https" {
+		wsUrl.Scheme = "wss"
+	} else {
+		wsUrl.Scheme = "ws"
+	}
+	wsUrl.RawQuery = fmt.Sprintf("port=%d", remotePort)
+	dialer := &websocket.Dialer{
+		TLSClientConfig:  tlsConfig,
+		Subprotocols:     []string{portForwardProtocolV4},
+		HandshakeTimeout: timeout,
+		Proxy:            http.ProxyFromEnvironment,
+	}
+	if config.Proxy != nil {
+		dialer.Proxy = config.Proxy
+	}
+	return &websocketForwarder{
+		url:        wsUrl.String(),
+		header:     header,
+		dialer:     dialer,
+		remotePort: remotePort,
+		localPort:  localPort,
+		stop:       stop,
+		ready:      ready,
+	}, nil
+}
+
+// Probe check whether websocket port forward is available
+func (f *websocketForwarder) Probe() error {
+	ws, err := f.dial()
+	if err != nil {
+		return err
+	}
+	return ws.Close()
+}
+
+// ForwardPorts listen local port and forward each connection via websocket, block until stopped or failed
+func (f *websocketForwarder) ForwardPorts() error {
+	var listeners []net.Listener
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, fmt.Sprintf("%d", f.localPort)))
+		if err != nil {
+			log.Debug().Err(err).Msgf("Unable to listen on %s:%d", host, f.localPort)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return fmt.Errorf("unable to listen on local port %d", f.localPort)
+	}
+
+	failure := make(chan error, 1)
+	for _, listener := range listeners {
+		go f.acceptLoop(listener, failure)
+	}
+	close(f.ready)
+
+	var err error
+	select {
+	case <-f.stop:
+	case err = <-failure:
+	}
+	for _, listener := range listeners {
+		_ = listener.Close()
+	}
+	return err
+}
+
+func (f *websocketForwarder) acceptLoop(listener net.Listener, failure chan error) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			if err2 := f.handleConnection(conn); err2 != nil {
+				select {
+				case failure <- err2:
+				default:
+				}
+			}
+		}()
+	}
+}
+
+// handleConnection forward a local connection, only return error when the websocket cannot be established
+func (f *websocketForwarder) handleConnection(conn net.Conn) error {
+	defer conn.Close()
+	ws, err := f.dial()
+	if err != nil {
+		_, _ = util.BackgroundLogger.Write([]byte(fmt.Sprintf("Failed to create websocket port forward: %s%s", err, util.Eol)))
+		return err
+	}
+	defer ws.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.copyFromWebsocket(ws, conn)
+	}()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err2 := conn.Read(buf)
+		if n > 0 {
+			if err3 := ws.WriteMessage(websocket.BinaryMessage, append([]byte{dataChannel}, buf[:n]...)); err3 != nil {
+				break
+			}
+		}
+		if err2 != nil {
+			break
+		}
+	}
+	_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+	return nil
+}
+
+// copyFromWebsocket the first 2 bytes of data and error channel are the port number
+func (f *websocketForwarder) copyFromWebsocket(ws *websocket.Conn, conn net.Conn) {
+	portSkipped := map[byte]bool{}
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			_ = conn.Close()
+			return
+		}
+		if len(message) == 0 {
+			continue
+		}
+		channel, data := message[0], message[1:]
+		if !portSkipped[channel] {
+			if len(data) < 2 {
+				continue
+			}
+			if port := binary.LittleEndian.Uint16(data[:2]); int(port) != f.remotePort {
+				log.Warn().Msgf("Unexpected port %d in websocket port forward stream", port)
+			}
+			portSkipped[channel] = true
+			data = data[2:]
+		}
+		if len(data) == 0 {
+			continue
+		}
+		switch channel {
+		case dataChannel:
+			if _, err = conn.Write(data); err != nil {
+				return
+			}
+		case errorChannel:
+			_, _ = util.BackgroundLogger.Write([]byte(fmt.Sprintf("Port forward error: %s%s", string(data), util.Eol)))
+			_ = conn.Close()
+			return
+		}
+	}
+}
+
+func (f *websocketForwarder) dial() (*websocket.Conn, error) {
+	ws, resp, err := f.dialer.Dial(f.url, f.header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("websocket handshake failed with status %s", resp.Status)
+		}
+		return nil, err
+	}
+	if ws.Subprotocol() != portForwardProtocolV4 {
+		_ = ws.Close()
+		return nil, fmt.Errorf("unsupported websocket sub-protocol '%s'", ws.Subprotocol())
+	}
+	return ws, nil
+}
+
+// headerCapture a fake round tripper records the request headers
+type headerCapture struct {
+	header http.Header
+	lock   sync.Mutex
+}
+
+func (h *headerCapture) RoundTrip(req *http.Request) (*http.Response, error) {
+	h.lock.Lock()
+	h.header = req.Header.Clone()
+	h.lock.Unlock()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+// getAuthHeader fetch authentication headers (bearer token, basic auth, exec plugin, etc.) of the rest config
+func getAuthHeader(config *rest.Config, apiUrl *url.URL) (http.Header, error) {
+	capture := &headerCapture{}
+	rt, err := rest.HTTPWrappersForConfig(config, capture)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, apiUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	capture.lock.Lock()
+	defer capture.lock.Unlock()
+	return capture.header, nil
+}
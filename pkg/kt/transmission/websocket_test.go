@@ -0,0 +1,96 @@
+package transmission
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"io"
+	"k8s.io/client-go/rest"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// startPortForwardServer emulate the v4.channel.k8s.io port forward protocol of api server, echo back all data
+func startPortForwardServer(t *testing.T, token string) *httptest.Server {
+	upgrader := websocket.Upgrader{Subprotocols: []string{portForwardProtocolV4}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		var port uint16
+		_, _ = fmt.Sscanf(r.URL.Query().Get("port"), "%d", &port)
+		for _, channel := range []byte{dataChannel, errorChannel} {
+			header := []byte{channel, 0, 0}
+			binary.LittleEndian.PutUint16(header[1:], port)
+			_ = ws.WriteMessage(websocket.BinaryMessage, header)
+		}
+		for {
+			_, message, err2 := ws.ReadMessage()
+			if err2 != nil {
+				return
+			}
+			if len(message) > 1 && message[0] == dataChannel {
+				_ = ws.WriteMessage(websocket.BinaryMessage, message)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebsocketForwarder(t *testing.T) {
+	server := startPortForwardServer(t, "abc")
+	apiUrl, err := parseReqHost(server.URL, "/api/v1/namespaces/default/pods/test/portforward")
+	require.Nil(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	localPort := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+
+	stop := make(chan struct{})
+	ready := make(chan struct{})
+	fw, err := newWebsocketForwarder(&rest.Config{Host: server.URL, BearerToken: "abc"}, apiUrl, 8080, localPort,
+		stop, ready, 5*time.Second)
+	require.Nil(t, err)
+	require.Nil(t, fw.Probe())
+	res := make(chan error)
+	go func() {
+		res <- fw.ForwardPorts()
+	}()
+	<-ready
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	require.Nil(t, err)
+	_, err = conn.Write([]byte("hello websocket"))
+	require.Nil(t, err)
+	data := make([]byte, 15)
+	_, err = io.ReadFull(conn, data)
+	require.Nil(t, err)
+	require.Equal(t, "hello websocket", string(data))
+	_ = conn.Close()
+
+	close(stop)
+	require.Nil(t, <-res)
+}
+
+func TestWebsocketForwarderUnauthorized(t *testing.T) {
+	server := startPortForwardServer(t, "abc")
+	apiUrl, _ := url.Parse(server.URL + "/api/v1/namespaces/default/pods/test/portforward")
+	fw, err := newWebsocketForwarder(&rest.Config{Host: server.URL, BearerToken: "wrong"}, apiUrl, 8080, 0,
+		make(chan struct{}), make(chan struct{}), 5*time.Second)
+	require.Nil(t, err)
+	err = fw.Probe()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "401")
+}
@@ -5,6 +5,8 @@ import "fmt"
 const (
 	// EnvKubeConfig environment variable for kube config file
 	EnvKubeConfig = "KUBECONFIG"
+	// EnvMountRoot environment variable for local directory of files mounted by target pod
+	EnvMountRoot = "KT_MOUNT_ROOT"
 
 	// KubernetesToolkit name of this tool
 	KubernetesToolkit = "kt"
@@ -36,10 +38,20 @@ const (
 	ExchangeModeEphemeral = "ephemeral"
 	// ExchangeModeSelector selector mode
 	ExchangeModeSelector = "selector"
+	// ExchangeModeEndpoints endpoints mode
+	ExchangeModeEndpoints = "endpoints"
 	// MeshModeAuto auto mode
 	MeshModeAuto = "auto"
 	// MeshModeManual manual mode
 	MeshModeManual = "manual"
+	// MeshModeIstio istio mode
+	MeshModeIstio = "istio"
+	// MeshModeMirror mirror mode
+	MeshModeMirror = "mirror"
+	// TransportSsh tunnel via ssh
+	TransportSsh = "ssh"
+	// TransportMux tunnel via kt mux protocol
+	TransportMux = "mux"
 	// DnsModeLocalDns local dns mode
 	DnsModeLocalDns = "localDNS"
 	// DnsModePodDns pod dns mode
@@ -67,8 +79,12 @@ const (
 	KtRefCount = "kt-ref-count"
 	// KtLastHeartBeat annotation used for timestamp of last heart beat
 	KtLastHeartBeat = "kt-last-heart-beat"
-	// KtLock annotation used for avoid auto mesh conflict
+	// KtLock annotation used for avoid auto mesh conflict (legacy, replaced by lock lease)
 	KtLock = "kt-lock"
+	// KtLease annotation used for record session leases which keep the resource alive
+	KtLease = "kt-lease"
+	// KtEndpoints annotation used for record endpoints of service before taken over
+	KtEndpoints = "kt-endpoints"
 
 	// PostfixRsaKey postfix of local private key name
 	PostfixRsaKey = ".key"
@@ -88,6 +104,8 @@ const (
 	DefaultContainer = "standalone"
 	// StuntmanServiceSuffix suffix of stuntman service name
 	StuntmanServiceSuffix = "-kt-stuntman"
+	// FallbackSliceSuffix suffix of endpoint slice name of original pods used when shadow pod not ready
+	FallbackSliceSuffix = "-kt-fallback"
 	// RouterPodSuffix suffix of router pod name
 	RouterPodSuffix = "-kt-router"
 	// ExchangePodInfix exchange pod name
@@ -106,6 +124,30 @@ const (
 	RolePreviewShadow = "shadow-preview"
 	// RoleRouter router role
 	RoleRouter = "router"
+	// RoleSession session lease role
+	RoleSession = "session"
+	// RoleLock lock lease role
+	RoleLock = "lock"
+	// SessionLeasePrefix prefix of session lease name
+	SessionLeasePrefix = "kt-session-"
+	// LockLeaseSuffix suffix of service lock lease name
+	LockLeaseSuffix = "-kt-lock"
+	// KtSessionGroup api group of KtSession resource
+	KtSessionGroup = "kt.alibaba.com"
+	// KtSessionVersion api version of KtSession resource
+	KtSessionVersion = "v1alpha1"
+	// KtSessionResource plural name of KtSession resource
+	KtSessionResource = "ktsessions"
+	// KtSessionKind kind of KtSession resource
+	KtSessionKind = "KtSession"
+	// KtSessionFinalizer finalizer used for recover cluster before KtSession removed
+	KtSessionFinalizer = "kt.alibaba.com/recover"
+	// SessionPhasePending KtSession not reconciled yet
+	SessionPhasePending = "Pending"
+	// SessionPhaseApplied changes in KtSession applied to cluster
+	SessionPhaseApplied = "Applied"
+	// SessionPhaseFailed failed to apply changes in KtSession
+	SessionPhaseFailed = "Failed"
 	// SortByName birdseye sort
 	SortByName = "name"
 	// SortByStatus birdseye sort
@@ -121,6 +163,12 @@ const (
 
 	// ResourceHeartBeatIntervalMinus interval of resource heart beat
 	ResourceHeartBeatIntervalMinus = 2
+	// SessionLeaseDurationSec session lease expires if not renewed within this duration
+	SessionLeaseDurationSec = (ResourceHeartBeatIntervalMinus*2 + 1) * 60
+	// LockLeaseDurationSec service lock lease expires if not renewed within this duration
+	LockLeaseDurationSec = 3 * 60
+	// SessionApplyTimeoutSec seconds to wait for controller applying KtSession changes
+	SessionApplyTimeoutSec = 60
 	// PortForwardHeartBeatIntervalSec interval of port-forward heart beat
 	PortForwardHeartBeatIntervalSec = 60
 
@@ -132,5 +180,8 @@ var (
 	KtPidDir = fmt.Sprintf("%s/pid", KtHome)
 	KtLockDir = fmt.Sprintf("%s/lock", KtHome)
 	KtProfileDir = fmt.Sprintf("%s/profile", KtHome)
+	KtJournalDir = fmt.Sprintf("%s/journal", KtHome)
+	KtMountDir = fmt.Sprintf("%s/mount", KtHome)
 	KtConfigFile = fmt.Sprintf("%s/config", KtHome)
+	KtMeshVersionFile = fmt.Sprintf("%s/mesh-version", KtHome)
 )
\ No newline at end of file
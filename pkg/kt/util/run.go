@@ -39,6 +39,14 @@ func BackgroundRun(cmd *exec.Cmd, name string, res chan error) error {
 	return nil
 }
 
+// ShellCommand create cmd which runs the command line with system shell
+func ShellCommand(cmdline string) *exec.Cmd {
+	if IsWindows() {
+		return exec.Command("cmd", "/C", cmdline)
+	}
+	return exec.Command("sh", "-c", cmdline)
+}
+
 // CanRun check whether a command can execute successful
 func CanRun(cmd *exec.Cmd) bool {
 	return cmd.Run() == nil
@@ -0,0 +1,139 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// FaultTargetVersion faults apply to requests routed to the mesh version
+	FaultTargetVersion = "version"
+	// FaultTargetStuntman faults apply to requests routed to original pods
+	FaultTargetStuntman = "stuntman"
+)
+
+// percentPattern nginx split_clients accepts percentage with at most 2 decimal places
+var percentPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]{1,2})?$`)
+
+// Fault faults injected by router, owned by a mesh version and removed together with it
+type Fault struct {
+	Version      string
+	Stuntman     bool
+	Delay        int
+	DelayPercent float64
+	Abort        int
+	AbortPercent float64
+	Drop         bool
+	DropPercent  float64
+}
+
+// ParseFault parse fault spec like 'delay=500ms,abort=503:10%,drop=1%,target=stuntman'
+func ParseFault(version, spec string) (*Fault, error) {
+	fault := &Fault{Version: version}
+	for _, item := range strings.Split(spec, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		value := ""
+		if len(kv) == 2 {
+			value = kv[1]
+		}
+		var err error
+		switch kv[0] {
+		case "delay":
+			var duration string
+			duration, fault.DelayPercent, err = splitPercent(value)
+			if err == nil {
+				var d time.Duration
+				if d, err = time.ParseDuration(duration); err == nil && d <= 0 {
+					err = fmt.Errorf("should be positive")
+				}
+				fault.Delay = int(d.Milliseconds())
+			}
+		case "abort":
+			var status string
+			status, fault.AbortPercent, err = splitPercent(value)
+			if err == nil {
+				if fault.Abort, err = strconv.Atoi(status); err == nil && (fault.Abort < 400 || fault.Abort > 599) {
+					err = fmt.Errorf("should be a http error status")
+				}
+			}
+		case "drop":
+			fault.Drop = true
+			fault.DropPercent, err = parsePercent(value)
+		case "target":
+			if value != FaultTargetVersion && value != FaultTargetStuntman {
+				err = fmt.Errorf("should be '%s' or '%s'", FaultTargetVersion, FaultTargetStuntman)
+			}
+			fault.Stuntman = value == FaultTargetStuntman
+		default:
+			return nil, fmt.Errorf("unknown fault '%s'", kv[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid fault '%s': %s", item, err)
+		}
+	}
+	if fault.Delay == 0 && fault.Abort == 0 && !fault.Drop {
+		return nil, fmt.Errorf("no fault specified in '%s'", spec)
+	}
+	return fault, nil
+}
+
+// FaultIndex index of faults applying to requests of specified version, -1 if not exist
+func (c *KtConf) FaultIndex(version string) int {
+	for i, f := range c.Faults {
+		if f.Version == version && !f.Stuntman {
+			return i
+		}
+	}
+	return -1
+}
+
+// StuntmanFaultIndex index of faults applying to requests to original pods, -1 if not exist
+func (c *KtConf) StuntmanFaultIndex() int {
+	for i, f := range c.Faults {
+		if f.Stuntman {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasDelay whether any fault delays requests
+func (c *KtConf) HasDelay() bool {
+	for _, f := range c.Faults {
+		if f.Delay > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPercent split value like '503:10%', percent is 100 if not specified
+func splitPercent(value string) (string, float64, error) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], 100, nil
+	}
+	percent, err := parsePercent(parts[1])
+	return parts[0], percent, err
+}
+
+func parsePercent(value string) (float64, error) {
+	if value == "" {
+		return 100, nil
+	}
+	if !strings.HasSuffix(value, "%") {
+		return 0, fmt.Errorf("percentage '%s' should end with '%%'", value)
+	}
+	number := strings.TrimSuffix(value, "%")
+	if !percentPattern.MatchString(number) {
+		return 0, fmt.Errorf("percentage '%s' should be a number with at most 2 decimal places", value)
+	}
+	percent, err := strconv.ParseFloat(number, 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return 0, fmt.Errorf("percentage '%s' should between 0%% and 100%%", value)
+	}
+	return percent, nil
+}
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseFault(t *testing.T) {
+	fault, err := ParseFault("abc", "delay=500ms,abort=503:10%")
+	require.Nil(t, err)
+	require.Equal(t, Fault{Version: "abc", Delay: 500, DelayPercent: 100, Abort: 503, AbortPercent: 10}, *fault)
+
+	fault, err = ParseFault("abc", "drop=0.5%,target=stuntman")
+	require.Nil(t, err)
+	require.Equal(t, Fault{Version: "abc", Stuntman: true, Drop: true, DropPercent: 0.5}, *fault)
+
+	fault, err = ParseFault("abc", "abort=500:33.33%")
+	require.Nil(t, err)
+	require.Equal(t, 33.33, fault.AbortPercent)
+
+	for _, spec := range []string{"", "target=stuntman", "delay=-1s", "abort=200", "abort=503:10", "drop=120%",
+		"target=all", "timeout=1s", "drop=33.333%", "drop=1e-5%", "drop=0%", "abort=503:.5%"} {
+		_, err = ParseFault("abc", spec)
+		require.NotNil(t, err, spec)
+	}
+}
@@ -13,13 +13,10 @@ var routeTemplate string
 
 const pathRouteConf = "/etc/nginx/conf.d/route.conf"
 
+// WriteAndReloadRouteConf render route configuration and let nginx reload it,
+// requests are all sent to original pods if no version left, e.g. all removed by watchdog
 func WriteAndReloadRouteConf(ktConf *KtConf) error {
-	var err error
-	if len(ktConf.Versions) > 0 {
-		err = writeRouteConf(ktConf)
-	} else {
-		err = removeRouteConf()
-	}
+	err := WriteRouteConf(ktConf)
 	if err != nil {
 		return err
 	}
@@ -42,7 +39,8 @@ func reloadRouteConf() error {
 	return nil
 }
 
-func writeRouteConf(ktConf *KtConf) error {
+// WriteRouteConf render route configuration without reloading nginx
+func WriteRouteConf(ktConf *KtConf) error {
 	tmpl, err := template.New("route").Parse(routeTemplate)
 	if err != nil {
 		return fmt.Errorf("failed to load route template: %s", err)
@@ -55,17 +53,10 @@ func writeRouteConf(ktConf *KtConf) error {
 	}
 	defer routeConfFile.Close()
 
+	ktConf.SetProxyDefaults()
 	err = tmpl.Execute(routeConfFile, ktConf)
 	if err != nil {
 		return fmt.Errorf("failed to generate route configuration: %s", err)
 	}
 	return nil
 }
-
-func removeRouteConf() error {
-	err := os.Remove(pathRouteConf)
-	if err != nil {
-		return fmt.Errorf("failed to remove route configuration: %s", err)
-	}
-	return nil
-}
@@ -0,0 +1,104 @@
+package router
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestRouteTemplate(t *testing.T) {
+	tmpl, err := template.New("route").Parse(routeTemplate)
+	require.Nil(t, err)
+	var buf strings.Builder
+	ktConf := &KtConf{
+		Service:  "tomcat",
+		Ports:    [][]string{{"80", "8080"}},
+		Header:   "version",
+		Versions: []string{"abc"},
+		Mirrors:  []string{"xyz"},
+	}
+	ktConf.SetProxyDefaults()
+	require.Nil(t, tmpl.Execute(&buf, ktConf))
+	conf := buf.String()
+	require.Contains(t, conf, `if ($kt_version = "abc")`)
+	require.Contains(t, conf, "location = "+SessionPath+" {")
+	require.Contains(t, conf, "map $arg_version $kt_session_version {\n    default  \"\";\n\n    \"abc\"  \"abc\";\n\n}")
+	require.Contains(t, conf, "\"\"  $cookie_kt_version;")
+	require.Contains(t, conf, `add_header  Set-Cookie "kt_version=$kt_session_version; Path=/; HttpOnly; SameSite=Lax";`)
+	require.Contains(t, conf, "proxy_pass  http://tomcat-kt-mesh-abc-80;")
+	require.Contains(t, conf, "upstream tomcat-kt-mesh-xyz-80 {")
+	require.Contains(t, conf, "mirror  /kt_mirror_xyz;")
+	require.Contains(t, conf, "proxy_read_timeout 10s;\n        proxy_send_timeout 10s;\n        proxy_pass  http://tomcat-kt-mesh-xyz-80$request_uri;")
+	require.NotContains(t, conf, `$kt_version = "xyz"`)
+	require.Contains(t, conf, "client_max_body_size  0;")
+	require.Contains(t, conf, "proxy_read_timeout  1h;")
+	require.Contains(t, conf, "proxy_set_header  Connection $kt_connection_upgrade;")
+}
+
+func TestRouteTemplateWithFaults(t *testing.T) {
+	tmpl, err := template.New("route").Parse(routeTemplate)
+	require.Nil(t, err)
+	var buf strings.Builder
+	require.Nil(t, tmpl.Execute(&buf, &KtConf{
+		Service:  "tomcat",
+		Ports:    [][]string{{"80", "8080"}},
+		Header:   "version",
+		Versions: []string{"abc", "def"},
+		Faults: []Fault{
+			{Version: "abc", Delay: 500, DelayPercent: 100, Abort: 503, AbortPercent: 10},
+			{Version: "def", Stuntman: true, Drop: true, DropPercent: 5},
+		},
+	}))
+	conf := buf.String()
+	require.Contains(t, conf, "js_import  kt_fault from /etc/nginx/kt_fault.js;")
+	require.Contains(t, conf, "100%  500;")
+	require.Contains(t, conf, "split_clients \"${request_id}abort\" $kt_abort_0 {\n    10%  1;")
+	require.Contains(t, conf, "split_clients \"${request_id}drop\" $kt_drop_1 {\n    5%  1;")
+	require.Contains(t, conf, "error_page 418 = @kt_fault_0;")
+	require.Contains(t, conf, "proxy_pass  http://tomcat-kt-mesh-def-80;")
+	require.Contains(t, conf, "if ($kt_to_stuntman) {\n            error_page 418 = @kt_fault_1;")
+	require.Contains(t, conf, "return 503;")
+	require.Contains(t, conf, "return 444;")
+	require.Contains(t, conf, "set $kt_next  \"@kt_upstream_0\";")
+	require.Equal(t, 1, strings.Count(conf, "location @kt_upstream_"))
+}
+
+func TestRouteTemplateWithFailover(t *testing.T) {
+	tmpl, err := template.New("route").Parse(routeTemplate)
+	require.Nil(t, err)
+	var buf strings.Builder
+	require.Nil(t, tmpl.Execute(&buf, &KtConf{
+		Service:  "tomcat",
+		Ports:    [][]string{{"80", "8080"}},
+		Header:   "version",
+		Versions: []string{"abc", "def"},
+		Watches:  []Watch{{Version: "abc", Failover: true}},
+	}))
+	conf := buf.String()
+	require.Contains(t, conf, "server tomcat-kt-mesh-abc:80 max_fails=1 fail_timeout=10s;\n  server tomcat-kt-stuntman:80 backup;")
+	require.Contains(t, conf, "server tomcat-kt-mesh-def:80;")
+	require.Equal(t, 1, strings.Count(conf, " backup;"))
+
+	buf.Reset()
+	require.Nil(t, tmpl.Execute(&buf, &KtConf{Service: "tomcat", Ports: [][]string{{"80", "8080"}}, Header: "version"}))
+	require.Contains(t, buf.String(), "proxy_pass  http://tomcat-kt-stuntman-80;")
+}
+
+func TestRouteTemplateWithFractionalPercent(t *testing.T) {
+	tmpl, err := template.New("route").Parse(routeTemplate)
+	require.Nil(t, err)
+	fault, err := ParseFault("abc", "delay=1s:0.05%,abort=503:12.5%")
+	require.Nil(t, err)
+	var buf strings.Builder
+	require.Nil(t, tmpl.Execute(&buf, &KtConf{
+		Service:  "tomcat",
+		Ports:    [][]string{{"80", "8080"}},
+		Header:   "version",
+		Versions: []string{"abc"},
+		Faults:   []Fault{*fault},
+	}))
+	conf := buf.String()
+	require.Contains(t, conf, "0.05%  1000;")
+	require.Contains(t, conf, "12.5%  1;")
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultConnectTimeout time to wait for establishing connection with upstream
+	DefaultConnectTimeout = "10s"
+	// DefaultProxyTimeout time to wait between two successive read or write operations, long enough for websocket
+	DefaultProxyTimeout = "1h"
+	// DefaultMaxBodySize request body size not limited by default
+	DefaultMaxBodySize = "0"
+)
+
+var timePattern = regexp.MustCompile(`^[0-9]+(ms|s|m|h|d)?$`)
+var sizePattern = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+
+// ApplyProxyOption set timeout or body size limit from option like 'read-timeout=1h',
+// return false if it's not a proxy option
+func (c *KtConf) ApplyProxyOption(option string) (bool, error) {
+	kv := strings.SplitN(option, "=", 2)
+	if len(kv) != 2 {
+		return false, nil
+	}
+	var target *string
+	pattern := timePattern
+	switch kv[0] {
+	case "connect-timeout":
+		target = &c.ConnectTimeout
+	case "read-timeout":
+		target = &c.ReadTimeout
+	case "send-timeout":
+		target = &c.SendTimeout
+	case "max-body-size":
+		target, pattern = &c.MaxBodySize, sizePattern
+	default:
+		return false, nil
+	}
+	if !pattern.MatchString(kv[1]) {
+		return true, fmt.Errorf("invalid value of %s: '%s'", kv[0], kv[1])
+	}
+	*target = kv[1]
+	return true, nil
+}
+
+// SetProxyDefaults fill proxy settings not specified with default values
+func (c *KtConf) SetProxyDefaults() {
+	if c.ConnectTimeout == "" {
+		c.ConnectTimeout = DefaultConnectTimeout
+	}
+	if c.ReadTimeout == "" {
+		c.ReadTimeout = DefaultProxyTimeout
+	}
+	if c.SendTimeout == "" {
+		c.SendTimeout = DefaultProxyTimeout
+	}
+	if c.MaxBodySize == "" {
+		c.MaxBodySize = DefaultMaxBodySize
+	}
+}
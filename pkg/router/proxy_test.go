@@ -0,0 +1,25 @@
+package router
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestApplyProxyOption(t *testing.T) {
+	ktConf := &KtConf{}
+	for _, option := range []string{"read-timeout=30m", "max-body-size=100m", "mirror", "expire=5"} {
+		_, err := ktConf.ApplyProxyOption(option)
+		require.Nil(t, err, option)
+	}
+	ktConf.SetProxyDefaults()
+	require.Equal(t, "30m", ktConf.ReadTimeout)
+	require.Equal(t, DefaultProxyTimeout, ktConf.SendTimeout)
+	require.Equal(t, DefaultConnectTimeout, ktConf.ConnectTimeout)
+	require.Equal(t, "100m", ktConf.MaxBodySize)
+
+	for _, option := range []string{"send-timeout=1 h", "connect-timeout=", "max-body-size=1t"} {
+		ok, err := ktConf.ApplyProxyOption(option)
+		require.True(t, ok, option)
+		require.NotNil(t, err, option)
+	}
+}
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	// SessionPath reserved path of router, which sets routing cookie and redirects back
+	SessionPath = "/.kt/session"
+	// sessionCookiePrefix prefix of routing cookie name, avoid conflicting with cookies of the service
+	sessionCookiePrefix = "kt_"
+)
+
+// SessionPath reserved path of router, for rendering route config
+func (c *KtConf) SessionPath() string {
+	return SessionPath
+}
+
+// SessionCookie name of cookie which routes requests like the version header
+func (c *KtConf) SessionCookie() string {
+	return SessionCookie(c.Header)
+}
+
+// SessionCookie name of routing cookie for the header, in which '-' is already replaced with '_'
+func SessionCookie(header string) string {
+	return sessionCookiePrefix + header
+}
+
+// SessionUrl url of router to let browser carry routing cookie of the version
+func SessionUrl(host string, port int, version string) string {
+	if port != 80 {
+		host = fmt.Sprintf("%s:%d", host, port)
+	}
+	return fmt.Sprintf("http://%s%s?version=%s", host, SessionPath, url.QueryEscape(version))
+}
@@ -0,0 +1,12 @@
+package router
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSessionUrl(t *testing.T) {
+	require.Equal(t, "http://tomcat.default/.kt/session?version=abc", SessionUrl("tomcat.default", 80, "abc"))
+	require.Equal(t, "http://tomcat.default:8080/.kt/session?version=a+b", SessionUrl("tomcat.default", 8080, "a b"))
+	require.Equal(t, "kt_kt_mark", (&KtConf{Header: "kt_mark"}).SessionCookie())
+}
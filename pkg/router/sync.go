@@ -0,0 +1,70 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// ConfigDir directory of router pod where configmap holding route configuration mounted
+	ConfigDir = "/etc/kt-router"
+	// ConfigKey key of route configuration in configmap
+	ConfigKey = "kt.conf"
+)
+
+// Syncer load route configuration managed by ktctl, excluding versions expired by watchdog
+type Syncer struct {
+	path    string
+	loaded  []byte
+	applied []byte
+	expired map[string]bool
+}
+
+// NewSyncer create syncer reading route configuration from mounted configmap
+func NewSyncer() *Syncer {
+	return &Syncer{path: filepath.Join(ConfigDir, ConfigKey), expired: map[string]bool{}}
+}
+
+// Load read route configuration, and check whether it changed since last applied
+func (s *Syncer) Load() (*KtConf, bool, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read route configuration: %s", err)
+	}
+	var ktConf KtConf
+	if err = json.Unmarshal(data, &ktConf); err != nil {
+		return nil, false, fmt.Errorf("failed to parse route configuration: %s", err)
+	}
+	s.loaded = data
+	for version := range s.expired {
+		if !contains(ktConf.Versions, version) && !contains(ktConf.Mirrors, version) {
+			// already removed by ktctl, the same version could be added again later
+			delete(s.expired, version)
+		} else {
+			ktConf.Remove(version)
+		}
+	}
+	return &ktConf, !bytes.Equal(data, s.applied), nil
+}
+
+// Commit mark last loaded configuration as applied
+func (s *Syncer) Commit() {
+	s.applied = s.loaded
+}
+
+// Expire exclude the version from route until it's removed from configuration
+func (s *Syncer) Expire(version string) {
+	s.expired[version] = true
+}
+
+func contains(versions []string, version string) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package router
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncerLoad(t *testing.T) {
+	syncer := NewSyncer()
+	syncer.path = filepath.Join(t.TempDir(), ConfigKey)
+	_, _, err := syncer.Load()
+	require.NotNil(t, err)
+
+	ktConf := &KtConf{Service: "tomcat", Ports: [][]string{{"80", "8080"}}, Header: "version"}
+	require.Nil(t, ktConf.AddVersion("version", "a", false, nil, &Watch{Version: "a", Expire: 5}))
+	require.Nil(t, ktConf.AddVersion("version", "b", false, nil, nil))
+	writeConfig(t, syncer.path, ktConf)
+
+	loaded, changed, err := syncer.Load()
+	require.Nil(t, err)
+	require.True(t, changed)
+	require.Equal(t, []string{"a", "b"}, loaded.Versions)
+	syncer.Commit()
+	_, changed, _ = syncer.Load()
+	require.False(t, changed)
+
+	// expired version is excluded until removed by ktctl
+	syncer.Expire("a")
+	loaded, changed, _ = syncer.Load()
+	require.False(t, changed)
+	require.Equal(t, []string{"b"}, loaded.Versions)
+	require.Empty(t, loaded.Watches)
+
+	ktConf.Remove("a")
+	writeConfig(t, syncer.path, ktConf)
+	_, changed, _ = syncer.Load()
+	require.True(t, changed)
+	syncer.Commit()
+	require.Nil(t, ktConf.AddVersion("version", "a", false, nil, nil))
+	writeConfig(t, syncer.path, ktConf)
+	loaded, changed, _ = syncer.Load()
+	require.True(t, changed)
+	require.Equal(t, []string{"b", "a"}, loaded.Versions)
+}
+
+func TestAddVersion(t *testing.T) {
+	ktConf := &KtConf{Header: "version"}
+	require.NotNil(t, ktConf.AddVersion("kt_version", "a", false, nil, nil))
+	require.Nil(t, ktConf.AddVersion("kt_version", "m", true, &Fault{Version: "m", Stuntman: true, Drop: true}, nil))
+	require.Equal(t, []string{"m"}, ktConf.Mirrors)
+	require.NotNil(t, ktConf.AddVersion("version", "a", false, &Fault{Version: "a", Stuntman: true, Abort: 503}, nil))
+	require.Nil(t, ktConf.AddVersion("version", "a", false, &Fault{Version: "a", Abort: 503}, nil))
+	require.Equal(t, []string{"a"}, ktConf.Versions)
+	require.Equal(t, 2, len(ktConf.Faults))
+}
+
+func writeConfig(t *testing.T, path string, ktConf *KtConf) {
+	data, err := json.Marshal(ktConf)
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(path, data, 0644))
+}
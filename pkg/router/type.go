@@ -1,8 +1,87 @@
 package router
 
+import (
+	"fmt"
+	"strings"
+)
+
 type KtConf struct {
-	Service  string
-	Ports    [][]string
-	Header   string
-	Versions []string
+	Service        string
+	Ports          [][]string
+	Header         string
+	Versions       []string
+	Mirrors        []string
+	Faults         []Fault
+	Watches        []Watch
+	ConnectTimeout string
+	ReadTimeout    string
+	SendTimeout    string
+	MaxBodySize    string
+}
+
+// SplitVersionMark split version mark into header used in nginx variable and version
+func SplitVersionMark(mark string) (string, string) {
+	splits := strings.Split(mark, ":")
+	return strings.ReplaceAll(splits[0], "-", "_"), splits[1]
+}
+
+// ParsePorts parse ports parameter like '80:8080,70:7000'
+func ParsePorts(portsParameter string) [][]string {
+	ports := make([][]string, 0)
+	for _, pp := range strings.Split(portsParameter, ",") {
+		ports = append(ports, strings.Split(pp, ":"))
+	}
+	return ports
+}
+
+// AddVersion route requests with version header to mesh service of the version, or copy requests to it in mirror mode
+func (c *KtConf) AddVersion(header, version string, mirror bool, fault *Fault, watch *Watch) error {
+	// mirrored requests are not routed by header
+	if !mirror && c.Header != header {
+		return fmt.Errorf("specified header '%s' no match mesh pod header '%s'", header, c.Header)
+	}
+	if i := c.StuntmanFaultIndex(); fault != nil && fault.Stuntman && i >= 0 {
+		return fmt.Errorf("faults to original pods already injected by version '%s'", c.Faults[i].Version)
+	}
+	if mirror {
+		c.Mirrors = append(c.Mirrors, version)
+	} else {
+		c.Versions = append(c.Versions, version)
+	}
+	if fault != nil {
+		c.Faults = append(c.Faults, *fault)
+	}
+	if watch != nil {
+		c.Watches = append(c.Watches, *watch)
+	}
+	return nil
+}
+
+// Remove delete routes, faults and health checking of specified version
+func (c *KtConf) Remove(version string) {
+	c.Versions = removeVersion(c.Versions, version)
+	c.Mirrors = removeVersion(c.Mirrors, version)
+	faults := make([]Fault, 0)
+	for _, f := range c.Faults {
+		if f.Version != version {
+			faults = append(faults, f)
+		}
+	}
+	c.Faults = faults
+	watches := make([]Watch, 0)
+	for _, w := range c.Watches {
+		if w.Version != version {
+			watches = append(watches, w)
+		}
+	}
+	c.Watches = watches
+}
+
+func removeVersion(versions []string, version string) []string {
+	for i, v := range versions {
+		if v == version {
+			return append(versions[:i], versions[i+1:]...)
+		}
+	}
+	return versions
 }
@@ -0,0 +1,78 @@
+package router
+
+import (
+	"net"
+	"time"
+)
+
+// probeTimeout time to wait for connecting mesh service
+const probeTimeout = 3 * time.Second
+
+// Watch health checking settings of a mesh version
+type Watch struct {
+	Version  string
+	Failover bool
+	Expire   int
+}
+
+// Watchdog find versions whose mesh service keeps unreachable longer than expire minutes
+type Watchdog struct {
+	lastHealthy map[string]time.Time
+	probe       func(address string) error
+}
+
+// NewWatchdog create watchdog probing mesh services via tcp connection
+func NewWatchdog() *Watchdog {
+	return &Watchdog{
+		lastHealthy: map[string]time.Time{},
+		probe: func(address string) error {
+			conn, err := net.DialTimeout("tcp", address, probeTimeout)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+}
+
+// Check probe mesh service of each watched version, and return versions expired
+func (w *Watchdog) Check(ktConf *KtConf, now time.Time) []string {
+	var expired []string
+	watching := map[string]bool{}
+	for _, watch := range ktConf.Watches {
+		if watch.Expire <= 0 || len(ktConf.Ports) == 0 {
+			continue
+		}
+		watching[watch.Version] = true
+		last, ok := w.lastHealthy[watch.Version]
+		// newly added version is considered healthy at the beginning
+		if !ok || w.probe(ktConf.MeshAddress(watch.Version)) == nil {
+			w.lastHealthy[watch.Version] = now
+			continue
+		}
+		if now.Sub(last) >= time.Duration(watch.Expire)*time.Minute {
+			expired = append(expired, watch.Version)
+		}
+	}
+	for version := range w.lastHealthy {
+		if !watching[version] {
+			delete(w.lastHealthy, version)
+		}
+	}
+	return expired
+}
+
+// MeshAddress address of the mesh service of specified version
+func (c *KtConf) MeshAddress(version string) string {
+	return net.JoinHostPort(c.Service+"-kt-mesh-"+version, c.Ports[0][0])
+}
+
+// IsFailover whether requests of specified version go to original pods when mesh service unreachable
+func (c *KtConf) IsFailover(version string) bool {
+	for _, watch := range c.Watches {
+		if watch.Version == version {
+			return watch.Failover
+		}
+	}
+	return false
+}
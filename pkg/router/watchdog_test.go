@@ -0,0 +1,40 @@
+package router
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestWatchdogCheck(t *testing.T) {
+	reachable := map[string]bool{}
+	w := NewWatchdog()
+	w.probe = func(address string) error {
+		if reachable[address] {
+			return nil
+		}
+		return fmt.Errorf("connection refused")
+	}
+	ktConf := &KtConf{
+		Service:  "tomcat",
+		Ports:    [][]string{{"80", "8080"}},
+		Versions: []string{"abc", "def", "xyz"},
+		Watches:  []Watch{{Version: "abc", Expire: 5}, {Version: "def", Expire: 5, Failover: true}, {Version: "xyz"}},
+	}
+	reachable["tomcat-kt-mesh-abc:80"] = true
+	now := time.Now()
+	require.Empty(t, w.Check(ktConf, now))
+	require.Empty(t, w.Check(ktConf, now.Add(4*time.Minute)))
+	require.Equal(t, []string{"def"}, w.Check(ktConf, now.Add(5*time.Minute)))
+
+	ktConf.Remove("def")
+	require.Equal(t, []string{"abc", "xyz"}, ktConf.Versions)
+	require.Equal(t, 2, len(ktConf.Watches))
+	require.Empty(t, w.Check(ktConf, now.Add(6*time.Minute)))
+	require.NotContains(t, w.lastHealthy, "def")
+
+	reachable["tomcat-kt-mesh-abc:80"] = false
+	require.Empty(t, w.Check(ktConf, now.Add(10*time.Minute)))
+	require.Equal(t, []string{"abc"}, w.Check(ktConf, now.Add(11*time.Minute)))
+}
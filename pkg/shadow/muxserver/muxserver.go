@@ -0,0 +1,203 @@
+package muxserver
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/common/mux"
+	"github.com/alibaba/kt-connect/pkg/shadow/readiness"
+	"github.com/alibaba/kt-connect/pkg/shadow/sshserver"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"sync"
+)
+
+// MuxServer tunnel server multiplexing all forward and reverse streams of a client over one connection
+type MuxServer struct {
+	authorizedKeysFile string
+	// remote listening port -> listen stream
+	listens map[int]*listen
+	lock    sync.Mutex
+}
+
+type listen struct {
+	session  *mux.Session
+	stream   *mux.Stream
+	listener net.Listener
+	address  string
+}
+
+// Start setup mux tunnel server
+func Start(port int, s *MuxServer) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to listen mux tunnel port %d", port)
+		return
+	}
+	if err = s.Serve(listener); err != nil {
+		log.Error().Err(err).Msgf("Mux tunnel server stopped")
+	}
+}
+
+// NewMuxServer create mux tunnel server
+func NewMuxServer(authorizedKeysFile string) *MuxServer {
+	return &MuxServer{
+		authorizedKeysFile: authorizedKeysFile,
+		listens:            map[int]*listen{},
+	}
+}
+
+// Serve accept tunnel connections on the listener
+func (s *MuxServer) Serve(listener net.Listener) error {
+	defer listener.Close()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConnection(conn)
+	}
+}
+
+func (s *MuxServer) handleConnection(conn net.Conn) {
+	err := mux.ServerHandshake(conn, func(key ssh.PublicKey) bool {
+		return sshserver.IsAuthorizedKey(s.authorizedKeysFile, key)
+	})
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to handshake with %s", conn.RemoteAddr())
+		_ = conn.Close()
+		return
+	}
+	log.Info().Msgf("Mux tunnel from %s established", conn.RemoteAddr())
+	session := mux.Server(conn, mux.DefaultConfig())
+	for {
+		stream, err2 := session.Accept()
+		if err2 != nil {
+			log.Info().Msgf("Mux tunnel from %s closed: %s", conn.RemoteAddr(), err2)
+			return
+		}
+		go s.handleStream(session, stream)
+	}
+}
+
+func (s *MuxServer) handleStream(session *mux.Session, stream *mux.Stream) {
+	req, err := mux.ParseRequest(stream.Request())
+	if err != nil {
+		_ = stream.Refuse(err.Error())
+		return
+	}
+	switch req.Kind {
+	case mux.RequestDial:
+		target, err2 := net.Dial("tcp", req.Address)
+		if err2 != nil {
+			log.Debug().Err(err2).Msgf("Failed to dial %s", req.Address)
+			_ = stream.Refuse(err2.Error())
+			return
+		}
+		if err2 = stream.Confirm(); err2 != nil {
+			_ = target.Close()
+			return
+		}
+		mux.Pipe(stream, target)
+	case mux.RequestListen:
+		s.handleListen(session, stream, req.Address)
+	default:
+		_ = stream.Refuse(fmt.Sprintf("unsupported request '%s'", req.Kind))
+	}
+}
+
+// handleListen listen the address until the stream closed by client
+func (s *MuxServer) handleListen(session *mux.Session, stream *mux.Stream, address string) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		if port, err2 := parsePort(address); err2 == nil && s.ReleasePort(port) {
+			// port was still held by a previous connection, retry after released
+			listener, err = net.Listen("tcp", address)
+		}
+	}
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to listen %s", address)
+		_ = stream.Refuse(err.Error())
+		return
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	l := &listen{session: session, stream: stream, listener: listener, address: address}
+	s.lock.Lock()
+	s.listens[port] = l
+	s.lock.Unlock()
+	if err = stream.Confirm(); err != nil {
+		s.ReleasePort(port)
+		return
+	}
+	log.Info().Msgf("Listening port %d for %s", port, session.RemoteAddr())
+
+	go func() {
+		// client close the stream to cancel listening
+		_, _ = io.Copy(io.Discard, stream)
+		s.releaseListen(port, l)
+	}()
+	for {
+		conn, err2 := listener.Accept()
+		if err2 != nil {
+			log.Debug().Err(err2).Msgf("Stop listening port %d", port)
+			return
+		}
+		go func() {
+			forwarded, err3 := session.Open((&mux.Request{Kind: mux.RequestForwarded, Address: address}).Encode())
+			if err3 != nil {
+				log.Debug().Err(err3).Msgf("Failed to forward connection of port %d", port)
+				_ = conn.Close()
+				return
+			}
+			mux.Pipe(forwarded, conn)
+		}()
+	}
+}
+
+// CheckPort open a probe stream of the forwarded port, which is refused by client if local endpoint unreachable
+func (s *MuxServer) CheckPort(port int) error {
+	s.lock.Lock()
+	l, exists := s.listens[port]
+	s.lock.Unlock()
+	if !exists {
+		return readiness.ErrNotForwarded
+	}
+	probe, err := l.session.Open((&mux.Request{Kind: mux.RequestForwarded, Address: l.address}).Encode())
+	if err != nil {
+		return err
+	}
+	return probe.Close()
+}
+
+// ReleasePort stop listening specified port, return false if the port is not listened by mux server
+func (s *MuxServer) ReleasePort(port int) bool {
+	s.lock.Lock()
+	l, exists := s.listens[port]
+	s.lock.Unlock()
+	if !exists {
+		return false
+	}
+	return s.releaseListen(port, l)
+}
+
+func (s *MuxServer) releaseListen(port int, l *listen) bool {
+	s.lock.Lock()
+	if s.listens[port] != l {
+		s.lock.Unlock()
+		return false
+	}
+	delete(s.listens, port)
+	s.lock.Unlock()
+	_ = l.listener.Close()
+	_ = l.stream.Close()
+	log.Info().Msgf("Port %d released", port)
+	return true
+}
+
+func parsePort(address string) (int, error) {
+	addr, err := net.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return 0, err
+	}
+	return addr.Port, nil
+}
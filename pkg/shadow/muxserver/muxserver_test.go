@@ -0,0 +1,187 @@
+package muxserver
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/common/mux"
+	"github.com/alibaba/kt-connect/pkg/shadow/readiness"
+	"github.com/alibaba/kt-connect/pkg/shadow/shadowtest"
+	"github.com/alibaba/kt-connect/pkg/shadow/sshserver"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"testing"
+)
+
+func listenLocal(tb testing.TB) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(tb, err)
+	tb.Cleanup(func() { _ = listener.Close() })
+	return listener
+}
+
+func connectMux(tb testing.TB, signer ssh.Signer, keyFile string) (*MuxServer, *mux.Session) {
+	s := NewMuxServer(keyFile)
+	listener := listenLocal(tb)
+	go func() { _ = s.Serve(listener) }()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.Nil(tb, err)
+	require.Nil(tb, mux.ClientHandshake(conn, signer))
+	session := mux.Client(conn, mux.DefaultConfig())
+	tb.Cleanup(func() { _ = session.Close() })
+	return s, session
+}
+
+func connectSsh(tb testing.TB, signer ssh.Signer, keyFile string) *ssh.Client {
+	s, err := sshserver.NewSshServer(keyFile)
+	require.Nil(tb, err)
+	listener := listenLocal(tb)
+	go func() { _ = s.Serve(listener) }()
+	client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{
+		User:            "root",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	require.Nil(tb, err)
+	tb.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func roundTrip(tb testing.TB, conn net.Conn, data []byte) {
+	_, err := conn.Write(data)
+	require.Nil(tb, err)
+	_, err = io.ReadFull(conn, data)
+	require.Nil(tb, err)
+}
+
+func TestDial(t *testing.T) {
+	signer, keyFile := shadowtest.PrepareKey(t)
+	_, session := connectMux(t, signer, keyFile)
+	echo := shadowtest.StartEchoServer(t)
+
+	st, err := session.Open((&mux.Request{Kind: mux.RequestDial, Address: echo}).Encode())
+	require.Nil(t, err)
+	data := []byte("hello mux")
+	roundTrip(t, st, data)
+	require.Equal(t, "hello mux", string(data))
+	_ = st.Close()
+}
+
+func TestListenAndRelease(t *testing.T) {
+	signer, keyFile := shadowtest.PrepareKey(t)
+	s, session := connectMux(t, signer, keyFile)
+	port := freePort(t)
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+
+	listenStream, err := session.Open((&mux.Request{Kind: mux.RequestListen, Address: address}).Encode())
+	require.Nil(t, err)
+	go func() {
+		st, err2 := session.Accept()
+		require.Nil(t, err2)
+		req, err2 := mux.ParseRequest(st.Request())
+		require.Nil(t, err2)
+		require.Equal(t, mux.RequestForwarded, req.Kind)
+		require.Equal(t, address, req.Address)
+		_ = st.Confirm()
+		_, _ = io.Copy(st, st)
+		_ = st.Close()
+	}()
+
+	conn, err := net.Dial("tcp", address)
+	require.Nil(t, err)
+	data := []byte("hello reverse")
+	roundTrip(t, conn, data)
+	require.Equal(t, "hello reverse", string(data))
+	_ = conn.Close()
+
+	// listening port held by stale stream should be taken over
+	_, err = session.Open((&mux.Request{Kind: mux.RequestListen, Address: address}).Encode())
+	require.Nil(t, err)
+	_, err = io.Copy(io.Discard, listenStream)
+	require.Nil(t, err)
+	require.False(t, s.ReleasePort(port+1))
+}
+
+func TestCheckPort(t *testing.T) {
+	signer, keyFile := shadowtest.PrepareKey(t)
+	s, session := connectMux(t, signer, keyFile)
+	port := freePort(t)
+
+	_, err := session.Open((&mux.Request{Kind: mux.RequestListen, Address: fmt.Sprintf("127.0.0.1:%d", port)}).Encode())
+	require.Nil(t, err)
+	go func() {
+		st, err2 := session.Accept()
+		require.Nil(t, err2)
+		_ = st.Refuse("connection refused")
+		st, err2 = session.Accept()
+		require.Nil(t, err2)
+		_ = st.Confirm()
+	}()
+
+	require.NotNil(t, s.CheckPort(port))
+	require.Nil(t, s.CheckPort(port))
+	require.Equal(t, readiness.ErrNotForwarded, s.CheckPort(port+1))
+}
+
+func freePort(tb testing.TB) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(tb, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func BenchmarkSshDialRoundTrip(b *testing.B) {
+	signer, keyFile := shadowtest.PrepareKey(b)
+	client := connectSsh(b, signer, keyFile)
+	echo := shadowtest.StartEchoServer(b)
+	data := make([]byte, 1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		conn, err := client.Dial("tcp", echo)
+		require.Nil(b, err)
+		roundTrip(b, conn, data)
+		_ = conn.Close()
+	}
+}
+
+func BenchmarkMuxDialRoundTrip(b *testing.B) {
+	signer, keyFile := shadowtest.PrepareKey(b)
+	_, session := connectMux(b, signer, keyFile)
+	echo := shadowtest.StartEchoServer(b)
+	data := make([]byte, 1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		st, err := session.Open((&mux.Request{Kind: mux.RequestDial, Address: echo}).Encode())
+		require.Nil(b, err)
+		roundTrip(b, st, data)
+		_ = st.Close()
+	}
+}
+
+func BenchmarkSshThroughput(b *testing.B) {
+	signer, keyFile := shadowtest.PrepareKey(b)
+	client := connectSsh(b, signer, keyFile)
+	conn, err := client.Dial("tcp", shadowtest.StartEchoServer(b))
+	require.Nil(b, err)
+	defer conn.Close()
+	data := make([]byte, 32*1024)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		roundTrip(b, conn, data)
+	}
+}
+
+func BenchmarkMuxThroughput(b *testing.B) {
+	signer, keyFile := shadowtest.PrepareKey(b)
+	_, session := connectMux(b, signer, keyFile)
+	st, err := session.Open((&mux.Request{Kind: mux.RequestDial, Address: shadowtest.StartEchoServer(b)}).Encode())
+	require.Nil(b, err)
+	defer st.Close()
+	data := make([]byte, 32*1024)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		roundTrip(b, st, data)
+	}
+}
@@ -0,0 +1,68 @@
+package readiness
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrNotForwarded the port is not listened by the checker
+var ErrNotForwarded = errors.New("not forwarded")
+
+// Checker check whether connection to a remote listening port could reach the local endpoint it forwarded to
+type Checker interface {
+	CheckPort(port int) error
+}
+
+// Start serve readiness probe of shadow pod
+func Start(port int, path string, exposePorts []int, checkers ...Checker) {
+	mux := http.NewServeMux()
+	mux.Handle(path, Handler(exposePorts, checkers...))
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux); err != nil {
+		log.Error().Err(err).Msgf("Readiness probe server stopped")
+	}
+}
+
+// Handler report ready only when all exposed ports are reachable via any of the checkers,
+// ports are checked concurrently, so that probe duration won't grow with the number of exposed ports
+func Handler(exposePorts []int, checkers ...Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		results := make([]error, len(exposePorts))
+		var wg sync.WaitGroup
+		for i, port := range exposePorts {
+			wg.Add(1)
+			go func(i, port int) {
+				defer wg.Done()
+				results[i] = checkPort(port, checkers)
+			}(i, port)
+		}
+		wg.Wait()
+		var failures []string
+		for _, err := range results {
+			if err != nil {
+				failures = append(failures, err.Error())
+			}
+		}
+		if len(failures) > 0 {
+			log.Debug().Msgf("Shadow not ready: %s", strings.Join(failures, "; "))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(strings.Join(failures, "\n")))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+func checkPort(port int, checkers []Checker) error {
+	for _, c := range checkers {
+		if err := c.CheckPort(port); err == nil {
+			return nil
+		} else if err != ErrNotForwarded {
+			return fmt.Errorf("port %d %s", port, err)
+		}
+	}
+	return fmt.Errorf("port %d %s", port, ErrNotForwarded)
+}
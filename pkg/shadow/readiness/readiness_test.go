@@ -0,0 +1,59 @@
+package readiness
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeChecker map[int]error
+
+type slowChecker time.Duration
+
+func (c slowChecker) CheckPort(port int) error {
+	time.Sleep(time.Duration(c))
+	return nil
+}
+
+func (c fakeChecker) CheckPort(port int) error {
+	if err, exists := c[port]; exists {
+		return err
+	}
+	return ErrNotForwarded
+}
+
+func probe(exposePorts []int, checkers ...Checker) (int, string) {
+	recorder := httptest.NewRecorder()
+	Handler(exposePorts, checkers...).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	return recorder.Code, recorder.Body.String()
+}
+
+func TestHandler(t *testing.T) {
+	ssh := fakeChecker{8080: nil, 9090: fmt.Errorf("local endpoint unreachable")}
+	mux := fakeChecker{7001: nil}
+
+	code, body := probe([]int{8080, 7001}, ssh, mux)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ok", body)
+
+	code, body = probe([]int{8080, 9090}, ssh, mux)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "port 9090 local endpoint unreachable", body)
+
+	code, body = probe([]int{8000}, ssh, mux)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "port 8000 not forwarded", body)
+
+	code, _ = probe(nil, ssh, mux)
+	require.Equal(t, http.StatusOK, code)
+}
+
+func TestHandlerManyPorts(t *testing.T) {
+	start := time.Now()
+	code, _ := probe([]int{8001, 8002, 8003, 8004, 8005, 8006}, slowChecker(500*time.Millisecond))
+	require.Equal(t, http.StatusOK, code)
+	require.Less(t, time.Since(start), 2*time.Second)
+}
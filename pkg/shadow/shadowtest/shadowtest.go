@@ -0,0 +1,56 @@
+// Package shadowtest fixtures shared by tests of tunnel servers in shadow pod
+package shadowtest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// NewKey generate an ed25519 client key
+func NewKey(tb testing.TB) ssh.Signer {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(tb, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.Nil(tb, err)
+	return signer
+}
+
+// PrepareKey generate client key, and an authorized_keys file containing it
+func PrepareKey(tb testing.TB) (ssh.Signer, string) {
+	signer := NewKey(tb)
+	keyFile := filepath.Join(tb.TempDir(), "authorized_keys")
+	require.Nil(tb, os.WriteFile(keyFile, ssh.MarshalAuthorizedKey(signer.PublicKey()), 0644))
+	return signer, keyFile
+}
+
+// ServeEcho send back data of every connection accepted by the listener, until the listener closed
+func ServeEcho(listener net.Listener) {
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+}
+
+// StartEchoServer listen a local port which sends back received data, return its address
+func StartEchoServer(tb testing.TB) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(tb, err)
+	tb.Cleanup(func() { _ = listener.Close() })
+	ServeEcho(listener)
+	return listener.Addr().String()
+}
@@ -0,0 +1,186 @@
+package sshserver
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/common"
+	"github.com/alibaba/kt-connect/pkg/shadow/readiness"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// probeTimeout channel still open after this duration means local endpoint is connected
+const probeTimeout = 1 * time.Second
+
+// forwarder a remote listening port and the ssh connection it belongs to
+type forwarder struct {
+	conn     *ssh.ServerConn
+	listener net.Listener
+	bindHost string
+	bindPort uint32
+}
+
+// tcpipForwardPayload RFC 4254 7.1
+type tcpipForwardPayload struct {
+	Host string
+	Port uint32
+}
+
+func (s *SshServer) handleTcpipForward(conn *ssh.ServerConn, req *ssh.Request) {
+	var payload tcpipForwardPayload
+	if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+		_ = req.Reply(false, nil)
+		return
+	}
+	bindHost := payload.Host
+	if bindHost == "" || bindHost == "localhost" {
+		// same as "GatewayPorts yes" of openssh
+		bindHost = "0.0.0.0"
+	}
+	address := net.JoinHostPort(bindHost, strconv.Itoa(int(payload.Port)))
+	listener, err := net.Listen("tcp", address)
+	if err != nil && s.ReleasePort(int(payload.Port)) {
+		// port was still held by a previous connection, retry after released
+		listener, err = net.Listen("tcp", address)
+	}
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to listen %s", address)
+		_ = req.Reply(false, nil)
+		return
+	}
+	port := uint32(listener.Addr().(*net.TCPAddr).Port)
+	f := &forwarder{
+		conn:     conn,
+		listener: listener,
+		bindHost: payload.Host,
+		bindPort: port,
+	}
+	s.lock.Lock()
+	s.forwards[int(port)] = f
+	s.lock.Unlock()
+
+	var reply []byte
+	if payload.Port == 0 {
+		reply = ssh.Marshal(struct{ Port uint32 }{port})
+	}
+	_ = req.Reply(true, reply)
+	log.Info().Msgf("Listening port %d for %s", port, conn.RemoteAddr())
+	go s.acceptForward(f)
+}
+
+func (s *SshServer) handleCancelTcpipForward(req *ssh.Request) {
+	var payload tcpipForwardPayload
+	if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+		_ = req.Reply(false, nil)
+		return
+	}
+	_ = req.Reply(s.ReleasePort(int(payload.Port)), nil)
+}
+
+func (s *SshServer) acceptForward(f *forwarder) {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			log.Debug().Err(err).Msgf("Stop listening port %d", f.bindPort)
+			return
+		}
+		go func() {
+			originHost, originPort := splitHostPort(conn.RemoteAddr())
+			payload := ssh.Marshal(&tcpipChannelPayload{
+				Host:       f.bindHost,
+				Port:       f.bindPort,
+				OriginHost: originHost,
+				OriginPort: originPort,
+			})
+			channel, requests, err2 := f.conn.OpenChannel("forwarded-tcpip", payload)
+			if err2 != nil {
+				log.Debug().Err(err2).Msgf("Failed to open forwarded channel for port %d", f.bindPort)
+				_ = conn.Close()
+				return
+			}
+			go ssh.DiscardRequests(requests)
+			pipe(channel, conn)
+		}()
+	}
+}
+
+// CheckPort open a probe channel of the forwarded port, ssh client accepts channel before dialing local endpoint,
+// and closes it immediately if the dialing failed
+func (s *SshServer) CheckPort(port int) error {
+	s.lock.Lock()
+	f, exists := s.forwards[port]
+	s.lock.Unlock()
+	if !exists {
+		return readiness.ErrNotForwarded
+	}
+	payload := ssh.Marshal(&tcpipChannelPayload{
+		Host:       f.bindHost,
+		Port:       f.bindPort,
+		OriginHost: "127.0.0.1",
+		OriginPort: uint32(common.ReadinessPort),
+	})
+	channel, requests, err := f.conn.OpenChannel("forwarded-tcpip", payload)
+	if err != nil {
+		return fmt.Errorf("tunnel unavailable: %s", err)
+	}
+	go ssh.DiscardRequests(requests)
+	defer channel.Close()
+	res := make(chan error, 1)
+	go func() {
+		_, err2 := channel.Read(make([]byte, 1))
+		res <- err2
+	}()
+	select {
+	case err = <-res:
+		if err == io.EOF {
+			return fmt.Errorf("local endpoint unreachable")
+		}
+		return err
+	case <-time.After(probeTimeout):
+		return nil
+	}
+}
+
+// ReleasePort stop listening specified port, return false if the port is not listened by ssh server
+func (s *SshServer) ReleasePort(port int) bool {
+	s.lock.Lock()
+	f, exists := s.forwards[port]
+	delete(s.forwards, port)
+	s.lock.Unlock()
+	if !exists {
+		return false
+	}
+	_ = f.listener.Close()
+	log.Info().Msgf("Port %d released", port)
+	return true
+}
+
+// releaseConnection release all ports listened by the closed connection
+func (s *SshServer) releaseConnection(conn *ssh.ServerConn) {
+	var ports []int
+	s.lock.Lock()
+	for port, f := range s.forwards {
+		if f.conn == conn {
+			ports = append(ports, port)
+		}
+	}
+	s.lock.Unlock()
+	for _, port := range ports {
+		s.ReleasePort(port)
+	}
+}
+
+func splitHostPort(addr net.Addr) (string, uint32) {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP.String(), uint32(tcpAddr.Port)
+	}
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String(), 0
+	}
+	p, _ := strconv.Atoi(port)
+	return host, uint32(p)
+}
@@ -0,0 +1,80 @@
+package sshserver
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ssh"
+	"os/exec"
+	"regexp"
+	"strconv"
+)
+
+// disconnectCommand compatible with the '/disconnect.sh <port>' script of legacy shadow image
+var disconnectCommand = regexp.MustCompile(`^(/disconnect\.sh|disconnect)\s+([0-9]+)\s*$`)
+
+type execPayload struct {
+	Command string
+}
+
+type exitStatusPayload struct {
+	Status uint32
+}
+
+func (s *SshServer) handleSession(newChannel ssh.NewChannel) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+	for req := range requests {
+		switch req.Type {
+		case "env":
+			// environment variables are not passed to command
+			_ = req.Reply(true, nil)
+		case "exec":
+			var payload execPayload
+			if err = ssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			status := s.execute(channel, payload.Command)
+			_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(&exitStatusPayload{status}))
+			return
+		default:
+			// interactive shell and pty are not supported
+			if req.WantReply {
+				_ = req.Reply(false, nil)
+			}
+		}
+	}
+}
+
+func (s *SshServer) execute(channel ssh.Channel, command string) uint32 {
+	if matches := disconnectCommand.FindStringSubmatch(command); matches != nil {
+		port, _ := strconv.Atoi(matches[2])
+		if s.ReleasePort(port) {
+			_, _ = fmt.Fprintf(channel, "port %d disconnected\n", port)
+		} else {
+			_, _ = fmt.Fprintf(channel, "no process using port %d\n", port)
+		}
+		return 0
+	}
+
+	log.Debug().Msgf("Executing command: %s", command)
+	// e.g. sshuttle needs to run its server side script
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = channel
+	cmd.Stdout = channel
+	cmd.Stderr = channel.Stderr()
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return uint32(exitErr.ExitCode())
+		}
+		_, _ = fmt.Fprintf(channel.Stderr(), "%s\n", err)
+		return 127
+	}
+	return 0
+}
@@ -0,0 +1,180 @@
+package sshserver
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"os"
+	"sync"
+)
+
+// SshServer ssh server which handles socks dialing, remote port listening and port release natively
+type SshServer struct {
+	authorizedKeysFile string
+	config             *ssh.ServerConfig
+	// remote listening port -> forwarder
+	forwards map[int]*forwarder
+	lock     sync.Mutex
+}
+
+// Start setup ssh server
+func Start(sshPort int, s *SshServer) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", sshPort))
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to listen ssh port %d", sshPort)
+		return
+	}
+	if err = s.Serve(listener); err != nil {
+		log.Error().Err(err).Msgf("Ssh server stopped")
+	}
+}
+
+// NewSshServer create ssh server with an ephemeral host key
+func NewSshServer(authorizedKeysFile string) (*SshServer, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	s := &SshServer{
+		authorizedKeysFile: authorizedKeysFile,
+		forwards:           map[int]*forwarder{},
+	}
+	s.config = &ssh.ServerConfig{
+		PublicKeyCallback: s.checkPublicKey,
+	}
+	s.config.AddHostKey(hostKey)
+	return s, nil
+}
+
+// Serve accept ssh connections on the listener
+func (s *SshServer) Serve(listener net.Listener) error {
+	defer listener.Close()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConnection(conn)
+	}
+}
+
+func (s *SshServer) checkPublicKey(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	if IsAuthorizedKey(s.authorizedKeysFile, key) {
+		return &ssh.Permissions{}, nil
+	}
+	return nil, fmt.Errorf("unknown public key for %s", meta.User())
+}
+
+// IsAuthorizedKey check whether key exists in authorized keys file
+// the file is read on every check, so that key mounted after startup also works
+func IsAuthorizedKey(authorizedKeysFile string, key ssh.PublicKey) bool {
+	content, err := os.ReadFile(authorizedKeysFile)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to read authorized keys")
+		return false
+	}
+	for len(content) > 0 {
+		authorizedKey, _, _, rest, err2 := ssh.ParseAuthorizedKey(content)
+		if err2 != nil {
+			break
+		}
+		if bytes.Equal(authorizedKey.Marshal(), key.Marshal()) {
+			return true
+		}
+		content = rest
+	}
+	return false
+}
+
+func (s *SshServer) handleConnection(c net.Conn) {
+	conn, channels, requests, err := ssh.NewServerConn(c, s.config)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to handshake with %s", c.RemoteAddr())
+		_ = c.Close()
+		return
+	}
+	log.Info().Msgf("Ssh connection from %s (%s) established", conn.RemoteAddr(), conn.User())
+	go s.handleGlobalRequests(conn, requests)
+	for newChannel := range channels {
+		switch newChannel.ChannelType() {
+		case "direct-tcpip":
+			go handleDirectTcpip(newChannel)
+		case "session":
+			go s.handleSession(newChannel)
+		default:
+			_ = newChannel.Reject(ssh.UnknownChannelType, fmt.Sprintf("unsupported channel type: %s", newChannel.ChannelType()))
+		}
+	}
+	s.releaseConnection(conn)
+	log.Info().Msgf("Ssh connection from %s closed", conn.RemoteAddr())
+}
+
+func (s *SshServer) handleGlobalRequests(conn *ssh.ServerConn, requests <-chan *ssh.Request) {
+	for req := range requests {
+		switch req.Type {
+		case "tcpip-forward":
+			s.handleTcpipForward(conn, req)
+		case "cancel-tcpip-forward":
+			s.handleCancelTcpipForward(req)
+		default:
+			// e.g. keepalive@openssh.com, reply false is the expected response
+			if req.WantReply {
+				_ = req.Reply(false, nil)
+			}
+		}
+	}
+}
+
+// tcpipChannelPayload RFC 4254 7.2, used by both direct-tcpip and forwarded-tcpip channel
+type tcpipChannelPayload struct {
+	Host       string
+	Port       uint32
+	OriginHost string
+	OriginPort uint32
+}
+
+func handleDirectTcpip(newChannel ssh.NewChannel) {
+	var payload tcpipChannelPayload
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		_ = newChannel.Reject(ssh.ConnectionFailed, "invalid payload")
+		return
+	}
+	address := net.JoinHostPort(payload.Host, fmt.Sprintf("%d", payload.Port))
+	target, err := net.Dial("tcp", address)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to dial %s", address)
+		_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		_ = target.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	pipe(channel, target)
+}
+
+// pipe copy data in both direction, and close both side when either side finished
+func pipe(channel ssh.Channel, conn net.Conn) {
+	var once sync.Once
+	closeBoth := func() {
+		_ = channel.Close()
+		_ = conn.Close()
+	}
+	go func() {
+		_, _ = io.Copy(channel, conn)
+		once.Do(closeBoth)
+	}()
+	_, _ = io.Copy(conn, channel)
+	once.Do(closeBoth)
+}
@@ -0,0 +1,140 @@
+package sshserver
+
+import (
+	"fmt"
+	"github.com/alibaba/kt-connect/pkg/shadow/readiness"
+	"github.com/alibaba/kt-connect/pkg/shadow/shadowtest"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"testing"
+)
+
+func startServer(t *testing.T, keyFile string) (*SshServer, string) {
+	s, err := NewSshServer(keyFile)
+	require.Nil(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(func() { _ = listener.Close() })
+	return s, listener.Addr().String()
+}
+
+func connect(t *testing.T, address string, key ssh.Signer) (*ssh.Client, error) {
+	return ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            "root",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(key)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+}
+
+func requireEcho(t *testing.T, conn net.Conn, text string) {
+	_, err := conn.Write([]byte(text))
+	require.Nil(t, err)
+	buf := make([]byte, len(text))
+	_, err = io.ReadFull(conn, buf)
+	require.Nil(t, err)
+	require.Equal(t, text, string(buf))
+}
+
+func TestAuthentication(t *testing.T) {
+	key, keyFile := shadowtest.PrepareKey(t)
+	_, address := startServer(t, keyFile)
+	client, err := connect(t, address, key)
+	require.Nil(t, err)
+	_ = client.Close()
+	_, err = connect(t, address, shadowtest.NewKey(t))
+	require.NotNil(t, err)
+}
+
+func TestDirectTcpip(t *testing.T) {
+	key, keyFile := shadowtest.PrepareKey(t)
+	_, address := startServer(t, keyFile)
+	echo := shadowtest.StartEchoServer(t)
+	client, err := connect(t, address, key)
+	require.Nil(t, err)
+	defer client.Close()
+
+	conn, err := client.Dial("tcp", echo)
+	require.Nil(t, err)
+	defer conn.Close()
+	requireEcho(t, conn, "hello direct")
+}
+
+func TestTcpipForwardAndDisconnect(t *testing.T) {
+	key, keyFile := shadowtest.PrepareKey(t)
+	_, address := startServer(t, keyFile)
+	client, err := connect(t, address, key)
+	require.Nil(t, err)
+	defer client.Close()
+
+	remoteListener, err := client.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	shadowtest.ServeEcho(remoteListener)
+	port := remoteListener.Addr().(*net.TCPAddr).Port
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	requireEcho(t, conn, "hello forward")
+	_ = conn.Close()
+
+	session, err := client.NewSession()
+	require.Nil(t, err)
+	out, err := session.Output(fmt.Sprintf("/disconnect.sh %d", port))
+	require.Nil(t, err)
+	require.Equal(t, fmt.Sprintf("port %d disconnected\n", port), string(out))
+
+	_, err = net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NotNil(t, err)
+}
+
+func TestReleasePortOnReconnect(t *testing.T) {
+	key, keyFile := shadowtest.PrepareKey(t)
+	s, address := startServer(t, keyFile)
+	staleClient, err := connect(t, address, key)
+	require.Nil(t, err)
+	defer staleClient.Close()
+	staleListener, err := staleClient.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	port := staleListener.Addr().(*net.TCPAddr).Port
+
+	// port still held by stale connection should be taken over by new connection
+	client, err := connect(t, address, key)
+	require.Nil(t, err)
+	defer client.Close()
+	_, err = client.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.Nil(t, err)
+	s.lock.Lock()
+	require.Equal(t, client.SessionID(), s.forwards[port].conn.SessionID())
+	s.lock.Unlock()
+	require.False(t, s.ReleasePort(port+1))
+}
+
+func TestCheckPort(t *testing.T) {
+	key, keyFile := shadowtest.PrepareKey(t)
+	s, address := startServer(t, keyFile)
+	client, err := connect(t, address, key)
+	require.Nil(t, err)
+	defer client.Close()
+
+	reachable, err := client.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	shadowtest.ServeEcho(reachable)
+	unreachable, err := client.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	go func() {
+		for {
+			conn, err2 := unreachable.Accept()
+			if err2 != nil {
+				return
+			}
+			// act like ssh client failed to dial local endpoint
+			_ = conn.Close()
+		}
+	}()
+
+	require.Nil(t, s.CheckPort(reachable.Addr().(*net.TCPAddr).Port))
+	require.NotNil(t, s.CheckPort(unreachable.Addr().(*net.TCPAddr).Port))
+	require.Equal(t, readiness.ErrNotForwarded, s.CheckPort(1))
+}